@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ravisastryk/chanopt/internal/driver"
+	"github.com/ravisastryk/chanopt/pkg/analyzer"
+)
+
+// ANSI escapes used for terminal output.
+const (
+	ansiReset = "\x1b[0m"
+	ansiBold  = "\x1b[1m"
+	ansiDim   = "\x1b[2m"
+	ansiCyan  = "\x1b[36m"
+	ansiGreen = "\x1b[32m"
+)
+
+// useColor reports whether output to f should be colorized: f must be a
+// terminal, and neither -no-color nor the NO_COLOR convention
+// (https://no-color.org) may be set.
+func useColor(f *os.File, noColor bool) bool {
+	if noColor || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// printFinding writes f in the vet format, "posn: message", followed by
+// its related positions. With color, the pattern name and suggested
+// replacement are highlighted and a confidence bar is added.
+func printFinding(w io.Writer, f driver.Finding, color bool) {
+	if !color {
+		fmt.Fprintf(w, "%s: %s%s\n", f.Posn, f.Message, ruleTag(f))
+		for _, r := range f.Related {
+			fmt.Fprintf(w, "\t%s: %s\n", r.Posn, r.Message)
+		}
+		return
+	}
+	msg := highlight(f.Message, f.Pattern, ansiBold+ansiCyan)
+	if repl := replacement(f.Pattern); repl != "" {
+		msg = highlight(msg, repl, ansiBold+ansiGreen)
+	}
+	fmt.Fprintf(w, "%s%s:%s %s%s%s%s\n", ansiDim, f.Posn, ansiReset, msg, ansiDim, ruleTag(f), ansiReset)
+	if strings.Contains(f.Message, "% confidence") {
+		fmt.Fprintf(w, "\t%s %3.0f%% confidence\n", bar(f.Confidence(), 20), f.Confidence()*100)
+	}
+	for _, r := range f.Related {
+		fmt.Fprintf(w, "\t%s%s:%s %s\n", ansiDim, r.Posn, ansiReset, r.Message)
+	}
+}
+
+// ruleTag is the rule ID printed after a finding's message.
+func ruleTag(f driver.Finding) string {
+	if f.Rule == "" {
+		return ""
+	}
+	return " [" + f.Rule + "]"
+}
+
+// highlight wraps the first occurrence of s in msg with the escape code.
+func highlight(msg, s, code string) string {
+	if s == "" {
+		return msg
+	}
+	before, after, ok := strings.Cut(msg, s)
+	if !ok {
+		return msg
+	}
+	return before + code + s + ansiReset + after
+}
+
+// replacement returns the suggested replacement for the named pattern, or
+// "" for categories outside the Registry.
+func replacement(pattern string) string {
+	p, err := analyzer.ParsePattern(pattern)
+	if err != nil {
+		return ""
+	}
+	return analyzer.Spec(p).Replacement
+}
+
+// bar draws x, in [0, 1], as a bar width cells wide.
+func bar(x float64, width int) string {
+	n := int(x*float64(width) + 0.5)
+	n = min(max(n, 0), width)
+	return ansiGreen + strings.Repeat("█", n) + ansiDim + strings.Repeat("░", width-n) + ansiReset
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// envPrefix is the prefix of the environment variables that set flags:
+// CHANOPT_MIN_CONFIDENCE=0.8 acts as -min-confidence=0.8.
+const envPrefix = "CHANOPT_"
+
+// envName returns the environment variable for the flag name.
+func envName(name string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// applyEnv sets each flag of fs not in set from its CHANOPT_* environment
+// variable, if present, and adds it to set. Flags given on the command
+// line therefore win over the environment, which wins over the
+// configuration file.
+func applyEnv(fs *flag.FlagSet, set map[string]bool) error {
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if set[f.Name] || err != nil {
+			return
+		}
+		v, ok := os.LookupEnv(envName(f.Name))
+		if !ok {
+			return
+		}
+		if e := fs.Set(f.Name, v); e != nil {
+			err = fmt.Errorf("%s=%q: %v", envName(f.Name), v, e)
+			return
+		}
+		set[f.Name] = true
+	})
+	return err
+}
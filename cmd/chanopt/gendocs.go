@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ravisastryk/chanopt/pkg/analyzer"
+	"github.com/ravisastryk/chanopt/pkg/templates"
+)
+
+// checkDocs describes the checks that are not Registry patterns, for the
+// rules page. Every entry of analyzer.Checks needs one.
+var checkDocs = map[string]string{
+	"BusyPoll":       "A select with a default case in a loop spins on a channel returned by a flagged producer. Replacing the producer removes the spin.",
+	"StopLeak":       "The stop func returned with a generator's channel signals something the goroutine never waits on, so the goroutine leaks.",
+	"OrDone":         "A hand-rolled orDone helper. Select on ctx.Done() where the channel is consumed, or use replacements.OrDone.",
+	"Merge":          "A hand-rolled channel merge helper. Use replacements.Merge(ctx, cs...), which cannot leak its goroutines.",
+	"PipelineStage":  "Advisory, with -pipeline: a pipeline stage whose per-value work is trivial can be fused into its consumer loop or written as composed iter.Seq functions.",
+	"ErrGroup":       "A sync.WaitGroup plus a buffered error channel reimplements golang.org/x/sync/errgroup.",
+	"ContextStop":    "A ticker loop stops on a separate stop channel although a context.Context is in scope. Select on ctx.Done() and cancel the context where the channel was closed, so there is one shutdown signal.",
+	"SendAfterClose": "A generator's goroutine can send on its channel after the channel is closed, which panics: a deferred send that runs after a deferred or explicit close, a send on a path that does not return after closing, or a stop func that closes the channel the goroutine sends on. Close the channel once, in the goroutine's first defer, so it runs last.",
+}
+
+// genDocs writes the rules page that diagnostics link to, one section per
+// rule ID.
+func genDocs(args []string) int {
+	fs := flag.NewFlagSet("gen-docs", flag.ExitOnError)
+	out := fs.String("out", "docs/rules.md", "file to write")
+	_ = fs.Parse(args)
+
+	var b bytes.Buffer
+	b.WriteString("<!-- Code generated by chanopt gen-docs. DO NOT EDIT. -->\n\n")
+	b.WriteString("# chanopt rules\n\n")
+	b.WriteString("Every finding carries a rule ID. Use it to disable a rule in\n")
+	b.WriteString("`.chanopt.yaml` or with `-disable`, or to suppress single findings:\n\n")
+	b.WriteString("```go\n//chanopt:ignore CHOPT001 part of the v1 API\n```\n\n")
+	b.WriteString("| Rule | Name | Default |\n|------|------|---------|\n")
+	for _, cat := range analyzer.Rules() {
+		def := "on"
+		if on, ok := analyzer.Checks[cat]; ok && !on {
+			def = "opt-in"
+		}
+		id := analyzer.RuleID(cat)
+		fmt.Fprintf(&b, "| [%s](#%s) | %s | %s |\n", id, strings.ToLower(id), cat, def)
+	}
+	for _, cat := range analyzer.Rules() {
+		id := analyzer.RuleID(cat)
+		fmt.Fprintf(&b, "\n<a id=%q></a>\n\n## %s %s\n\n", strings.ToLower(id), id, cat)
+		if _, ok := analyzer.Checks[cat]; ok {
+			doc, ok := checkDocs[cat]
+			if !ok {
+				fmt.Fprintf(os.Stderr, "chanopt gen-docs: no description of check %s\n", cat)
+				return 1
+			}
+			b.WriteString(doc + "\n")
+			continue
+		}
+		p, _ := analyzer.ParsePattern(cat)
+		spec := analyzer.Registry[p]
+		fmt.Fprintf(&b, "%s%s.\n\n", strings.ToUpper(spec.Rationale[:1]), spec.Rationale[1:])
+		fmt.Fprintf(&b, "- Replacement: %s\n- Speedup: %s\n", spec.Replacement, spec.Speedup)
+		if analyzer.HasAutofix(p) {
+			b.WriteString("- Autofix: yes, with `-fix`\n")
+		}
+		if ex, ok := templates.For(p); ok {
+			fmt.Fprintf(&b, "\n%s\n\nBefore:\n\n```go\n%s```\n\nAfter:\n\n```go\n%s```\n", ex.Summary, ex.Before, ex.After)
+		}
+	}
+	if err := os.WriteFile(*out, b.Bytes(), 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "chanopt gen-docs: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/format"
+	"go/token"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ravisastryk/chanopt/internal/config"
+	"github.com/ravisastryk/chanopt/pkg/analyzer"
+	"github.com/ravisastryk/chanopt/pkg/templates"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/checker"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/txtar"
+)
+
+// examplePackage matches the package clause of a pkg/templates file.
+var examplePackage = regexp.MustCompile(`(?m)^package example$`)
+
+// genFile is a fixture file to write: the code of a pattern's example, in
+// package kind, before its expectations are added.
+type genFile struct {
+	pattern analyzer.Pattern
+	kind    string // "positive" or "negative"
+	path    string // under -out
+	src     []byte
+}
+
+// genTestdata writes analysistest fixtures for every registered pattern into
+// <out>/src/positive and <out>/src/negative. It returns the exit code.
+//
+// The fixtures are the pattern's example in pkg/templates: the before code
+// is the positive and the after code the negative. Their expectations are
+// what the analyzer reports on them, so the positive of a pattern the
+// analyzer detects must be reported as it, and the negative must not be
+// reported at all.
+func genTestdata(args []string) int {
+	fs := flag.NewFlagSet("gen-testdata", flag.ExitOnError)
+	out := fs.String("out", "testdata", "analysistest testdata root to write into")
+	only := fs.String("pattern", "", "generate fixtures for this pattern only")
+	force := fs.Bool("force", false, "overwrite existing fixture files")
+	custom := fs.String("patterns", "", "generate fixtures for the custom patterns defined in this YAML `file` instead of the registered ones")
+	_ = fs.Parse(args)
+	if *custom != "" {
+		return genCustom(*custom, *out, *only, *force)
+	}
+
+	var pats []analyzer.Pattern
+	for p := range analyzer.Registry {
+		if *only == "" || p.String() == *only {
+			pats = append(pats, p)
+		}
+	}
+	if len(pats) == 0 {
+		fmt.Fprintf(os.Stderr, "chanopt gen-testdata: unknown pattern %q\n", *only)
+		return 2
+	}
+	sort.Slice(pats, func(i, j int) bool { return pats[i] < pats[j] })
+
+	var gen []genFile
+	for _, p := range pats {
+		name := strings.ToLower(p.String()) + ".go"
+		ex, ok := templates.For(p)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "chanopt gen-testdata: no example of %s: add pkg/templates/examples/%s.txtar\n", p, strings.ToLower(p.String()))
+			return 1
+		}
+		for kind, src := range map[string]string{"positive": ex.Before, "negative": ex.After} {
+			if imp := nonStdImport(src); imp != "" {
+				fmt.Fprintf(os.Stderr, "no %s fixture for %s: its example imports %s, which testdata cannot\n", kind, p, imp)
+				continue
+			}
+			src = examplePackage.ReplaceAllLiteralString(src, "package "+kind)
+			gen = append(gen, genFile{p, kind, filepath.Join(*out, "src", kind, name), []byte(src)})
+		}
+	}
+	slices.SortFunc(gen, func(a, b genFile) int { return strings.Compare(a.path, b.path) })
+
+	wants, err := expectations(gen)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "chanopt gen-testdata: %v\n", err)
+		return 1
+	}
+
+	// The analyzer exports a stats fact for a package with findings, which
+	// analysistest expects on the first file of the package: the one that
+	// sorts first once the positives are written.
+	positiveDir := filepath.Join(*out, "src", "positive")
+	first, _ := filepath.Glob(filepath.Join(positiveDir, "*.go"))
+	var files []genFile
+	for _, f := range gen {
+		want := strconv.Quote(regexp.QuoteMeta("chanopt: " + f.pattern.String() + " pattern"))
+		switch {
+		case f.kind == "negative" && len(wants[f.path]) > 0:
+			fmt.Fprintf(os.Stderr, "chanopt gen-testdata: the negative fixture of %s is reported: %s\n", f.pattern, strings.Join(slices.Concat(slices.Collect(maps.Values(wants[f.path]))...), " "))
+			return 1
+		case f.kind == "positive" && !reports(wants[f.path], want):
+			if analyzer.Detectable(f.pattern) {
+				fmt.Fprintf(os.Stderr, "chanopt gen-testdata: the example of %s is not reported as it\n", f.pattern)
+				return 1
+			}
+			fmt.Fprintf(os.Stderr, "no positive fixture for %s: the analyzer does not detect it yet\n", f.pattern)
+			continue
+		case f.kind == "positive":
+			first = append(first, f.path)
+		}
+		files = append(files, f)
+	}
+	slices.Sort(first)
+
+	for _, f := range files {
+		stats := ""
+		if f.path == first[0] {
+			stats = fmt.Sprintf(" // want package:%q", `stats\(.*`+f.pattern.String()+`=\d+.*\)`)
+		}
+		src, err := fixtureFile(f, stats, wants[f.path])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "chanopt gen-testdata: %s %s: %v\n", f.pattern, f.kind, err)
+			return 1
+		}
+		if err := writeFixture(f.path, src, *force); err != nil {
+			fmt.Fprintf(os.Stderr, "chanopt gen-testdata: %v\n", err)
+			return 1
+		}
+	}
+	return 0
+}
+
+// nonStdImport returns the first import of src outside the standard
+// library, which the GOPATH tree of analysistest cannot resolve, or "".
+func nonStdImport(src string) string {
+	for _, m := range regexp.MustCompile(`(?m)^\s*(?:import\s+)?"([^"]+)"$`).FindAllStringSubmatch(src, -1) {
+		if elem, _, _ := strings.Cut(m[1], "/"); strings.Contains(elem, ".") {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// header is the first line of every fixture generated from pkg/templates,
+// which ends with the package fact expectation, if any.
+const header = "// Fixture generated by chanopt gen-testdata from pkg/templates."
+
+// expectations writes the fixtures, without expectations, to a temporary
+// GOPATH tree, runs the analyzer over it as analysistest would, and returns
+// the expectations of what it reports, keyed by fixture path and line: its
+// diagnostics, up to the replacement they suggest, and the facts it
+// exports on objects.
+func expectations(gen []genFile) (map[string]map[int][]string, error) {
+	root, err := os.MkdirTemp("", "chanopt-gen-testdata")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(root)
+	if root, err = filepath.EvalSymlinks(root); err != nil {
+		return nil, err
+	}
+
+	paths := map[string]string{} // temporary path to fixture path
+	kinds := map[string]bool{}
+	for _, f := range gen {
+		tmp := filepath.Join(root, "src", f.kind, filepath.Base(f.path))
+		if err := os.MkdirAll(filepath.Dir(tmp), 0o755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(tmp, append([]byte(header+"\n\n"), f.src...), 0o644); err != nil {
+			return nil, err
+		}
+		paths[tmp] = f.path
+		kinds[f.kind] = true
+	}
+	pcfg := &packages.Config{
+		Mode: packages.LoadAllSyntax,
+		Dir:  root,
+		Env:  append(os.Environ(), "GOPATH="+root, "GO111MODULE=off", "GOWORK=off"),
+	}
+	pkgs, err := packages.Load(pcfg, slices.Sorted(maps.Keys(kinds))...)
+	if err != nil {
+		return nil, err
+	}
+	var errs []string
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		for _, e := range pkg.Errors {
+			errs = append(errs, e.Error())
+		}
+	})
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("the examples do not build together:\n%s", strings.Join(errs, "\n"))
+	}
+	graph, err := checker.Analyze([]*analysis.Analyzer{analyzer.Analyzer}, pkgs, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	wants := map[string]map[int][]string{}
+	for _, act := range graph.Roots {
+		if act.Err != nil {
+			return nil, act.Err
+		}
+		fset := act.Package.Fset
+		expect := func(posn token.Position, want string) {
+			path, ok := paths[posn.Filename]
+			if !ok {
+				return
+			}
+			if wants[path] == nil {
+				wants[path] = map[int][]string{}
+			}
+			wants[path][posn.Line] = append(wants[path][posn.Line], want)
+		}
+		for _, d := range act.Diagnostics {
+			msg, _, _ := strings.Cut(d.Message, " — ")
+			expect(fset.Position(d.Pos), strconv.Quote(regexp.QuoteMeta(msg)))
+		}
+		for _, f := range act.AllObjectFacts() {
+			if f.Object.Pkg() == act.Package.Types {
+				expect(fset.Position(f.Object.Pos()), f.Object.Name()+":"+strconv.Quote(regexp.QuoteMeta(fmt.Sprint(f.Fact))))
+			}
+		}
+	}
+	return wants, nil
+}
+
+// reports reports whether the expectations of a fixture include want.
+func reports(wants map[int][]string, want string) bool {
+	for _, ws := range wants {
+		if slices.Contains(ws, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// fixtureFile renders the gofmt-clean fixture f with the expectations
+// wants, by line, and the package fact expectation stats, if any, at the
+// end of the first line, where analysistest expects package facts.
+func fixtureFile(f genFile, stats string, wants map[int][]string) ([]byte, error) {
+	lines := strings.Split(header+stats+"\n\n"+string(f.src), "\n")
+	for line, ws := range wants {
+		lines[line-1] += " // want " + strings.Join(ws, " ")
+	}
+	return format.Source([]byte(strings.Join(lines, "\n")))
+}
+
+func writeFixture(path string, src []byte, force bool) error {
+	if _, err := os.Stat(path); err == nil && !force {
+		fmt.Fprintf(os.Stderr, "skip %s (exists; use -force)\n", path)
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, src, 0o644); err != nil {
+		return err
+	}
+	fmt.Println(path)
+	return nil
+}
+
+// wantMarker marks the lines of a custom pattern's before code where its
+// diagnostic is expected.
+const wantMarker = "// WANT"
+
+// genCustom writes fixtures for the custom patterns defined in the patterns
+// file path, or for the one named only: the before code of each example
+// as the positive, with the lines marked // WANT expecting the pattern's
+// diagnostic, and the after code as the negative. chanopt cannot tell what
+// a third-party matcher reports, so an example must mark at least one
+// line. It returns the exit code.
+func genCustom(path, out, only string, force bool) int {
+	pats, err := config.LoadPatterns(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "chanopt gen-testdata: %v\n", err)
+		return 1
+	}
+	n := 0
+	for _, p := range pats {
+		if only != "" && p.Name != only {
+			continue
+		}
+		n++
+		data, err := os.ReadFile(p.Example)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "chanopt gen-testdata: %s: %v\n", p.Name, err)
+			return 1
+		}
+		files := map[string]string{}
+		for _, f := range txtar.Parse(data).Files {
+			files[f.Name] = string(f.Data)
+		}
+		for _, fx := range []struct{ kind, name string }{{"positive", "before.go"}, {"negative", "after.go"}} {
+			kind, name := fx.kind, fx.name
+			src, ok := files[name]
+			switch {
+			case !ok:
+				fmt.Fprintf(os.Stderr, "chanopt gen-testdata: %s: no %s\n", p.Example, name)
+				return 1
+			case !examplePackage.MatchString(src):
+				fmt.Fprintf(os.Stderr, "chanopt gen-testdata: %s: %s is not in package example\n", p.Example, name)
+				return 1
+			case kind == "positive" && !strings.Contains(src, wantMarker):
+				fmt.Fprintf(os.Stderr, "chanopt gen-testdata: %s: before.go marks no line with %s\n", p.Example, wantMarker)
+				return 1
+			}
+			src = examplePackage.ReplaceAllLiteralString(src, "package "+kind)
+			if kind == "positive" {
+				src = strings.ReplaceAll(src, wantMarker, "// want "+strconv.Quote(p.Want))
+			}
+			fixture, err := format.Source([]byte("// Fixture generated by chanopt gen-testdata from " + filepath.Base(path) + ".\n\n" + src))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "chanopt gen-testdata: %s: %s: %v\n", p.Example, name, err)
+				return 1
+			}
+			if err := writeFixture(filepath.Join(out, "src", kind, strings.ToLower(p.Name)+".go"), fixture, force); err != nil {
+				fmt.Fprintf(os.Stderr, "chanopt gen-testdata: %v\n", err)
+				return 1
+			}
+		}
+	}
+	if n == 0 {
+		fmt.Fprintf(os.Stderr, "chanopt gen-testdata: unknown pattern %q in %s\n", only, path)
+		return 2
+	}
+	return 0
+}
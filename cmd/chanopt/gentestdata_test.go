@@ -0,0 +1,104 @@
+package main
+
+import (
+	"go/ast"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ravisastryk/chanopt/pkg/analyzer"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+// TestGenTestdata checks that the scaffolded fixtures pass analysistest as
+// generated: every expectation they carry is met, and nothing else is
+// reported.
+func TestGenTestdata(t *testing.T) {
+	for _, args := range [][]string{nil, {"-pattern", "ErrorIterator"}} {
+		dir := t.TempDir()
+		stdout := os.Stdout
+		os.Stdout, _ = os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+		code := genTestdata(append([]string{"-out", dir}, args...))
+		os.Stdout.Close()
+		os.Stdout = stdout
+		if code != 0 {
+			t.Fatalf("gen-testdata %q exited %d", args, code)
+		}
+		for _, kind := range []string{"positive", "negative"} {
+			if files, _ := filepath.Glob(filepath.Join(dir, "src", kind, "*.go")); len(files) == 0 {
+				t.Fatalf("gen-testdata %q wrote no %s fixtures", args, kind)
+			}
+		}
+		analysistest.Run(t, dir, analyzer.Analyzer, "positive", "negative")
+	}
+}
+
+// drainAnalyzer stands in for a third-party matcher: it reports every
+// call of a function named drain.
+var drainAnalyzer = &analysis.Analyzer{
+	Name: "drain",
+	Doc:  "report calls of drain",
+	Run: func(pass *analysis.Pass) (any, error) {
+		for _, f := range pass.Files {
+			ast.Inspect(f, func(n ast.Node) bool {
+				if call, ok := n.(*ast.CallExpr); ok {
+					if id, ok := call.Fun.(*ast.Ident); ok && id.Name == "drain" {
+						pass.Reportf(call.Pos(), "queuedrain: drain loop")
+					}
+				}
+				return true
+			})
+		}
+		return nil, nil
+	},
+}
+
+// TestGenTestdataPatterns generates the fixtures of a custom pattern from
+// a patterns file and runs them with the matcher they are for.
+func TestGenTestdataPatterns(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, src string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("patterns.yaml", "patterns:\n  - name: QueueDrain\n    want: \"queuedrain: drain loop\"\n    example: queuedrain.txtar\n")
+	write("queuedrain.txtar", `Drains a queue in a loop.
+-- before.go --
+package example
+
+func drain(q chan int) {}
+
+func Flush(q chan int) {
+	drain(q) // WANT
+}
+-- after.go --
+package example
+
+func Flush(q []int) []int { return q[:0] }
+`)
+	gen := func(args ...string) int {
+		stdout, stderr := os.Stdout, os.Stderr
+		os.Stdout, _ = os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+		os.Stderr = os.Stdout
+		defer func() {
+			os.Stdout.Close()
+			os.Stdout, os.Stderr = stdout, stderr
+		}()
+		return genTestdata(append([]string{"-out", filepath.Join(dir, "testdata"), "-patterns", filepath.Join(dir, "patterns.yaml")}, args...))
+	}
+	if code := gen(); code != 0 {
+		t.Fatalf("gen-testdata -patterns exited %d", code)
+	}
+	analysistest.Run(t, filepath.Join(dir, "testdata"), drainAnalyzer, "positive", "negative")
+
+	if code := gen("-pattern", "Other"); code != 2 {
+		t.Errorf("gen-testdata -patterns -pattern Other exited %d, want 2", code)
+	}
+	write("queuedrain.txtar", "-- before.go --\npackage example\n\nfunc Flush() {}\n-- after.go --\npackage example\n")
+	if code := gen("-force"); code != 1 {
+		t.Errorf("gen-testdata -patterns with no // WANT line exited %d, want 1", code)
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/ravisastryk/chanopt/internal/config"
+	"github.com/ravisastryk/chanopt/internal/driver"
+	"github.com/ravisastryk/chanopt/pkg/analyzer"
+)
+
+// hook analyzes the Go files staged in the current git repository, as they
+// are in the index rather than the working tree, and prints one line per
+// finding. The configuration files and CHANOPT_* variables apply as they do
+// to the standalone command, and classification results are cached on disk
+// across commits unless -cache says otherwise. It is meant to be run by
+// pre-commit and returns the process exit code: 1 when more than -max
+// findings are reported, 2 on errors.
+func hook(args []string) int {
+	fs := flag.NewFlagSet("hook", flag.ExitOnError)
+	maxFindings := fs.Int("max", 0, "fail only when more than `n` findings are reported")
+	conf := newConfigFlags(fs)
+	analyzer.Analyzer.Flags.VisitAll(func(f *flag.Flag) {
+		fs.Var(f.Value, f.Name, f.Usage)
+	})
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: chanopt hook [-max n] [-config file] [-min-confidence c] [-disable patterns] [-cache dir]\n")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if err := applyEnv(fs, set); err != nil {
+		fmt.Fprintf(os.Stderr, "chanopt hook: %v\n", err)
+		return 2
+	}
+	// Each commit changes a few files, so most generators are classified
+	// as they were last time: keep the results unless told not to.
+	if !set["cache"] {
+		if dir, err := os.UserCacheDir(); err == nil {
+			_ = fs.Set("cache", filepath.Join(dir, "chanopt"))
+		}
+	}
+	dirs, err := conf.resolver(set)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "chanopt hook: %v\n", err)
+		return 2
+	}
+
+	findings, root, err := analyzeStaged(dirs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "chanopt hook: %v\n", err)
+		return 2
+	}
+	for _, f := range findings {
+		name, err := filepath.Rel(root, f.Posn.Filename)
+		if err != nil {
+			name = f.Posn.Filename
+		}
+		fmt.Printf("%s:%d: %s%s\n", name, f.Posn.Line, strings.TrimPrefix(f.Message, "chanopt: "), ruleTag(f))
+	}
+	if len(findings) > *maxFindings {
+		return 1
+	}
+	return 0
+}
+
+// analyzeStaged runs the analyzer over the packages of the staged Go files,
+// with their staged contents overlaid, and keeps findings in those files
+// that the configuration in dirs reports.
+func analyzeStaged(dirs *config.Resolver) ([]driver.Finding, string, error) {
+	out, err := git("rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, "", err
+	}
+	root := string(bytes.TrimSpace(out))
+	out, err = git("diff", "--cached", "--name-only", "--diff-filter=ACMR", "-z", "--", "*.go")
+	if err != nil {
+		return nil, "", err
+	}
+
+	cfg := &driver.Config{Dir: root, Tests: true, Overlay: map[string][]byte{}, Dirs: dirs}
+	staged := map[string]bool{}
+	var patterns []string
+	for _, name := range strings.Split(string(out), "\x00") {
+		if name == "" || skipStaged(name) {
+			continue
+		}
+		src, err := git("show", ":"+name)
+		if err != nil {
+			return nil, "", err
+		}
+		abs := filepath.Join(root, filepath.FromSlash(name))
+		cfg.Overlay[abs] = src
+		staged[abs] = true
+		patterns = append(patterns, "file="+abs)
+	}
+	if len(patterns) == 0 {
+		return nil, root, nil
+	}
+
+	all, err := driver.Run(cfg, patterns...)
+	if err != nil {
+		return nil, "", err
+	}
+	var findings []driver.Finding
+	for _, f := range all {
+		if staged[f.Posn.Filename] {
+			findings = append(findings, f)
+		}
+	}
+	return findings, root, nil
+}
+
+// skipStaged reports whether a staged file is outside any package go list
+// would load: testdata and vendored code.
+func skipStaged(name string) bool {
+	for _, elem := range strings.Split(name, "/") {
+		if elem == "testdata" || elem == "vendor" {
+			return true
+		}
+	}
+	return false
+}
+
+func git(args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %v: %s", args[0], err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return out, nil
+}
@@ -7,13 +7,57 @@
 // Usage:
 //
 //	go vet -vettool=$(which chanopt) ./...
+//	chanopt [-fix [-diff | -dry-run]] [-verify-build] [-overlay file] [-top n] [-json] ./...
+//	chanopt -stdin [-stdin-filename path] < file.go
+//	chanopt -version | -capabilities [-json]
+//
+// Subcommands:
+//
+//	chanopt selftest [-dir demos]       check pattern coverage against the demos
+//	chanopt hook [-max n] [-cache dir]  pre-commit check of the staged Go files
 package main
 
 import (
+	"fmt"
+	"os"
+	"strings"
+
 	"github.com/ravisastryk/chanopt/pkg/analyzer"
 	"golang.org/x/tools/go/analysis/singlechecker"
 )
 
 func main() {
-	singlechecker.Main(analyzer.Analyzer)
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "selftest":
+			os.Exit(selftest(os.Args[2:]))
+		case "hook":
+			os.Exit(hook(os.Args[2:]))
+		case "gen-testdata": // internal: fixture scaffolding for matcher authors
+			os.Exit(genTestdata(os.Args[2:]))
+		case "gen-docs": // internal: writes docs/rules.md; see make docs
+			os.Exit(genDocs(os.Args[2:]))
+		}
+	}
+	if vetMode(os.Args[1:]) {
+		// go vet passes its own flags; the analyzer's may also come from
+		// the environment, which the command line still overrides.
+		if err := applyEnv(&analyzer.Analyzer.Flags, map[string]bool{}); err != nil {
+			fmt.Fprintf(os.Stderr, "chanopt: %v\n", err)
+			os.Exit(1)
+		}
+		singlechecker.Main(analyzer.Analyzer)
+	}
+	os.Exit(standalone(os.Args[1:]))
+}
+
+// vetMode reports whether chanopt is being driven by go vet, which queries
+// -V=full and -flags and then passes a single *.cfg unit description.
+func vetMode(args []string) bool {
+	for _, a := range args {
+		if strings.HasPrefix(a, "-V=") || a == "-flags" {
+			return true
+		}
+	}
+	return len(args) > 0 && strings.HasSuffix(args[len(args)-1], ".cfg")
 }
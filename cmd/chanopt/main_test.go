@@ -0,0 +1,296 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ravisastryk/chanopt/pkg/analyzer"
+)
+
+// cmdModule is a module with two findings of different confidence: an
+// IDGenerator at 95% and a ConfigBroadcaster at 70%.
+const cmdModule = `package m
+
+func IDs() <-chan int64 {
+	ch := make(chan int64)
+	go func() {
+		var id int64
+		for {
+			id++
+			ch <- id
+		}
+	}()
+	return ch
+}
+
+type Store struct{ ch chan int }
+
+func NewStore(v int) *Store {
+	s := &Store{ch: make(chan int, 1)}
+	s.ch <- v
+	return s
+}
+
+func (s *Store) Load() int {
+	v := <-s.ch
+	s.ch <- v
+	return v
+}
+
+func (s *Store) Set(v int) {
+	<-s.ch
+	s.ch <- v
+}
+`
+
+// writeModule writes cmdModule, with yaml as its .chanopt.yaml if not
+// empty, to a new directory and returns it.
+func writeModule(t *testing.T, yaml string) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{"go.mod": "module example.com/m\n\ngo 1.23\n", "m.go": cmdModule}
+	if yaml != "" {
+		files[".chanopt.yaml"] = "root: true\n" + yaml
+	}
+	for name, src := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// runCmd runs cmd, such as standalone or hook, with args in dir and the
+// CHANOPT_* and other variables of env set, and returns its exit code and
+// what it wrote to standard output and standard error. The analyzer's
+// flags, which the commands share, are reset afterwards.
+func runCmd(t *testing.T, dir string, env map[string]string, cmd func([]string) int, args ...string) (code int, stdout, stderr string) {
+	t.Helper()
+	t.Chdir(dir)
+	for k, v := range env {
+		t.Setenv(k, v)
+	}
+	t.Cleanup(func() {
+		analyzer.Analyzer.Flags.VisitAll(func(f *flag.Flag) { _ = f.Value.Set(f.DefValue) })
+	})
+	out, errOut := filepath.Join(t.TempDir(), "stdout"), filepath.Join(t.TempDir(), "stderr")
+	capture := func(name string, f **os.File) func() string {
+		file, err := os.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		saved := *f
+		*f = file
+		return func() string {
+			*f = saved
+			file.Close()
+			data, err := os.ReadFile(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return string(data)
+		}
+	}
+	restoreOut, restoreErr := capture(out, &os.Stdout), capture(errOut, &os.Stderr)
+	code = cmd(args)
+	return code, restoreOut(), restoreErr()
+}
+
+// TestStandaloneSettings runs the command with configuration files, flags
+// and CHANOPT_* variables, and checks the settings in effect through what
+// it reports and how it exits. Flags win over the environment, which wins
+// over the configuration files.
+func TestStandaloneSettings(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		yaml     string
+		env      map[string]string
+		args     []string
+		code     int
+		patterns []string // reported, with -json
+		stdout   string   // exactly, when not -json
+		stderr   string   // contained
+	}{
+		{name: "defaults", args: []string{"-json"}, patterns: []string{"ConfigBroadcaster", "IDGenerator"}},
+		{name: "file", yaml: "min_confidence: 0.8\n", args: []string{"-json"}, patterns: []string{"IDGenerator"}},
+		{name: "env over file", yaml: "min_confidence: 0.8\n", env: map[string]string{"CHANOPT_MIN_CONFIDENCE": "0.5"}, args: []string{"-json"}, patterns: []string{"ConfigBroadcaster", "IDGenerator"}},
+		{name: "flag over env", env: map[string]string{"CHANOPT_MIN_CONFIDENCE": "0.9"}, args: []string{"-json", "-min-confidence=0.5"}, patterns: []string{"ConfigBroadcaster", "IDGenerator"}},
+		{name: "disable in file", yaml: "disable: [CHOPT001]\n", args: []string{"-json"}, patterns: []string{"ConfigBroadcaster"}},
+		{name: "empty disable in env", yaml: "disable: [IDGenerator]\n", env: map[string]string{"CHANOPT_DISABLE": ""}, args: []string{"-json"}, patterns: []string{"ConfigBroadcaster", "IDGenerator"}},
+		{name: "json from env", env: map[string]string{"CHANOPT_JSON": "true", "CHANOPT_DISABLE": "ConfigBroadcaster"}, patterns: []string{"IDGenerator"}},
+		{name: "findings exit 3", args: []string{"-no-color"}, code: 3, stderr: "chanopt: IDGenerator pattern"},
+		{name: "NO_COLOR", env: map[string]string{"NO_COLOR": "1"}, code: 3, stderr: "[CHOPT001]"},
+		{name: "quiet", args: []string{"-quiet"}, code: 3},
+		{name: "quiet from env", env: map[string]string{"CHANOPT_QUIET": "1"}, code: 3},
+		{name: "quiet and clean", yaml: "min_confidence: 1\n", args: []string{"-quiet"}},
+		{name: "count only", args: []string{"-count-only"}, stdout: "2\n"},
+		{name: "count only from env", yaml: "min_confidence: 0.8\n", env: map[string]string{"CHANOPT_COUNT_ONLY": "true"}, stdout: "1\n"},
+		{name: "quiet and count only", args: []string{"-quiet", "-count-only"}, code: 1, stderr: "mutually exclusive"},
+		{name: "bad env", env: map[string]string{"CHANOPT_MIN_CONFIDENCE": "high"}, code: 1, stderr: `CHANOPT_MIN_CONFIDENCE="high"`},
+		{name: "bad file", yaml: "min_confidense: 0.8\n", code: 1, stderr: "did you mean min_confidence?"},
+		{name: "version", args: []string{"-version"}, stdout: "chanopt " + chanoptVersion() + "\n"},
+		{name: "version json from env", env: map[string]string{"CHANOPT_JSON": "1"}, args: []string{"-version"}, stdout: `{"version":"` + chanoptVersion() + "\"}\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := writeModule(t, tc.yaml)
+			args := tc.args
+			if !slices.Contains(args, "-version") {
+				args = append(slices.Clip(args), "./...")
+			}
+			code, stdout, stderr := runCmd(t, dir, tc.env, standalone, args...)
+			if code != tc.code {
+				t.Fatalf("exit code %d, want %d; stderr:\n%s", code, tc.code, stderr)
+			}
+			if !strings.Contains(stderr, tc.stderr) {
+				t.Errorf("stderr %q does not contain %q", stderr, tc.stderr)
+			}
+			if strings.Contains(stderr, "\x1b[") {
+				t.Errorf("colored output to a file: %q", stderr)
+			}
+			if tc.patterns == nil {
+				if stdout != tc.stdout {
+					t.Errorf("stdout = %q, want %q", stdout, tc.stdout)
+				}
+				return
+			}
+			var report struct {
+				Run      struct{ Config string `json:"config_hash"` }
+				Findings []struct{ Pattern string }
+			}
+			if err := json.Unmarshal([]byte(stdout), &report); err != nil {
+				t.Fatalf("-json output: %v\n%s", err, stdout)
+			}
+			var got []string
+			for _, f := range report.Findings {
+				got = append(got, f.Pattern)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tc.patterns) {
+				t.Errorf("reported %q, want %q", got, tc.patterns)
+			}
+			if !strings.HasPrefix(report.Run.Config, "sha256:") {
+				t.Errorf("config_hash = %q", report.Run.Config)
+			}
+		})
+	}
+}
+
+// TestCapabilities checks the -capabilities report against the analyzer.
+func TestCapabilities(t *testing.T) {
+	code, stdout, _ := runCmd(t, t.TempDir(), nil, standalone, "-capabilities", "-json")
+	if code != 0 {
+		t.Fatalf("exit code %d", code)
+	}
+	var caps struct {
+		Version  string
+		Patterns []struct {
+			Name    string
+			Autofix bool
+		}
+	}
+	if err := json.Unmarshal([]byte(stdout), &caps); err != nil {
+		t.Fatal(err)
+	}
+	if caps.Version != chanoptVersion() || len(caps.Patterns) != len(analyzer.Registry) {
+		t.Errorf("-capabilities = version %q, %d patterns, want %q, %d", caps.Version, len(caps.Patterns), chanoptVersion(), len(analyzer.Registry))
+	}
+	for _, p := range caps.Patterns {
+		if pat, err := analyzer.ParsePattern(p.Name); err != nil || p.Autofix != analyzer.HasAutofix(pat) {
+			t.Errorf("pattern %s: autofix %v, %v", p.Name, p.Autofix, err)
+		}
+	}
+}
+
+// TestUseColor checks when findings are colored. /dev/null is a character
+// device, as a terminal is.
+func TestUseColor(t *testing.T) {
+	tty, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Skip(err)
+	}
+	defer tty.Close()
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("TERM", "xterm")
+	if !useColor(tty, false) {
+		t.Error("no color on a character device")
+	}
+	if useColor(tty, true) {
+		t.Error("color with -no-color")
+	}
+	t.Setenv("NO_COLOR", "1")
+	if useColor(tty, false) {
+		t.Error("color with NO_COLOR set")
+	}
+	file, err := os.Create(filepath.Join(t.TempDir(), "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	t.Setenv("NO_COLOR", "")
+	if useColor(file, false) {
+		t.Error("color in a regular file")
+	}
+}
+
+// TestHook stages the module in a new git repository and runs the
+// pre-commit hook over it, with its threshold from a flag or the
+// environment and the configuration file of the repository.
+func TestHook(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip(err)
+	}
+	for _, tc := range []struct {
+		name string
+		yaml string
+		env  map[string]string
+		args []string
+		code int
+	}{
+		{name: "over max", args: []string{"-max", "1"}, code: 1},
+		{name: "at max", args: []string{"-max", "2"}},
+		{name: "max from env", env: map[string]string{"CHANOPT_MAX": "2"}},
+		{name: "flag over env", env: map[string]string{"CHANOPT_MAX": "2"}, args: []string{"-max", "0"}, code: 1},
+		{name: "file", yaml: "min_confidence: 0.8\n", args: []string{"-max", "1"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := writeModule(t, tc.yaml)
+			for _, args := range [][]string{{"init", "-q"}, {"add", "."}} {
+				cmd := exec.Command("git", args...)
+				cmd.Dir = dir
+				if out, err := cmd.CombinedOutput(); err != nil {
+					t.Fatalf("git %s: %v\n%s", args[0], err, out)
+				}
+			}
+			args := append([]string{"-cache", t.TempDir()}, tc.args...)
+			code, stdout, stderr := runCmd(t, dir, tc.env, hook, args...)
+			if code != tc.code {
+				t.Fatalf("exit code %d, want %d; stdout:\n%s\nstderr:\n%s", code, tc.code, stdout, stderr)
+			}
+			if !strings.Contains(stdout, "m.go:") {
+				t.Errorf("no finding in m.go printed:\n%s", stdout)
+			}
+		})
+	}
+}
+
+// TestSelftest runs the self-test against the demos, which must cover
+// every detectable pattern with nothing flagged in the optimized code.
+func TestSelftest(t *testing.T) {
+	demos, err := filepath.Abs("../../demos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, stdout, stderr := runCmd(t, demos, nil, selftest, "-dir", demos)
+	if code != 0 {
+		t.Fatalf("selftest exited %d:\n%s%s", code, stdout, stderr)
+	}
+	if strings.Contains(stdout, "MISSING") || strings.Contains(stdout, "FLAGGED") {
+		t.Errorf("selftest matrix:\n%s", stdout)
+	}
+}
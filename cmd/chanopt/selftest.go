@@ -0,0 +1,83 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/ravisastryk/chanopt/internal/driver"
+	"github.com/ravisastryk/chanopt/pkg/analyzer"
+)
+
+// selftest runs the analyzer over the demos module and prints a conformance
+// matrix: every Detectable pattern must be detected in demos/antipatterns
+// and nothing may be reported in demos/optimized. The patterns the
+// classifier cannot recognize yet are listed as exempt rather than failing
+// the run. It returns the process exit code.
+func selftest(args []string) int {
+	fs := flag.NewFlagSet("selftest", flag.ExitOnError)
+	dir := fs.String("dir", "demos", "path to the chanopt demos module")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: chanopt selftest [-dir path/to/chanopt/demos]\n")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	anti, err := driver.Run(&driver.Config{Dir: *dir}, "./antipatterns")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "chanopt selftest: %v\n", err)
+		return 2
+	}
+	opt, err := driver.Run(&driver.Config{Dir: *dir}, "./optimized")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "chanopt selftest: %v\n", err)
+		return 2
+	}
+
+	detected := map[string]int{}
+	for _, f := range anti {
+		detected[f.Pattern]++
+	}
+	falsePos := map[string]int{}
+	for _, f := range opt {
+		falsePos[f.Pattern]++
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RULE\tPATTERN\tANTIPATTERNS\tOPTIMIZED")
+	var pats []analyzer.Pattern
+	for p := range analyzer.Registry {
+		pats = append(pats, p)
+	}
+	sort.Slice(pats, func(i, j int) bool { return pats[i] < pats[j] })
+
+	covered, exempt := 0, 0
+	for _, p := range pats {
+		got, want := "MISSING", "clean"
+		switch {
+		case detected[p.String()] > 0:
+			got = "detected"
+			covered++
+		case !analyzer.Detectable(p):
+			got = "exempt"
+			exempt++
+		}
+		if n := falsePos[p.String()]; n > 0 {
+			want = fmt.Sprintf("FLAGGED (%d)", n)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", analyzer.RuleID(p.String()), p, got, want)
+	}
+	_ = w.Flush()
+
+	total := len(pats) - exempt
+	fmt.Printf("\n%d/%d patterns detected (%d exempt), %d findings in optimized code\n", covered, total, exempt, len(opt))
+	for _, f := range opt {
+		fmt.Printf("  %s: %s%s\n", f.Posn, f.Message, ruleTag(f))
+	}
+	if covered+exempt < len(pats) || len(opt) > 0 {
+		return 1
+	}
+	return 0
+}
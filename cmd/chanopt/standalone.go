@@ -0,0 +1,438 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ravisastryk/chanopt/internal/config"
+	"github.com/ravisastryk/chanopt/internal/driver"
+	"github.com/ravisastryk/chanopt/pkg/analyzer"
+)
+
+// standalone analyzes the packages named on the command line with the
+// in-process driver. It returns the process exit code: 0 when clean, 1 on
+// errors and 3 when findings were reported, like singlechecker.
+func standalone(args []string) int {
+	fs := flag.NewFlagSet("chanopt", flag.ExitOnError)
+	var (
+		fix         = fs.Bool("fix", false, "apply suggested fixes")
+		diff        = fs.Bool("diff", false, "with -fix, print the fixes as a unified diff instead of applying them")
+		dryRun      = fs.Bool("dry-run", false, "with -fix, report per pattern what the fixes would change instead of applying them")
+		verifyBuild = fs.Bool("verify-build", false, "type-check each fix in memory and reject those that do not compile")
+		jsonOut     = fs.Bool("json", false, "emit findings as JSON")
+		tests       = fs.Bool("test", true, "also analyze test files")
+		overlay     = fs.String("overlay", "", "JSON `file` replacing file contents, in go build -overlay format")
+		top         = fs.Int("top", 0, "report only the `n` highest-impact findings (cost model savings × confidence)")
+		statsFile   = fs.String("stats", "", "write the finding counts per package and pattern, summed over all packages, to a JSON `file`")
+		sortKey     = fs.String("sort", "position", "order of the findings: "+strings.Join(driver.SortKeys, ", ")+"; with -top, impact unless given")
+		stdin       = fs.Bool("stdin", false, "analyze a single file read from standard input")
+		stdinName   = fs.String("stdin-filename", "stdin.go", "with -stdin, the `path` of the file being read")
+		vendor      = fs.Bool("include-vendor", false, "also report findings in vendored and dependency-module packages")
+		noColor     = fs.Bool("no-color", false, "disable colored output (also set by the NO_COLOR environment variable)")
+		quiet       = fs.Bool("quiet", false, "print no findings; report them only through the exit status")
+		countOnly   = fs.Bool("count-only", false, "print only the number of findings, and exit 0 unless analysis fails")
+		showVersion = fs.Bool("version", false, "print the chanopt version and exit")
+		showCaps    = fs.Bool("capabilities", false, "print the patterns, checks and features of this binary and exit")
+		cpuProfile  = fs.String("cpuprofile", "", "write a CPU profile of the run to `file`, for go tool pprof")
+		memProfile  = fs.String("memprofile", "", "write a heap profile, taken at the end of the run, to `file`")
+		conf        = newConfigFlags(fs)
+	)
+	analyzer.Analyzer.Flags.VisitAll(func(f *flag.Flag) {
+		fs.Var(f.Value, f.Name, f.Usage)
+	})
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "%s: %s\n\n", analyzer.Analyzer.Name, analyzer.Analyzer.Doc)
+		fmt.Fprintf(fs.Output(), "Usage: chanopt [-flag] [package]\n       chanopt -stdin [-stdin-filename path] < file.go\n       chanopt selftest [-dir demos]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if err := applyEnv(fs, set); err != nil {
+		fmt.Fprintf(os.Stderr, "chanopt: %v\n", err)
+		return 1
+	}
+	switch {
+	case *showVersion:
+		if err := writeVersion(os.Stdout, *jsonOut); err != nil {
+			fmt.Fprintf(os.Stderr, "chanopt: %v\n", err)
+			return 1
+		}
+		return 0
+	case *showCaps:
+		if err := writeCapabilities(os.Stdout, *jsonOut); err != nil {
+			fmt.Fprintf(os.Stderr, "chanopt: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	if *stdin != (fs.NArg() == 0) {
+		fs.Usage()
+		return 1
+	}
+
+	dirs, err := conf.resolver(set)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "chanopt: %v\n", err)
+		return 1
+	}
+	if set["include-vendor"] {
+		dirs.Top.IncludeVendor, dirs.Top.Set["include_vendor"] = *vendor, true
+	}
+	here, err := dirs.For(".")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "chanopt: %v\n", err)
+		return 1
+	}
+
+	if !slices.Contains(driver.SortKeys, *sortKey) {
+		fmt.Fprintf(os.Stderr, "chanopt: -sort: unknown order %q (want one of %s)\n", *sortKey, strings.Join(driver.SortKeys, ", "))
+		return 1
+	}
+	if countTrue(*jsonOut, *quiet, *countOnly) > 1 {
+		fmt.Fprintln(os.Stderr, "chanopt: -json, -quiet and -count-only are mutually exclusive")
+		return 1
+	}
+	if *dryRun && (!*fix || *diff) {
+		fmt.Fprintln(os.Stderr, "chanopt: -dry-run requires -fix and cannot be used with -diff")
+		return 1
+	}
+	if (*overlay != "" || *stdin) && *fix && !*diff && !*dryRun {
+		fmt.Fprintln(os.Stderr, "chanopt: -fix with -overlay or -stdin requires -diff; unsaved buffers are not written to disk")
+		return 1
+	}
+
+	if *stdin && *statsFile != "" {
+		fmt.Fprintln(os.Stderr, "chanopt: -stats needs packages; it cannot be used with -stdin")
+		return 1
+	}
+
+	cfg := &driver.Config{Tests: *tests, IncludeVendor: here.IncludeVendor, Dirs: dirs}
+	if *statsFile != "" {
+		cfg.Stats = &driver.Rollup{}
+	}
+	if *overlay != "" {
+		var err error
+		if cfg.Overlay, err = driver.LoadOverlay(*overlay); err != nil {
+			fmt.Fprintf(os.Stderr, "chanopt: %v\n", err)
+			return 1
+		}
+	}
+	stopProfiles, err := startProfiles(*cpuProfile, *memProfile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "chanopt: %v\n", err)
+		return 1
+	}
+	defer stopProfiles()
+
+	var findings []driver.Finding
+	if *stdin {
+		findings, err = runStdin(cfg, *stdinName)
+	} else {
+		findings, err = driver.Run(cfg, fs.Args()...)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "chanopt: %v\n", err)
+		return 1
+	}
+	if cfg.Stats != nil {
+		if err := writeStats(*statsFile, currentRunInfo(dirs), cfg.Stats); err != nil {
+			fmt.Fprintf(os.Stderr, "chanopt: %v\n", err)
+			return 1
+		}
+	}
+
+	if *top > 0 {
+		findings = driver.Top(findings, *top)
+	}
+	if set["sort"] {
+		_ = driver.SortBy(findings, *sortKey) // checked above
+	}
+
+	var rejected []driver.SkippedFix
+	if *verifyBuild {
+		for i, f := range findings {
+			var ok []driver.Fix
+			for _, fx := range f.Fixes {
+				if err := driver.VerifyFix(cfg, fx); err != nil {
+					fmt.Fprintf(os.Stderr,
+						"%s: chanopt: internal error: fix %q does not compile and was discarded (please report this as a fix-generation bug):\n\t%v\n",
+						f.Posn, fx.Message, strings.ReplaceAll(err.Error(), "\n", "\n\t"))
+					continue
+				}
+				ok = append(ok, fx)
+			}
+			findings[i].Fixes = ok
+			if len(ok) == 0 && len(f.Fixes) > 0 {
+				rejected = append(rejected, driver.SkippedFix{Finding: f, Reason: "fix does not compile"})
+			}
+		}
+	}
+
+	if *dryRun {
+		_, skipped, err := driver.ApplyFixes(cfg, findings)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "chanopt: %v\n", err)
+			return 1
+		}
+		for _, f := range skipped {
+			rejected = append(rejected, driver.SkippedFix{Finding: f, Reason: "overlaps another fix"})
+		}
+		for _, f := range findings {
+			p, err := analyzer.ParsePattern(f.Pattern)
+			if err != nil || !analyzer.HasAutofix(p) || len(f.Fixes) > 0 || slices.ContainsFunc(rejected, func(s driver.SkippedFix) bool { return s.Finding.Posn == f.Posn }) {
+				continue
+			}
+			// The analyzer withholds the fix when the rewrite is unsafe:
+			// the function is not a plain generator or is marked nofix.
+			rejected = append(rejected, driver.SkippedFix{Finding: f, Reason: "no fix offered"})
+		}
+		if err := writePlan(os.Stdout, currentRunInfo(dirs), driver.PlanFixes(findings, rejected), rejected, *jsonOut); err != nil {
+			fmt.Fprintf(os.Stderr, "chanopt: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	switch {
+	case *quiet:
+	case *countOnly:
+		fmt.Println(len(findings))
+	case *jsonOut:
+		if err := writeJSON(os.Stdout, currentRunInfo(dirs), findings); err != nil {
+			fmt.Fprintf(os.Stderr, "chanopt: %v\n", err)
+			return 1
+		}
+	default:
+		color := useColor(os.Stderr, *noColor)
+		for _, f := range findings {
+			printFinding(os.Stderr, f, color)
+		}
+	}
+
+	if *fix {
+		files, skipped, err := driver.ApplyFixes(cfg, findings)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "chanopt: %v\n", err)
+			return 1
+		}
+		for _, f := range skipped {
+			fmt.Fprintf(os.Stderr, "%s: chanopt: fix skipped, it overlaps another fix\n", f.Posn)
+		}
+		for name, content := range files {
+			if *diff {
+				old, err := cfg.ReadFile(name)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "chanopt: %v\n", err)
+					return 1
+				}
+				fmt.Print(driver.Diff(name, old, content))
+				continue
+			}
+			if err := os.WriteFile(name, content, 0o644); err != nil {
+				fmt.Fprintf(os.Stderr, "chanopt: %v\n", err)
+				return 1
+			}
+		}
+	}
+
+	if len(findings) > 0 && !*jsonOut && !*countOnly {
+		return 3
+	}
+	return 0
+}
+
+// configFlags are the flags that override the configuration files, for
+// the commands that read them.
+type configFlags struct {
+	file    *string
+	minConf *float64
+	disable *string
+}
+
+func newConfigFlags(fs *flag.FlagSet) *configFlags {
+	return &configFlags{
+		file:    fs.String("config", "", "configuration `file` applied beneath the "+config.FileName+" files found from each package's directory upward"),
+		minConf: fs.Float64("min-confidence", 0, "report only findings with at least this confidence, in [0, 1]"),
+		disable: fs.String("disable", "", "comma-separated `patterns` and checks not to report"),
+	}
+}
+
+// resolver returns the configuration in effect in each directory.
+// Configuration files apply per directory; the flags in set, given on the
+// command line or in the environment, win over them.
+func (cf *configFlags) resolver(set map[string]bool) (*config.Resolver, error) {
+	dirs := &config.Resolver{Top: &config.Config{Set: map[string]bool{}}}
+	if *cf.file != "" {
+		var err error
+		if dirs.Base, err = config.Load(*cf.file); err != nil {
+			return nil, err
+		}
+	}
+	flags := dirs.Top
+	if set["min-confidence"] {
+		flags.MinConfidence, flags.Set["min_confidence"] = *cf.minConf, true
+	}
+	if set["disable"] {
+		if *cf.disable != "" {
+			flags.Disable = strings.Split(*cf.disable, ",")
+		}
+		if err := config.CheckNames(flags.Disable); err != nil {
+			return nil, fmt.Errorf("-disable: %v", err)
+		}
+		flags.Set["disable"] = true
+	}
+	if set["pipeline"] {
+		flags.Pipeline = analyzer.Analyzer.Flags.Lookup("pipeline").Value.String() == "true"
+		flags.Set["pipeline"] = true
+	}
+	return dirs, nil
+}
+
+func countTrue(bs ...bool) int {
+	n := 0
+	for _, b := range bs {
+		if b {
+			n++
+		}
+	}
+	return n
+}
+
+// runStdin analyzes standard input as the contents of filename.
+func runStdin(cfg *driver.Config, filename string) ([]driver.Finding, error) {
+	src, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return nil, err
+	}
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Overlay == nil {
+		cfg.Overlay = map[string][]byte{}
+	}
+	cfg.Overlay[abs] = src
+	return driver.RunFile(cfg, abs)
+}
+
+// jsonFinding is the -json representation of a finding.
+type jsonFinding struct {
+	Posn    string   `json:"posn"`
+	Pattern string   `json:"pattern"`
+	Rule    string   `json:"rule,omitempty"`
+	URL     string   `json:"url,omitempty"`
+	Message string   `json:"message"`
+	Impact  float64  `json:"impact_ns"`
+	Callers int      `json:"callers,omitempty"`
+	Test    bool     `json:"test_only,omitempty"`
+	Copy    int64    `json:"copy_bytes,omitempty"`
+	Buffer  *int64   `json:"buffer,omitempty"`
+	Effort  *effort  `json:"effort,omitempty"`
+	Related []string `json:"related,omitempty"`
+	Fixes   []string `json:"fixes,omitempty"`
+}
+
+// effort is the -json representation of an analyzer.Effort.
+type effort struct {
+	Lines           int     `json:"lines"`
+	CallSites       int     `json:"call_sites"`
+	SignatureChange bool    `json:"signature_change"`
+	Autofix         bool    `json:"autofix"`
+	Score           float64 `json:"score"`
+	ValuePerEffort  float64 `json:"value_per_effort"`
+}
+
+// writeJSON writes the -json report: the run's provenance and the
+// findings.
+func writeJSON(w io.Writer, run runInfo, findings []driver.Finding) error {
+	out := make([]jsonFinding, 0, len(findings))
+	for _, f := range findings {
+		jf := jsonFinding{Posn: f.Posn.String(), Pattern: f.Pattern, Rule: f.Rule, URL: f.URL, Message: f.Message, Impact: round(f.Impact()), Callers: f.Callers, Test: f.TestOnly, Copy: f.CopyBytes, Buffer: f.Buffer}
+		if e := f.Effort; e != nil {
+			jf.Effort = &effort{e.Lines, e.CallSites, e.SignatureChange, e.Autofix, round(e.Score()), round(f.ValuePerEffort())}
+		}
+		for _, r := range f.Related {
+			jf.Related = append(jf.Related, r.Posn.String()+": "+r.Message)
+		}
+		for _, fx := range f.Fixes {
+			jf.Fixes = append(jf.Fixes, fx.Message)
+		}
+		out = append(out, jf)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(struct {
+		Run      runInfo       `json:"run"`
+		Findings []jsonFinding `json:"findings"`
+	}{run, out})
+}
+
+// writeStats writes the -stats artifact, tagged with the chanopt version
+// so that a dashboard can tell a change in the code from one in chanopt.
+func writeStats(name string, run runInfo, r *driver.Rollup) error {
+	data, err := json.MarshalIndent(struct {
+		Version string  `json:"chanopt_version"`
+		Run     runInfo `json:"run"`
+		*driver.Rollup
+	}{run.Version, run, r}, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, append(data, '\n'), 0o644)
+}
+
+// writePlan prints the -dry-run report: a table of the fixes per pattern
+// and the findings whose fix would be left out.
+func writePlan(w io.Writer, run runInfo, plans []driver.FixPlan, skipped []driver.SkippedFix, asJSON bool) error {
+	if asJSON {
+		type jsonSkipped struct {
+			Posn    string `json:"posn"`
+			Pattern string `json:"pattern"`
+			Reason  string `json:"reason"`
+		}
+		out := struct {
+			Run     runInfo          `json:"run"`
+			Plan    []driver.FixPlan `json:"plan"`
+			Skipped []jsonSkipped    `json:"skipped"`
+		}{Run: run, Plan: plans, Skipped: []jsonSkipped{}}
+		for _, s := range skipped {
+			out.Skipped = append(out.Skipped, jsonSkipped{s.Finding.Posn.String(), s.Finding.Pattern, s.Reason})
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		return enc.Encode(out)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PATTERN\tRULE\tFIXES\tFILES\tSIGNATURES\tCALL SITES\tSKIPPED")
+	for _, p := range plans {
+		name := p.Pattern
+		if name == "" {
+			name = "total"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%d\t%d\n", name, p.Rule, p.Fixes, p.Files, p.Signatures, p.CallSites, p.Skipped)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if len(skipped) > 0 {
+		fmt.Fprintln(w, "\nskipped:")
+	}
+	for _, s := range skipped {
+		fmt.Fprintf(w, "  %s: %s: %s\n", s.Finding.Posn, s.Finding.Pattern, s.Reason)
+	}
+	return nil
+}
+
+// round rounds x to one decimal place for display.
+func round(x float64) float64 {
+	return math.Round(x*10) / 10
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ravisastryk/chanopt/internal/config"
+	"github.com/ravisastryk/chanopt/pkg/analyzer"
+)
+
+// version is the chanopt release, set at link time with
+// -ldflags "-X main.version=v1.2.3"; otherwise it comes from the module
+// version recorded by go install.
+var version = ""
+
+func chanoptVersion() string {
+	if version != "" {
+		return version
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok && bi.Main.Version != "" {
+		return bi.Main.Version
+	}
+	return "(devel)"
+}
+
+// runInfo is the provenance recorded in every JSON report, so that an
+// archived report says which chanopt, configured how, analyzed which
+// revision of the code.
+type runInfo struct {
+	Generated string `json:"generated"` // RFC 3339, UTC
+	Version   string `json:"chanopt_version"`
+	Revision  string `json:"chanopt_revision,omitempty"` // VCS revision chanopt was built from
+	GoVersion string `json:"go_version"`                 // the Go release chanopt was built with
+	Config    string `json:"config_hash"`                // see config.Resolver.Hash
+	Commit    string `json:"commit,omitempty"`           // HEAD of the git repository in the working directory, if any
+}
+
+func currentRunInfo(dirs *config.Resolver) runInfo {
+	info := runInfo{
+		Generated: time.Now().UTC().Format(time.RFC3339),
+		Version:   chanoptVersion(),
+		GoVersion: runtime.Version(),
+		Config:    dirs.Hash(),
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			if s.Key == "vcs.revision" {
+				info.Revision = s.Value
+			}
+		}
+	}
+	if out, err := exec.Command("git", "rev-parse", "HEAD").Output(); err == nil {
+		info.Commit = strings.TrimSpace(string(out))
+	}
+	return info
+}
+
+// capabilities is the -capabilities report, for wrapper tooling that has
+// to adapt to the installed binary.
+type capabilities struct {
+	Version      string       `json:"version"`
+	Patterns     []patternCap `json:"patterns"`
+	Categories   []category   `json:"categories"`
+	SSA          bool         `json:"ssa"` // false: analysis uses syntax and types only
+	ConfigSchema int          `json:"config_schema"`
+}
+
+type patternCap struct {
+	ID          int    `json:"id"`
+	Rule        string `json:"rule"`
+	Name        string `json:"name"`
+	Replacement string `json:"replacement"`
+	Autofix     bool   `json:"autofix"`
+}
+
+type category struct {
+	Rule    string `json:"rule"`
+	Name    string `json:"name"`
+	Default bool   `json:"default"`
+}
+
+func currentCapabilities() capabilities {
+	c := capabilities{Version: chanoptVersion(), ConfigSchema: config.SchemaVersion}
+	for p := range analyzer.Registry {
+		c.Patterns = append(c.Patterns, patternCap{int(p), analyzer.RuleID(p.String()), p.String(), analyzer.Spec(p).Replacement, analyzer.HasAutofix(p)})
+	}
+	sort.Slice(c.Patterns, func(i, j int) bool { return c.Patterns[i].ID < c.Patterns[j].ID })
+	for name, on := range analyzer.Checks {
+		c.Categories = append(c.Categories, category{analyzer.RuleID(name), name, on})
+	}
+	sort.Slice(c.Categories, func(i, j int) bool { return c.Categories[i].Rule < c.Categories[j].Rule })
+	return c
+}
+
+func writeVersion(w io.Writer, asJSON bool) error {
+	if asJSON {
+		return json.NewEncoder(w).Encode(struct {
+			Version string `json:"version"`
+		}{chanoptVersion()})
+	}
+	_, err := fmt.Fprintf(w, "chanopt %s\n", chanoptVersion())
+	return err
+}
+
+func writeCapabilities(w io.Writer, asJSON bool) error {
+	c := currentCapabilities()
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+		return enc.Encode(c)
+	}
+	fmt.Fprintf(w, "chanopt %s\n\npatterns:\n", c.Version)
+	for _, p := range c.Patterns {
+		fix := ""
+		if p.Autofix {
+			fix = " (autofix)"
+		}
+		fmt.Fprintf(w, "  %s %-18s %s%s\n", p.Rule, p.Name, p.Replacement, fix)
+	}
+	fmt.Fprintf(w, "\nother checks:\n")
+	for _, cat := range c.Categories {
+		state := "on"
+		if !cat.Default {
+			state = "opt-in"
+		}
+		fmt.Fprintf(w, "  %s %-18s %s\n", cat.Rule, cat.Name, state)
+	}
+	ssa := "no"
+	if c.SSA {
+		ssa = "yes"
+	}
+	_, err := fmt.Fprintf(w, "\nssa mode: %s\nconfig schema: %d\n", ssa, c.ConfigSchema)
+	return err
+}
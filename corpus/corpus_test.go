@@ -0,0 +1,168 @@
+// Package corpus regression-tests the analyzer against pinned open-source
+// modules. It downloads modules through the Go module proxy, so it only runs
+// when asked to:
+//
+//	go test ./corpus -corpus            # compare against snapshots
+//	go test ./corpus -corpus -update    # rewrite snapshots
+package corpus_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ravisastryk/chanopt/internal/driver"
+)
+
+var (
+	runCorpus = flag.Bool("corpus", false, "download the pinned corpus modules and check their findings")
+	update    = flag.Bool("update", false, "rewrite corpus snapshots from the current findings")
+)
+
+func TestCorpus(t *testing.T) {
+	if !*runCorpus {
+		t.Skip("corpus check needs network access; run with -corpus")
+	}
+	t.Setenv("GOFLAGS", "-mod=mod")
+	for _, mod := range readManifest(t) {
+		t.Run(mod, func(t *testing.T) {
+			dir := fetch(t, mod)
+			findings, err := driver.Run(&driver.Config{Dir: dir}, "./...")
+			if err != nil {
+				t.Fatal(err)
+			}
+			var b strings.Builder
+			fmt.Fprintf(&b, "%s%s: %d\n", header, mod, len(findings))
+			for _, f := range findings {
+				rel, err := filepath.Rel(dir, f.Posn.Filename)
+				if err != nil {
+					t.Fatal(err)
+				}
+				fmt.Fprintf(&b, "%s:%d:%d: %s\n", filepath.ToSlash(rel), f.Posn.Line, f.Posn.Column, f.Pattern)
+			}
+			got := b.String()
+
+			if *update {
+				if err := os.WriteFile(snapshot(mod), []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want := readSnapshot(t, mod)
+			if got != want {
+				t.Errorf("findings for %s changed\n--- want\n%s--- got\n%s", mod, want, got)
+			}
+		})
+	}
+}
+
+// TestSnapshots checks, without the network, that every corpus module
+// has a snapshot written by -update and that every snapshot belongs to a
+// corpus module, so that a module is never left unchecked by a missing or
+// empty file.
+func TestSnapshots(t *testing.T) {
+	mods := readManifest(t)
+	if len(mods) == 0 {
+		t.Fatal("corpus.txt lists no modules")
+	}
+	for _, mod := range mods {
+		readSnapshot(t, mod)
+	}
+	files, err := filepath.Glob(filepath.Join("snapshots", "*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range files {
+		if !slices.ContainsFunc(mods, func(mod string) bool { return snapshot(mod) == file }) {
+			t.Errorf("%s belongs to no module in corpus.txt", file)
+		}
+	}
+}
+
+// header starts the first line of every snapshot, which names the module
+// and counts its findings.
+const header = "# chanopt findings in "
+
+// snapshot returns the path of the snapshot of mod.
+func snapshot(mod string) string {
+	return filepath.Join("snapshots", strings.ReplaceAll(mod, "/", "_")+".txt")
+}
+
+// readSnapshot returns the snapshot of mod, failing t if it is missing or
+// was not written by -update for mod.
+func readSnapshot(t *testing.T, mod string) string {
+	t.Helper()
+	data, err := os.ReadFile(snapshot(mod))
+	if err != nil {
+		t.Fatalf("%v (run with -corpus -update to create it)", err)
+	}
+	if !strings.HasPrefix(string(data), header+mod+": ") {
+		t.Fatalf("%s does not start with %q (run with -corpus -update to rewrite it)", snapshot(mod), header+mod+": ")
+	}
+	return string(data)
+}
+
+// readManifest returns the module@version entries of corpus.txt.
+func readManifest(t *testing.T) []string {
+	f, err := os.Open("corpus.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var mods []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			mods = append(mods, line)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return mods
+}
+
+// fetch downloads mod through the module proxy and returns a writable copy
+// of its source tree, so that dependency resolution can update go.sum.
+func fetch(t *testing.T, mod string) string {
+	out, err := exec.Command("go", "mod", "download", "-json", mod).Output()
+	if err != nil {
+		t.Fatalf("go mod download %s: %v", mod, err)
+	}
+	var info struct{ Dir, Error string }
+	if err := json.Unmarshal(out, &info); err != nil {
+		t.Fatal(err)
+	}
+	if info.Error != "" {
+		t.Fatalf("go mod download %s: %s", mod, info.Error)
+	}
+	dst := t.TempDir()
+	err = filepath.WalkDir(info.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(info.Dir, path)
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0o644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dst
+}
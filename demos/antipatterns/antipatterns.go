@@ -1,7 +1,11 @@
-// Package antipatterns demonstrates 10 channel anti-patterns chanopt detects.
+// Package antipatterns demonstrates the channel anti-patterns chanopt detects.
 package antipatterns
 
-import "time"
+import (
+	"os"
+	"sync"
+	"time"
+)
 
 // 1. IDGenerator — goroutine increments counter, sends to channel.
 func NewIDGenerator() <-chan int64 {
@@ -126,3 +130,177 @@ func Heartbeat(d time.Duration) <-chan struct{} {
 	}()
 	return ch
 }
+
+// 11. FanOutDispatcher — fixed worker pool doing trivial work per job.
+func SquareAll(in []int) []int {
+	out := make([]int, len(in))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				out[j] = in[j] * in[j]
+			}
+		}()
+	}
+	for j := range in {
+		jobs <- j
+	}
+	close(jobs)
+	wg.Wait()
+	return out
+}
+
+// 12. LazyInit — init goroutine parks a computed value in a package channel.
+var hostnameCh = make(chan string, 1)
+
+func init() {
+	go func() {
+		h, _ := os.Hostname()
+		hostnameCh <- h
+	}()
+}
+
+func Hostname() string {
+	h := <-hostnameCh
+	hostnameCh <- h
+	return h
+}
+
+// 13. ResultCollector — channel buffered to the goroutine count, drained once per goroutine.
+func Lengths(words []string) []int {
+	results := make(chan int, len(words))
+	for _, w := range words {
+		go func() {
+			results <- len(w)
+		}()
+	}
+	out := make([]int, 0, len(words))
+	for range len(words) {
+		out = append(out, <-results)
+	}
+	return out
+}
+
+// 14. PingPong — two goroutines take turns over a request and a response channel.
+func RunningTotals(nums []int) []int {
+	req := make(chan int)
+	resp := make(chan int)
+	go func() {
+		total := 0
+		for n := range req {
+			total += n
+			resp <- total
+		}
+	}()
+	out := make([]int, 0, len(nums))
+	for _, n := range nums {
+		req <- n
+		out = append(out, <-resp)
+	}
+	close(req)
+	return out
+}
+
+// 15. AggregatorQueue — many senders, one goroutine folding each value into a total.
+type Counter struct {
+	events chan int
+	total  int
+}
+
+func NewCounter() *Counter {
+	c := &Counter{events: make(chan int, 1024)}
+	go c.run()
+	return c
+}
+
+func (c *Counter) Add(n int) { c.events <- n }
+
+func (c *Counter) run() {
+	for n := range c.events {
+		c.total += n
+	}
+}
+
+// 16. ShardedDispatcher — one goroutine routing each event by key to a per-shard goroutine.
+func CountByKey(events <-chan string, n int) []map[string]int {
+	counts := make([]map[string]int, n)
+	shards := make([]chan string, n)
+	var wg sync.WaitGroup
+	for i := range shards {
+		counts[i] = map[string]int{}
+		shards[i] = make(chan string, 64)
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for k := range shards[i] {
+				counts[i][k]++
+			}
+		}(i)
+	}
+	go func() {
+		for k := range events {
+			shards[len(k)%n] <- k
+		}
+		for _, ch := range shards {
+			close(ch)
+		}
+	}()
+	wg.Wait()
+	return counts
+}
+
+// 17. MemoCache — one goroutine answering lookups from a map over a request channel.
+type memoRequest struct {
+	key   string
+	reply chan int
+}
+
+type Memo struct {
+	f        func(string) int
+	requests chan memoRequest
+}
+
+func NewMemo(f func(string) int) *Memo {
+	m := &Memo{f: f, requests: make(chan memoRequest)}
+	go m.serve()
+	return m
+}
+
+func (m *Memo) Get(key string) int {
+	reply := make(chan int)
+	m.requests <- memoRequest{key, reply}
+	return <-reply
+}
+
+func (m *Memo) serve() {
+	cache := map[string]int{}
+	for req := range m.requests {
+		v, ok := cache[req.key]
+		if !ok {
+			v = m.f(req.key)
+			cache[req.key] = v
+		}
+		req.reply <- v
+	}
+}
+
+// 18. ErrorIterator — goroutine sends each parsed value with its error, closes channel.
+type Parsed struct {
+	N   int
+	Err error
+}
+
+func ParseAll(items []string, parse func(string) (int, error)) <-chan Parsed {
+	ch := make(chan Parsed)
+	go func() {
+		defer close(ch)
+		for _, s := range items {
+			n, err := parse(s)
+			ch <- Parsed{n, err}
+		}
+	}()
+	return ch
+}
@@ -4,14 +4,18 @@
 package demos
 
 import (
+	"errors"
+	"iter"
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 )
 
 // ═══ Pattern 1: ID Generator ═══
 
 func BenchmarkIDGen_Channel(b *testing.B) {
+	b.ReportAllocs()
 	ch := make(chan int64, 64)
 	go func() {
 		var id int64
@@ -26,7 +30,26 @@ func BenchmarkIDGen_Channel(b *testing.B) {
 	}
 }
 
+// BenchmarkIDGen_Unbuffered measures the same generator with an unbuffered
+// channel, where every receive is a handoff with the goroutine.
+func BenchmarkIDGen_Unbuffered(b *testing.B) {
+	b.ReportAllocs()
+	ch := make(chan int64)
+	go func() {
+		var id int64
+		for {
+			id++
+			ch <- id
+		}
+	}()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		<-ch
+	}
+}
+
 func BenchmarkIDGen_Atomic(b *testing.B) {
+	b.ReportAllocs()
 	var counter int64
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
@@ -37,6 +60,7 @@ func BenchmarkIDGen_Atomic(b *testing.B) {
 // ═══ Pattern 2: Round-Robin ═══
 
 func BenchmarkRR_Channel(b *testing.B) {
+	b.ReportAllocs()
 	items := []string{"a", "b", "c", "d"}
 	ch := make(chan string, 64)
 	go func() {
@@ -50,7 +74,23 @@ func BenchmarkRR_Channel(b *testing.B) {
 	}
 }
 
+func BenchmarkRR_Unbuffered(b *testing.B) {
+	b.ReportAllocs()
+	items := []string{"a", "b", "c", "d"}
+	ch := make(chan string)
+	go func() {
+		for i := 0; ; i = (i + 1) % len(items) {
+			ch <- items[i]
+		}
+	}()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		<-ch
+	}
+}
+
 func BenchmarkRR_Mutex(b *testing.B) {
+	b.ReportAllocs()
 	items := []string{"a", "b", "c", "d"}
 	var mu sync.Mutex
 	idx := 0
@@ -63,9 +103,41 @@ func BenchmarkRR_Mutex(b *testing.B) {
 	}
 }
 
+// ═══ Pattern 3: Rate Limiter ═══
+
+func BenchmarkRateLimit_Channel(b *testing.B) {
+	b.ReportAllocs()
+	ch := make(chan struct{}, 64)
+	go func() {
+		for {
+			ch <- struct{}{}
+		}
+	}()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		<-ch
+	}
+}
+
+func BenchmarkRateLimit_Mutex(b *testing.B) {
+	b.ReportAllocs()
+	var mu sync.Mutex
+	tokens := 0
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		mu.Lock()
+		if tokens == 0 {
+			tokens = 64
+		}
+		tokens--
+		mu.Unlock()
+	}
+}
+
 // ═══ Pattern 4: Config Store ═══
 
 func BenchmarkConfig_Channel(b *testing.B) {
+	b.ReportAllocs()
 	ch := make(chan string, 1)
 	ch <- "v1"
 	b.ResetTimer()
@@ -76,6 +148,7 @@ func BenchmarkConfig_Channel(b *testing.B) {
 }
 
 func BenchmarkConfig_AtomicValue(b *testing.B) {
+	b.ReportAllocs()
 	var store atomic.Value
 	store.Store("v1")
 	b.ResetTimer()
@@ -87,6 +160,7 @@ func BenchmarkConfig_AtomicValue(b *testing.B) {
 // ═══ Pattern 5: Bounded Iterator ═══
 
 func BenchmarkIter_Channel(b *testing.B) {
+	b.ReportAllocs()
 	items := make([]int, 100)
 	for i := range items {
 		items[i] = i
@@ -105,7 +179,28 @@ func BenchmarkIter_Channel(b *testing.B) {
 	}
 }
 
+func BenchmarkIter_Unbuffered(b *testing.B) {
+	b.ReportAllocs()
+	items := make([]int, 100)
+	for i := range items {
+		items[i] = i
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ch := make(chan int)
+		go func() {
+			defer close(ch)
+			for _, v := range items {
+				ch <- v
+			}
+		}()
+		for range ch {
+		}
+	}
+}
+
 func BenchmarkIter_Direct(b *testing.B) {
+	b.ReportAllocs()
 	items := make([]int, 100)
 	for i := range items {
 		items[i] = i
@@ -121,6 +216,7 @@ func BenchmarkIter_Direct(b *testing.B) {
 // ═══ Pattern 6: Circuit Breaker ═══
 
 func BenchmarkCB_Channel(b *testing.B) {
+	b.ReportAllocs()
 	ch := make(chan int32, 1)
 	ch <- 0
 	b.ResetTimer()
@@ -131,6 +227,7 @@ func BenchmarkCB_Channel(b *testing.B) {
 }
 
 func BenchmarkCB_Atomic(b *testing.B) {
+	b.ReportAllocs()
 	var state atomic.Int32
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
@@ -138,9 +235,39 @@ func BenchmarkCB_Atomic(b *testing.B) {
 	}
 }
 
+// ═══ Pattern 7: Semaphore ═══
+
+func BenchmarkSem_Channel(b *testing.B) {
+	b.ReportAllocs()
+	sem := make(chan struct{}, 4)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sem <- struct{}{}
+		<-sem
+	}
+}
+
+func BenchmarkSem_Mutex(b *testing.B) {
+	b.ReportAllocs()
+	var mu sync.Mutex
+	count, max := 0, 4
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		mu.Lock()
+		if count < max {
+			count++
+		}
+		mu.Unlock()
+		mu.Lock()
+		count--
+		mu.Unlock()
+	}
+}
+
 // ═══ Pattern 8: Singleton ═══
 
 func BenchmarkSingleton_Channel(b *testing.B) {
+	b.ReportAllocs()
 	ch := make(chan int, 1)
 	ch <- 42
 	b.ResetTimer()
@@ -151,6 +278,7 @@ func BenchmarkSingleton_Channel(b *testing.B) {
 }
 
 func BenchmarkSingleton_Once(b *testing.B) {
+	b.ReportAllocs()
 	var once sync.Once
 	var val int
 	b.ResetTimer()
@@ -159,3 +287,418 @@ func BenchmarkSingleton_Once(b *testing.B) {
 		_ = val
 	}
 }
+
+// ═══ Pattern 9: Fixed Fan-In ═══
+
+func BenchmarkFanIn_Channel(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		out := make(chan int)
+		go func() { out <- 1 }()
+		go func() { out <- 2 }()
+		_, _ = <-out, <-out
+	}
+}
+
+func BenchmarkFanIn_WaitGroup(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var wg sync.WaitGroup
+		results := make([]int, 2)
+		wg.Add(2)
+		go func() { defer wg.Done(); results[0] = 1 }()
+		go func() { defer wg.Done(); results[1] = 2 }()
+		wg.Wait()
+	}
+}
+
+// ═══ Pattern 10: Ticker Wrapper ═══
+// The sleep itself is elided: both sides measure per-tick delivery overhead.
+
+func BenchmarkTicker_Channel(b *testing.B) {
+	b.ReportAllocs()
+	ch := make(chan struct{})
+	go func() {
+		for {
+			ch <- struct{}{}
+		}
+	}()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		<-ch
+	}
+}
+
+func BenchmarkTicker_Direct(b *testing.B) {
+	b.ReportAllocs()
+	t := time.NewTicker(time.Hour)
+	defer t.Stop()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+}
+
+// ═══ Pattern 11: Fan-Out Dispatcher ═══
+
+func BenchmarkFanOut_Channel(b *testing.B) {
+	b.ReportAllocs()
+	in := make([]int, 1000)
+	out := make([]int, len(in))
+	for i := 0; i < b.N; i++ {
+		jobs := make(chan int)
+		var wg sync.WaitGroup
+		for w := 0; w < 4; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := range jobs {
+					out[j] = in[j] * in[j]
+				}
+			}()
+		}
+		for j := range in {
+			jobs <- j
+		}
+		close(jobs)
+		wg.Wait()
+	}
+}
+
+func BenchmarkFanOut_ParallelFor(b *testing.B) {
+	b.ReportAllocs()
+	in := make([]int, 1000)
+	out := make([]int, len(in))
+	for i := 0; i < b.N; i++ {
+		var wg sync.WaitGroup
+		chunk := len(in) / 4
+		for lo := 0; lo < len(in); lo += chunk {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := lo; j < min(lo+chunk, len(in)); j++ {
+					out[j] = in[j] * in[j]
+				}
+			}()
+		}
+		wg.Wait()
+	}
+}
+
+// ═══ Pattern 12: Lazy Init ═══
+
+var lazyCh = make(chan int, 1)
+
+func init() {
+	go func() { lazyCh <- 42 }()
+}
+
+func BenchmarkLazyInit_Channel(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		v := <-lazyCh
+		lazyCh <- v
+	}
+}
+
+var lazyValue = sync.OnceValue(func() int { return 42 })
+
+func BenchmarkLazyInit_OnceValue(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = lazyValue()
+	}
+}
+
+// ═══ Pattern 13: Result Collector ═══
+
+func BenchmarkResults_Channel(b *testing.B) {
+	b.ReportAllocs()
+	in := make([]int, 16)
+	for i := 0; i < b.N; i++ {
+		results := make(chan int, len(in))
+		for _, v := range in {
+			go func() { results <- v * v }()
+		}
+		sum := 0
+		for range len(in) {
+			sum += <-results
+		}
+	}
+}
+
+func BenchmarkResults_Indexed(b *testing.B) {
+	b.ReportAllocs()
+	in := make([]int, 16)
+	for i := 0; i < b.N; i++ {
+		out := make([]int, len(in))
+		var wg sync.WaitGroup
+		for j, v := range in {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				out[j] = v * v
+			}()
+		}
+		wg.Wait()
+		sum := 0
+		for _, r := range out {
+			sum += r
+		}
+	}
+}
+
+// ═══ Pattern 14: Ping-Pong ═══
+
+func BenchmarkPingPong_Channel(b *testing.B) {
+	b.ReportAllocs()
+	req := make(chan int)
+	resp := make(chan int)
+	go func() {
+		total := 0
+		for n := range req {
+			total += n
+			resp <- total
+		}
+	}()
+	sum := 0
+	for i := 0; i < b.N; i++ {
+		req <- i
+		sum = <-resp
+	}
+	close(req)
+	_ = sum
+}
+
+type pingPongTotal struct {
+	mu    sync.Mutex
+	total int
+}
+
+func (t *pingPongTotal) add(n int) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.total += n
+	return t.total
+}
+
+func BenchmarkPingPong_Mutex(b *testing.B) {
+	b.ReportAllocs()
+	var t pingPongTotal
+	sum := 0
+	for i := 0; i < b.N; i++ {
+		sum = t.add(i)
+	}
+	_ = sum
+}
+
+// ═══ Pattern 15: Aggregator Queue ═══
+
+func BenchmarkAggregator_Channel(b *testing.B) {
+	b.ReportAllocs()
+	events := make(chan int, 1024)
+	done := make(chan struct{})
+	total := 0
+	go func() {
+		for n := range events {
+			total += n
+		}
+		close(done)
+	}()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			events <- 1
+		}
+	})
+	close(events)
+	<-done
+}
+
+func BenchmarkAggregator_Mutex(b *testing.B) {
+	b.ReportAllocs()
+	var mu sync.Mutex
+	total := 0
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			mu.Lock()
+			total++
+			mu.Unlock()
+		}
+	})
+}
+
+// ═══ Pattern 16: Sharded Dispatcher ═══
+
+func BenchmarkSharded_Channel(b *testing.B) {
+	b.ReportAllocs()
+	const n = 8
+	events := make(chan int, 1024)
+	shards := make([]chan int, n)
+	var wg sync.WaitGroup
+	totals := make([]int, n)
+	for i := range shards {
+		shards[i] = make(chan int, 128)
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for v := range shards[i] {
+				totals[i] += v
+			}
+		}(i)
+	}
+	go func() {
+		for v := range events {
+			shards[v%n] <- v
+		}
+		for _, ch := range shards {
+			close(ch)
+		}
+	}()
+	var key atomic.Int64
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			events <- int(key.Add(1))
+		}
+	})
+	close(events)
+	wg.Wait()
+}
+
+func BenchmarkSharded_Locked(b *testing.B) {
+	b.ReportAllocs()
+	const n = 8
+	type shard struct {
+		mu    sync.Mutex
+		total int
+		_     [48]byte // keep shards on separate cache lines
+	}
+	var shards [n]shard
+	var key atomic.Int64
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			v := int(key.Add(1))
+			s := &shards[v%n]
+			s.mu.Lock()
+			s.total += v
+			s.mu.Unlock()
+		}
+	})
+}
+
+// ═══ Pattern 17: Memo Cache ═══
+
+type memoRequest struct {
+	key   int
+	reply chan int
+}
+
+func BenchmarkMemo_Channel(b *testing.B) {
+	b.ReportAllocs()
+	requests := make(chan memoRequest)
+	go func() {
+		cache := map[int]int{}
+		for req := range requests {
+			v, ok := cache[req.key]
+			if !ok {
+				v = req.key * req.key
+				cache[req.key] = v
+			}
+			req.reply <- v
+		}
+	}()
+	var key atomic.Int64
+	b.RunParallel(func(pb *testing.PB) {
+		reply := make(chan int)
+		for pb.Next() {
+			requests <- memoRequest{int(key.Add(1) % 64), reply}
+			<-reply
+		}
+	})
+	close(requests)
+}
+
+func BenchmarkMemo_Locked(b *testing.B) {
+	b.ReportAllocs()
+	var mu sync.Mutex
+	cache := map[int]int{}
+	var key atomic.Int64
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			k := int(key.Add(1) % 64)
+			mu.Lock()
+			v, ok := cache[k]
+			if !ok {
+				v = k * k
+				cache[k] = v
+			}
+			mu.Unlock()
+			_ = v
+		}
+	})
+}
+
+// ═══ Pattern 18: Error Iterator ═══
+
+type parsed struct {
+	n   int
+	err error
+}
+
+func parseItem(v int) (int, error) {
+	if v < 0 {
+		return 0, errNegative
+	}
+	return v * 2, nil
+}
+
+var errNegative = errors.New("negative")
+
+func BenchmarkErrIter_Channel(b *testing.B) {
+	b.ReportAllocs()
+	items := make([]int, 100)
+	for i := range items {
+		items[i] = i
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ch := make(chan parsed)
+		go func() {
+			defer close(ch)
+			for _, v := range items {
+				n, err := parseItem(v)
+				ch <- parsed{n, err}
+			}
+		}()
+		for r := range ch {
+			if r.err != nil {
+				b.Fatal(r.err)
+			}
+		}
+	}
+}
+
+func BenchmarkErrIter_Seq2(b *testing.B) {
+	b.ReportAllocs()
+	items := make([]int, 100)
+	for i := range items {
+		items[i] = i
+	}
+	parseAll := func(yield func(int, error) bool) {
+		for _, v := range items {
+			if !yield(parseItem(v)) {
+				return
+			}
+		}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, err := range iter.Seq2[int, error](parseAll) {
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
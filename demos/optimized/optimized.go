@@ -2,6 +2,8 @@
 package optimized
 
 import (
+	"iter"
+	"os"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -167,3 +169,137 @@ func FanInResults(a, b func() int) []int {
 func DirectTicker(d time.Duration) *time.Ticker {
 	return time.NewTicker(d)
 }
+
+// 11. FanOutDispatcher → chunked parallel-for (or just a loop)
+func SquareAll(in []int) []int {
+	out := make([]int, len(in))
+	const workers = 4
+	chunk := (len(in) + workers - 1) / workers
+	var wg sync.WaitGroup
+	for lo := 0; lo < len(in); lo += chunk {
+		hi := min(lo+chunk, len(in))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := lo; i < hi; i++ {
+				out[i] = in[i] * in[i]
+			}
+		}()
+	}
+	wg.Wait()
+	return out
+}
+
+// 12. LazyInit → sync.OnceValue
+var Hostname = sync.OnceValue(func() string {
+	h, _ := os.Hostname()
+	return h
+})
+
+// 13. ResultCollector → indexed slice writes (errgroup.Group when the work can fail)
+func Lengths(words []string) []int {
+	out := make([]int, len(words))
+	var wg sync.WaitGroup
+	for i, w := range words {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			out[i] = len(w)
+		}()
+	}
+	wg.Wait()
+	return out
+}
+
+// 14. PingPong → mutex-guarded struct
+type runningTotal struct {
+	mu    sync.Mutex
+	total int
+}
+
+func (t *runningTotal) add(n int) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.total += n
+	return t.total
+}
+
+func RunningTotals(nums []int) []int {
+	var t runningTotal
+	out := make([]int, 0, len(nums))
+	for _, n := range nums {
+		out = append(out, t.add(n))
+	}
+	return out
+}
+
+// 15. AggregatorQueue → mutex-guarded state (or per-goroutine shards summed at the end)
+type Counter struct {
+	mu    sync.Mutex
+	total int
+}
+
+func NewCounter() *Counter { return &Counter{} }
+
+func (c *Counter) Add(n int) {
+	c.mu.Lock()
+	c.total += n
+	c.mu.Unlock()
+}
+
+// 16. ShardedDispatcher → a mutex per shard, picked by key in the sender
+func CountByKey(events <-chan string, n int) []map[string]int {
+	type shard struct {
+		mu     sync.Mutex
+		counts map[string]int
+	}
+	shards := make([]shard, n)
+	for i := range shards {
+		shards[i].counts = map[string]int{}
+	}
+	for k := range events {
+		s := &shards[len(k)%n]
+		s.mu.Lock()
+		s.counts[k]++
+		s.mu.Unlock()
+	}
+	counts := make([]map[string]int, n)
+	for i := range shards {
+		counts[i] = shards[i].counts
+	}
+	return counts
+}
+
+// 17. MemoCache → a mutex-guarded map; sync.OnceValue per entry computes
+// each miss once, as golang.org/x/sync/singleflight would
+type Memo struct {
+	f     func(string) int
+	mu    sync.Mutex
+	cache map[string]func() int
+}
+
+func NewMemo(f func(string) int) *Memo {
+	return &Memo{f: f, cache: map[string]func() int{}}
+}
+
+func (m *Memo) Get(key string) int {
+	m.mu.Lock()
+	get, ok := m.cache[key]
+	if !ok {
+		get = sync.OnceValue(func() int { return m.f(key) })
+		m.cache[key] = get
+	}
+	m.mu.Unlock()
+	return get()
+}
+
+// 18. ErrorIterator → iter.Seq2[T, error]; breaking out of the loop stops parsing
+func ParseAll(items []string, parse func(string) (int, error)) iter.Seq2[int, error] {
+	return func(yield func(int, error) bool) {
+		for _, s := range items {
+			if !yield(parse(s)) {
+				return
+			}
+		}
+	}
+}
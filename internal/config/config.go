@@ -0,0 +1,199 @@
+// Package config reads chanopt configuration files (.chanopt.yaml).
+//
+// A configuration file is validated against Schema when it is loaded.
+// Unknown keys and values are errors, with a suggestion when they look
+// like a typo, so that a misspelled threshold cannot silently change what
+// is reported:
+//
+//	# .chanopt.yaml
+//	version: 1
+//	min_confidence: 0.8
+//	disable: [Singleton, PipelineStage]
+//	ignore:
+//	  - func: ".*Mock.*"
+//	    pattern: "*"
+//
+// A Resolver finds the configuration in effect in a directory by merging
+// the files in it and its parents, as .editorconfig does.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"strconv"
+)
+
+// FileName is the name of the configuration file.
+const FileName = ".chanopt.yaml"
+
+// SchemaVersion is the version of the configuration format described by
+// Schema.
+const SchemaVersion = 1
+
+// Config is the content of a configuration file. Fields the file does not
+// set keep their zero value.
+type Config struct {
+	MinConfidence float64  // drop findings with a lower confidence
+	Disable       []string // pattern and check names or rule IDs not to report
+	Ignore        []Ignore // findings not to report in functions matching a name pattern
+	Pipeline      bool     // enable the advisory pipeline stage check
+	IncludeVendor bool     // report findings in third-party packages
+	Root          bool     // do not merge files from parent directories
+
+	// Set holds the keys the file sets. Only these override an enclosing
+	// configuration in Merge, so that a nested file can set a value back
+	// to zero.
+	Set map[string]bool
+}
+
+// Merge returns c overridden by the keys set in o. A nested file replaces
+// a key's value, it does not add to it: a disable or ignore list in o
+// replaces the one in c.
+func (c *Config) Merge(o *Config) *Config {
+	m := *c
+	m.Set = maps.Clone(c.Set)
+	if m.Set == nil {
+		m.Set = map[string]bool{}
+	}
+	for key := range o.Set {
+		switch key {
+		case "min_confidence":
+			m.MinConfidence = o.MinConfidence
+		case "disable":
+			m.Disable = o.Disable
+		case "ignore":
+			m.Ignore = o.Ignore
+		case "pipeline":
+			m.Pipeline = o.Pipeline
+		case "include_vendor":
+			m.IncludeVendor = o.IncludeVendor
+		}
+		m.Set[key] = true
+	}
+	return &m
+}
+
+// Ignore drops the findings of a pattern, check or rule ID, or of all of
+// them if Pattern is "*", in the functions and methods whose name matches
+// Func. Mocks and adapters generated next to the code they stand in for
+// cannot be told apart from it by path, but can by name.
+type Ignore struct {
+	Func    string // regular expression matched against the whole name
+	Pattern string // name, rule ID or "*"
+
+	re *regexp.Regexp
+}
+
+// Matches reports whether ig applies to a finding of the category pattern
+// and rule ID rule in the function fn of the package pkgPath. Functions
+// are named as in "NewMockStore" or "(*MockStore).Next", and Func matches
+// either that name or the one qualified by the package path, as in
+// "example.com/store.(*MockStore).Next", the form in stack traces.
+func (ig *Ignore) Matches(pkgPath, fn, pattern, rule string) bool {
+	if fn == "" || ig.Pattern != "*" && ig.Pattern != pattern && ig.Pattern != rule {
+		return false
+	}
+	if ig.re == nil {
+		ig.re = regexp.MustCompile(anchor(ig.Func))
+	}
+	return ig.re.MatchString(fn) || ig.re.MatchString(pkgPath+"."+fn)
+}
+
+// anchor makes expr match whole names only.
+func anchor(expr string) string {
+	return "^(?:" + expr + ")$"
+}
+
+// Error is a problem in a configuration file.
+type Error struct {
+	File string
+	Line int
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Msg)
+}
+
+// Load reads and validates the configuration file at path.
+func Load(path string) (*Config, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(path, src)
+}
+
+// Parse validates and decodes the configuration in src, read from the file
+// name. All problems are reported, joined into one error.
+func Parse(name string, src []byte) (*Config, error) {
+	doc, err := parseYAML(src)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if errs := root.validate(doc, ""); len(errs) > 0 {
+		var all []error
+		for _, e := range errs {
+			e.File = name
+			all = append(all, e)
+		}
+		return nil, errors.Join(all...)
+	}
+
+	cfg := Config{Set: map[string]bool{}}
+	for _, key := range doc.keys {
+		cfg.Set[key] = true
+	}
+	if n := doc.get("min_confidence"); n != nil {
+		cfg.MinConfidence, _ = strconv.ParseFloat(n.scalar, 64)
+	}
+	if n := doc.get("disable"); n != nil {
+		for _, item := range n.values {
+			cfg.Disable = append(cfg.Disable, item.scalar)
+		}
+	}
+	if n := doc.get("ignore"); n != nil {
+		var errs []error
+		for i, item := range n.values {
+			ig := Ignore{Func: item.get("func").scalar, Pattern: "*"}
+			if p := item.get("pattern"); p != nil {
+				ig.Pattern = p.scalar
+			}
+			var err error
+			if ig.re, err = regexp.Compile(anchor(ig.Func)); err != nil {
+				errs = append(errs, &Error{name, item.get("func").line, fmt.Sprintf("ignore[%d].func: %v", i, err)})
+				continue
+			}
+			cfg.Ignore = append(cfg.Ignore, ig)
+		}
+		if len(errs) > 0 {
+			return nil, errors.Join(errs...)
+		}
+	}
+	if n := doc.get("pipeline"); n != nil {
+		cfg.Pipeline = n.scalar == "true"
+	}
+	if n := doc.get("include_vendor"); n != nil {
+		cfg.IncludeVendor = n.scalar == "true"
+	}
+	if n := doc.get("root"); n != nil {
+		cfg.Root = n.scalar == "true"
+	}
+	return &cfg, nil
+}
+
+// CheckNames returns an error for each name that is neither a pattern nor
+// a check, as the disable key would.
+func CheckNames(names []string) error {
+	items := root.Properties["disable"].Items
+	var errs []error
+	for _, name := range names {
+		for _, e := range items.validate(&node{kind: scalarNode, scalar: name}, "") {
+			errs = append(errs, errors.New(e.Msg))
+		}
+	}
+	return errors.Join(errs...)
+}
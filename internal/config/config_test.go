@@ -0,0 +1,293 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ravisastryk/chanopt/pkg/analyzer"
+)
+
+func TestParse(t *testing.T) {
+	src := `# chanopt settings
+version: 1
+min_confidence: 0.8
+disable:
+  - Singleton
+  - "PipelineStage"
+pipeline: true
+`
+	cfg, err := Parse(FileName, []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Config{MinConfidence: 0.8, Disable: []string{"Singleton", "PipelineStage"}, Pipeline: true}
+	if cfg.MinConfidence != want.MinConfidence || !slices.Equal(cfg.Disable, want.Disable) || cfg.Pipeline != want.Pipeline || cfg.IncludeVendor {
+		t.Errorf("Parse = %+v, want %+v", *cfg, want)
+	}
+
+	cfg, err = Parse(FileName, []byte("disable: [LazyInit, BusyPoll]  # flow style\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(cfg.Disable, []string{"LazyInit", "BusyPoll"}) {
+		t.Errorf("flow sequence: Disable = %q", cfg.Disable)
+	}
+}
+
+func TestIgnore(t *testing.T) {
+	src := `ignore:
+  - {func: ".*Mock.*", pattern: "*"}
+  - func: '\(\*fakeClock\)\..*'
+    pattern: ChanTicker
+  - func: NewIDs
+`
+	cfg, err := Parse(FileName, []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Ignore) != 3 || cfg.Ignore[2].Pattern != "*" {
+		t.Fatalf("Ignore = %+v, want 3 entries, the last for every pattern", cfg.Ignore)
+	}
+	for _, tc := range []struct {
+		fn, pattern, rule string
+		want              bool
+	}{
+		{"NewMockStore", "IDGenerator", "CHOPT001", true},
+		{"(*MockStore).Next", "StopLeak", "CHOPT102", true},
+		{"(*Store).Next", "IDGenerator", "CHOPT001", false},
+		{"(*fakeClock).Tick", "ChanTicker", "CHOPT010", true},
+		{"(*fakeClock).Tick", "StopLeak", "CHOPT102", false},
+		{"fakeClock.Tick", "ChanTicker", "CHOPT010", false},
+		{"NewIDs", "IDGenerator", "CHOPT001", true},
+		{"NewIDsFor", "IDGenerator", "CHOPT001", false}, // the whole name must match
+		{"", "IDGenerator", "CHOPT001", false},
+	} {
+		got := slices.ContainsFunc(cfg.Ignore, func(ig Ignore) bool { return ig.Matches("example.com/store", tc.fn, tc.pattern, tc.rule) })
+		if got != tc.want {
+			t.Errorf("ignored(%s, %s) = %t, want %t", tc.fn, tc.pattern, got, tc.want)
+		}
+	}
+	byPath := Ignore{Func: `example\.com/store\..*`, Pattern: "CHOPT001"}
+	if !byPath.Matches("example.com/store", "NewIDs", "IDGenerator", "CHOPT001") {
+		t.Errorf("%s does not match NewIDs by its package path", byPath.Func)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, tc := range []struct {
+		src, want string
+	}{
+		{"min_confidense: 0.8\n", `.chanopt.yaml:1: unknown key "min_confidense" (did you mean min_confidence?)`},
+		{"version: 1\nmin_confidence: 80\n", `.chanopt.yaml:2: min_confidence: 80 is above the maximum 1`},
+		{"min_confidence: high\n", `.chanopt.yaml:1: min_confidence: want number, got "high"`},
+		{"disable: [idgenerator]\n", `.chanopt.yaml:1: disable[0]: "idgenerator" is not one of the allowed values (did you mean IDGenerator?)`},
+		{"pipeline: yes please\n", `.chanopt.yaml:1: pipeline: want boolean, got "yes please"`},
+		{"disable: Singleton\n", `.chanopt.yaml:1: disable: want array, got "Singleton"`},
+		{"version: 2\n", `.chanopt.yaml:1: version: "2" is not one of the allowed values`},
+		{"pipeline: true\npipeline: false\n", `.chanopt.yaml: line 2: duplicate key "pipeline"`},
+		{"disable:\n  - Singleton\n   - LazyInit\n", `.chanopt.yaml: line 3: unexpected indentation`},
+		{"ignore:\n  - {pattern: Singleton}\n", `.chanopt.yaml:2: missing key "ignore[0].func"`},
+		{"ignore:\n  - {func: Mock, pattern: singleton}\n", `.chanopt.yaml:2: ignore[0].pattern: "singleton" is not one of the allowed values (did you mean Singleton?)`},
+		{"ignore:\n  - func: (*Mock\n", `.chanopt.yaml:2: ignore[0].func: error parsing regexp`},
+		{"ignore: [{func: Mock, path: mocks}]\n", `.chanopt.yaml:1: unknown key "ignore[0].path"`},
+	} {
+		_, err := Parse(FileName, []byte(tc.src))
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Parse(%q) error = %v, want %s", tc.src, err, tc.want)
+		}
+	}
+}
+
+func TestLoadPatterns(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "patterns.yaml")
+	load := func(src string) ([]Pattern, error) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return LoadPatterns(path)
+	}
+	pats, err := load("patterns:\n  - name: QueueDrain\n    want: \"queuedrain: .*\"\n    example: examples/queuedrain.txtar\n")
+	want := []Pattern{{"QueueDrain", "queuedrain: .*", filepath.Join(dir, "examples/queuedrain.txtar")}}
+	if err != nil || !slices.Equal(pats, want) {
+		t.Errorf("LoadPatterns = %+v, %v, want %+v", pats, err, want)
+	}
+
+	for _, tc := range []struct {
+		src, want string
+	}{
+		{"# nothing yet\n", `patterns.yaml:1: missing key "patterns"`},
+		{"patterns:\n  - {name: QueueDrain, want: x}\n", `patterns.yaml:2: missing key "patterns[0].example"`},
+		{"patterns:\n  - {name: QueueDrain, want: x, example: a, pattern: b}\n", `patterns.yaml:2: unknown key "patterns[0].pattern"`},
+		{"patterns:\n  - {name: queue-drain, want: x, example: a}\n", `patterns.yaml:2: patterns[0].name: "queue-drain" is not a Go identifier`},
+		{"patterns:\n  - {name: Q, want: x, example: a}\n  - {name: Q, want: y, example: b}\n", `patterns.yaml:3: patterns[1].name: Q is defined twice`},
+		{"patterns:\n  - {name: Q, want: \"(x\", example: a}\n", `patterns.yaml:2: patterns[0].want: error parsing regexp`},
+	} {
+		if _, err := load(tc.src); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("LoadPatterns(%q) error = %v, want %s", tc.src, err, tc.want)
+		}
+	}
+}
+
+// TestSchemaNames keeps the disable enum in the schema in sync with the
+// patterns and checks the analyzer reports.
+func TestSchemaNames(t *testing.T) {
+	var want []string
+	for p := range analyzer.Registry {
+		want = append(want, p.String())
+	}
+	for name := range analyzer.Checks {
+		want = append(want, name)
+	}
+	for _, cat := range analyzer.Rules() {
+		want = append(want, analyzer.RuleID(cat))
+	}
+	var got []string
+	for _, e := range root.Properties["disable"].Items.Enum {
+		got = append(got, e.(string))
+	}
+	slices.Sort(want)
+	slices.Sort(got)
+	if !slices.Equal(got, want) {
+		t.Errorf("schema disable enum = %q, want %q", got, want)
+	}
+	got = nil
+	for _, e := range root.Properties["ignore"].Items.Properties["pattern"].Enum {
+		got = append(got, e.(string))
+	}
+	slices.Sort(got)
+	if want = append([]string{"*"}, want...); !slices.Equal(got, want) {
+		t.Errorf("schema ignore pattern enum = %q, want %q", got, want)
+	}
+	if err := CheckNames([]string{"StopLeak", "singleton"}); err == nil || !strings.Contains(err.Error(), "did you mean Singleton?") {
+		t.Errorf("CheckNames error = %v", err)
+	}
+}
+
+func TestResolver(t *testing.T) {
+	root := t.TempDir()
+	write := func(dir, src string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, dir, FileName), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("repo", "root: true\nmin_confidence: 0.8\ndisable: [Singleton]\n")
+	write("repo/legacy", "min_confidence: 0.95\n")
+	write("repo/hot", "min_confidence: 0\ndisable: []\npipeline: true\n")
+	write("repo/vendored", "root: true\n")
+	if err := os.MkdirAll(filepath.Join(root, "repo/legacy/deep"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	r := &Resolver{Top: &Config{Disable: []string{"LazyInit"}, Set: map[string]bool{"disable": true}}}
+	for _, tc := range []struct {
+		dir      string
+		min      float64
+		disable  []string
+		pipeline bool
+	}{
+		{"repo", 0.8, []string{"LazyInit"}, false},
+		{"repo/legacy/deep", 0.95, []string{"LazyInit"}, false},
+		{"repo/hot", 0, []string{"LazyInit"}, true},
+		{"repo/vendored", 0, []string{"LazyInit"}, false},
+	} {
+		c, err := r.For(filepath.Join(root, tc.dir))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.MinConfidence != tc.min || !slices.Equal(c.Disable, tc.disable) || c.Pipeline != tc.pipeline {
+			t.Errorf("For(%s) = %+v, want min_confidence %g, disable %q, pipeline %t", tc.dir, *c, tc.min, tc.disable, tc.pipeline)
+		}
+	}
+
+	r = &Resolver{}
+	c, err := r.For(filepath.Join(root, "repo/legacy"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(c.Disable, []string{"Singleton"}) {
+		t.Errorf("nested file without disable: Disable = %q, want the parent's", c.Disable)
+	}
+
+	write("repo/broken", "min_confidense: 1\n")
+	if _, err := r.For(filepath.Join(root, "repo/broken")); err == nil || !strings.Contains(err.Error(), "min_confidense") {
+		t.Errorf("For(broken) error = %v", err)
+	}
+}
+
+func TestResolverHash(t *testing.T) {
+	root := t.TempDir()
+	hash := func(src string) string {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(root, FileName), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		r := &Resolver{}
+		if _, err := r.For(root); err != nil {
+			t.Fatal(err)
+		}
+		return r.Hash()
+	}
+	a := hash("root: true\ndisable: [Singleton, LazyInit]\n")
+	if b := hash("root: true\n# reordered\ndisable: [LazyInit, Singleton]\n"); a != b {
+		t.Errorf("Hash differs for the same settings: %s, %s", a, b)
+	}
+	if b := hash("root: true\ndisable: [Singleton]\n"); a == b {
+		t.Errorf("Hash is %s for different settings", a)
+	}
+	if b := hash("root: true\ndisable: [Singleton, LazyInit]\nignore: [{func: Mock}]\n"); a == b {
+		t.Errorf("Hash is %s for different settings", a)
+	}
+	if !strings.HasPrefix(a, "sha256:") {
+		t.Errorf("Hash = %q, want a sha256: prefix", a)
+	}
+	top := &Resolver{Top: &Config{MinConfidence: 0.9, Set: map[string]bool{"min_confidence": true}}}
+	if h := top.Hash(); h == (&Resolver{}).Hash() {
+		t.Errorf("Hash is %s with and without a command-line min_confidence", h)
+	}
+}
+
+// TestResolverHashDirs checks that the hash tells apart settings that
+// apply in different directories, and does not depend on where the module
+// is.
+func TestResolverHashDirs(t *testing.T) {
+	hash := func(root, a, b string) string {
+		t.Helper()
+		for name, src := range map[string]string{
+			"go.mod":                     "module example.com/m\n",
+			filepath.Join("a", FileName): a,
+			filepath.Join("b", FileName): b,
+		} {
+			path := filepath.Join(root, name)
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		r := &Resolver{}
+		for _, dir := range []string{"a", "b"} {
+			if _, err := r.For(filepath.Join(root, dir)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return r.Hash()
+	}
+	strict, loose := "disable: [Singleton]\n", "min_confidence: 0.9\n"
+	h := hash(t.TempDir(), strict, loose)
+	if swapped := hash(t.TempDir(), loose, strict); swapped == h {
+		t.Errorf("Hash is %s with the settings of a and b swapped", h)
+	}
+	if moved := hash(t.TempDir(), strict, loose); moved != h {
+		t.Errorf("Hash differs for the same module in another directory: %s, %s", h, moved)
+	}
+}
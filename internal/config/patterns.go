@@ -0,0 +1,98 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// Pattern is a custom pattern defined in a patterns file, for which
+// chanopt gen-testdata writes fixtures. chanopt does not detect custom
+// patterns itself: they belong to third-party matchers, which run the
+// fixtures with their own analyzer.
+//
+//	# patterns.yaml
+//	patterns:
+//	  - name: QueueDrain
+//	    want: "queuedrain: drain loop"
+//	    example: queuedrain.txtar
+type Pattern struct {
+	Name    string // a Go identifier; the fixture files are named after it in lower case
+	Want    string // regular expression of the diagnostic, as in a // want comment
+	Example string // txtar archive with before.go and after.go, as in pkg/templates
+}
+
+// patternsSchema is the schema of a patterns file.
+var patternsSchema = func() *schema {
+	no := false
+	str := &schema{Type: "string"}
+	return &schema{
+		Type:                 "object",
+		AdditionalProperties: &no,
+		Required:             []string{"patterns"},
+		Properties: map[string]*schema{
+			"patterns": {
+				Type: "array",
+				Items: &schema{
+					Type:                 "object",
+					AdditionalProperties: &no,
+					Required:             []string{"name", "want", "example"},
+					Properties:           map[string]*schema{"name": str, "want": str, "example": str},
+				},
+			},
+		},
+	}
+}()
+
+// patternName matches the names a custom pattern can have, which become
+// file names.
+var patternName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*$`)
+
+// LoadPatterns reads and validates the patterns file at path. The example
+// of each pattern is resolved relative to the file's directory. All
+// problems are reported, joined into one error.
+func LoadPatterns(path string) ([]Pattern, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := parseYAML(src)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	var errs []error
+	for _, e := range patternsSchema.validate(doc, "") {
+		e.File = path
+		errs = append(errs, e)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	var pats []Pattern
+	seen := map[string]bool{}
+	for i, item := range doc.get("patterns").values {
+		p := Pattern{
+			Name:    item.get("name").scalar,
+			Want:    item.get("want").scalar,
+			Example: filepath.Join(filepath.Dir(path), item.get("example").scalar),
+		}
+		switch {
+		case !patternName.MatchString(p.Name):
+			errs = append(errs, &Error{path, item.get("name").line, fmt.Sprintf("patterns[%d].name: %q is not a Go identifier", i, p.Name)})
+		case seen[p.Name]:
+			errs = append(errs, &Error{path, item.get("name").line, fmt.Sprintf("patterns[%d].name: %s is defined twice", i, p.Name)})
+		}
+		if _, err := regexp.Compile(p.Want); err != nil {
+			errs = append(errs, &Error{path, item.get("want").line, fmt.Sprintf("patterns[%d].want: %v", i, err)})
+		}
+		seen[p.Name] = true
+		pats = append(pats, p)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return pats, nil
+}
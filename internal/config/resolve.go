@@ -0,0 +1,145 @@
+package config
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Resolver returns the configuration in effect in a directory: the
+// .chanopt.yaml files of the directory and each of its parents, merged
+// outermost first so that the nearest file wins, as with .editorconfig.
+// The search upward stops at a file that sets root: true.
+//
+//	repo/.chanopt.yaml           min_confidence: 0.8
+//	repo/legacy/.chanopt.yaml    min_confidence: 0.95   # quieter here
+//	repo/hot/.chanopt.yaml       disable: []            # everything here
+type Resolver struct {
+	Base *Config // beneath every file, such as one named by -config; may be nil
+	Top  *Config // over every file, such as command-line flags; may be nil
+
+	mu    sync.Mutex
+	dirs  map[string]*Config // merged files by absolute directory
+	found map[string]bool    // directories and canonical forms of the files read
+}
+
+// For returns the configuration in effect in dir.
+func (r *Resolver) For(dir string) (*Config, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	files, err := r.files(abs, moduleRoot(abs))
+	r.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	c := &Config{}
+	if r.Base != nil {
+		c = c.Merge(r.Base)
+	}
+	c = c.Merge(files)
+	if r.Top != nil {
+		c = c.Merge(r.Top)
+	}
+	return c, nil
+}
+
+// Hash returns a digest of the configuration inputs, such as
+// "sha256:3f2a...", for telling whether two runs were configured alike:
+// Base, Top and the files read by For. A driver resolves the directory of
+// every package it analyzes, not only those with findings, so that the
+// hash does not change with the code's findings. It depends on the
+// settings and on the directories that hold them, relative to the module
+// root, but not on where the module is or how the files spell them.
+func (r *Resolver) Hash() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	h := sha256.New()
+	for _, c := range []*Config{r.Base, r.Top} {
+		if c == nil {
+			c = &Config{}
+		}
+		fmt.Fprintf(h, "%s\n", c.canonical())
+	}
+	for _, c := range slices.Sorted(maps.Keys(r.found)) {
+		fmt.Fprintf(h, "%s\n", c)
+	}
+	return fmt.Sprintf("sha256:%x", h.Sum(nil))
+}
+
+// canonical returns the settings of c on one line, with the disable and
+// ignore lists sorted since their order does not matter, and the keys c
+// sets, since only those override an enclosing configuration.
+func (c *Config) canonical() string {
+	disable := slices.Sorted(slices.Values(c.Disable))
+	var ignore []string
+	for _, ig := range c.Ignore {
+		ignore = append(ignore, strconv.Quote(ig.Func)+":"+ig.Pattern)
+	}
+	slices.Sort(ignore)
+	return fmt.Sprintf("min_confidence=%g disable=[%s] ignore=[%s] pipeline=%t include_vendor=%t root=%t set=[%s]",
+		c.MinConfidence, strings.Join(disable, ","), strings.Join(ignore, ","), c.Pipeline, c.IncludeVendor,
+		c.Root, strings.Join(slices.Sorted(maps.Keys(c.Set)), ","))
+}
+
+// files merges the configuration files from the root down to dir, and
+// records each it reads with its directory relative to mod.
+func (r *Resolver) files(dir, mod string) (*Config, error) {
+	if c, ok := r.dirs[dir]; ok {
+		return c, nil
+	}
+	own, err := Load(filepath.Join(dir, FileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		own, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	c := &Config{}
+	if up := filepath.Dir(dir); up != dir && (own == nil || !own.Root) {
+		if c, err = r.files(up, mod); err != nil {
+			return nil, err
+		}
+	}
+	if own != nil {
+		c = c.Merge(own)
+		if r.found == nil {
+			r.found = map[string]bool{}
+		}
+		rel := dir
+		if mod != "" {
+			rel, _ = filepath.Rel(mod, dir)
+		}
+		r.found[filepath.ToSlash(rel)+": "+own.canonical()] = true
+	}
+	if r.dirs == nil {
+		r.dirs = map[string]*Config{}
+	}
+	r.dirs[dir] = c
+	return c, nil
+}
+
+// moduleRoot returns the nearest directory from dir upward that holds a
+// go.mod file, or "" if there is none.
+func moduleRoot(dir string) string {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir
+		}
+		up := filepath.Dir(dir)
+		if up == dir {
+			return ""
+		}
+		dir = up
+	}
+}
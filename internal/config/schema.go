@@ -0,0 +1,211 @@
+package config
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Schema is the JSON schema of the configuration file. Editors with YAML
+// language support can validate .chanopt.yaml against it.
+//
+//go:embed schema.json
+var Schema []byte
+
+// schema is the subset of JSON Schema that Schema uses.
+type schema struct {
+	Type                 string             `json:"type"`
+	Description          string             `json:"description"`
+	Properties           map[string]*schema `json:"properties"`
+	AdditionalProperties *bool              `json:"additionalProperties"`
+	Required             []string           `json:"required"`
+	Items                *schema            `json:"items"`
+	Enum                 []any              `json:"enum"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+}
+
+var root = func() *schema {
+	var s schema
+	if err := json.Unmarshal(Schema, &s); err != nil {
+		panic("chanopt: invalid config schema: " + err.Error())
+	}
+	return &s
+}()
+
+// validate checks n against s and returns one error per problem, each
+// located at the line of the offending key or value.
+func (s *schema) validate(n *node, path string) []*Error {
+	var errs []*Error
+	fail := func(line int, format string, args ...any) {
+		errs = append(errs, &Error{Line: line, Msg: fmt.Sprintf(format, args...)})
+	}
+	if !s.hasType(n) {
+		where := path
+		if where == "" {
+			where = "top level"
+		}
+		fail(n.line, "%s: want %s, got %s", where, s.Type, describe(n))
+		return errs
+	}
+	switch n.kind {
+	case mapNode:
+		for i, key := range n.keys {
+			prop := s.Properties[key]
+			if prop == nil {
+				if s.AdditionalProperties == nil || *s.AdditionalProperties {
+					continue
+				}
+				msg := fmt.Sprintf("unknown key %q", join(path, key))
+				if alt := closest(key, s.propertyNames()); alt != "" {
+					msg += fmt.Sprintf(" (did you mean %s?)", alt)
+				}
+				fail(n.lines[i], "%s", msg)
+				continue
+			}
+			errs = append(errs, prop.validate(n.values[i], join(path, key))...)
+		}
+		for _, key := range s.Required {
+			if n.get(key) == nil {
+				fail(n.line, "missing key %q", join(path, key))
+			}
+		}
+	case seqNode:
+		if s.Items != nil {
+			for i, item := range n.values {
+				errs = append(errs, s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case scalarNode:
+		if len(s.Enum) > 0 && !s.inEnum(n) {
+			var names []string
+			for _, e := range s.Enum {
+				names = append(names, fmt.Sprint(e))
+			}
+			msg := fmt.Sprintf("%q is not one of the allowed values", n.scalar)
+			if path != "" {
+				msg = path + ": " + msg
+			}
+			if alt := closest(n.scalar, names); alt != "" {
+				msg += fmt.Sprintf(" (did you mean %s?)", alt)
+			}
+			fail(n.line, "%s", msg)
+		}
+		if s.Type == "number" || s.Type == "integer" {
+			v, _ := strconv.ParseFloat(n.scalar, 64)
+			if s.Minimum != nil && v < *s.Minimum {
+				fail(n.line, "%s: %s is below the minimum %g", path, n.scalar, *s.Minimum)
+			}
+			if s.Maximum != nil && v > *s.Maximum {
+				fail(n.line, "%s: %s is above the maximum %g", path, n.scalar, *s.Maximum)
+			}
+		}
+	}
+	return errs
+}
+
+// hasType reports whether n is of the schema's JSON type.
+func (s *schema) hasType(n *node) bool {
+	switch s.Type {
+	case "":
+		return true
+	case "object":
+		return n.kind == mapNode
+	case "array":
+		return n.kind == seqNode
+	}
+	if n.kind != scalarNode {
+		return false
+	}
+	switch s.Type {
+	case "string":
+		return true
+	case "boolean":
+		return !n.quoted && (n.scalar == "true" || n.scalar == "false")
+	case "number":
+		_, err := strconv.ParseFloat(n.scalar, 64)
+		return !n.quoted && err == nil
+	case "integer":
+		_, err := strconv.Atoi(n.scalar)
+		return !n.quoted && err == nil
+	}
+	return false
+}
+
+func (s *schema) inEnum(n *node) bool {
+	for _, e := range s.Enum {
+		switch e := e.(type) {
+		case string:
+			if n.scalar == e {
+				return true
+			}
+		case float64:
+			if v, err := strconv.ParseFloat(n.scalar, 64); err == nil && v == e {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *schema) propertyNames() []string {
+	var names []string
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func describe(n *node) string {
+	switch {
+	case n.kind != scalarNode:
+		return n.kind.String()
+	case n.scalar == "" && !n.quoted:
+		return "nothing"
+	}
+	return strconv.Quote(n.scalar)
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// closest returns the candidate nearest to s by edit distance, ignoring
+// case, if it is close enough to be a likely typo.
+func closest(s string, candidates []string) string {
+	best, bestDist := "", len(s)/3+2
+	for _, c := range candidates {
+		if d := editDistance(strings.ToLower(s), strings.ToLower(c)); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
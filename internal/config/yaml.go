@@ -0,0 +1,319 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// node is a parsed YAML value. chanopt only needs the subset of YAML used
+// by config files: block and flow mappings and sequences of scalars,
+// comments, and single- or double-quoted strings. Anchors, tags, multi-line
+// scalars and multiple documents are not supported.
+type node struct {
+	line   int
+	kind   nodeKind
+	scalar string
+	quoted bool
+	keys   []string // mapping keys, in order
+	lines  []int    // line of each key
+	values []*node  // mapping values, parallel to keys, or sequence items
+}
+
+type nodeKind int
+
+const (
+	scalarNode nodeKind = iota
+	mapNode
+	seqNode
+)
+
+func (k nodeKind) String() string {
+	return [...]string{"scalar", "mapping", "sequence"}[k]
+}
+
+// get returns the value of key in a mapping node.
+func (n *node) get(key string) *node {
+	for i, k := range n.keys {
+		if k == key {
+			return n.values[i]
+		}
+	}
+	return nil
+}
+
+// yamlLine is one non-blank source line with its comment removed.
+type yamlLine struct {
+	no     int
+	indent int
+	text   string
+}
+
+// parseYAML parses src. An empty document is an empty mapping.
+func parseYAML(src []byte) (*node, error) {
+	var lines []yamlLine
+	for i, l := range strings.Split(string(src), "\n") {
+		if lead := l[:len(l)-len(strings.TrimLeft(l, " \t"))]; strings.Contains(lead, "\t") {
+			return nil, fmt.Errorf("line %d: tabs cannot be used for indentation", i+1)
+		}
+		text := strings.TrimRight(stripComment(l), " \r")
+		trimmed := strings.TrimLeft(text, " ")
+		if trimmed == "" || trimmed == "---" {
+			continue
+		}
+		lines = append(lines, yamlLine{i + 1, len(text) - len(trimmed), trimmed})
+	}
+	if len(lines) == 0 {
+		return &node{line: 1, kind: mapNode}, nil
+	}
+	p := &yamlParser{lines: lines}
+	n, err := p.block(lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.i < len(p.lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", p.lines[p.i].no)
+	}
+	return n, nil
+}
+
+// stripComment removes a # comment that is not inside quotes.
+func stripComment(l string) string {
+	var quote byte
+	for i := 0; i < len(l); i++ {
+		switch c := l[i]; {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || l[i-1] == ' '):
+			return l[:i]
+		}
+	}
+	return l
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	i     int
+}
+
+// block parses the mapping or sequence whose lines are indented by indent.
+func (p *yamlParser) block(indent int) (*node, error) {
+	first := p.lines[p.i]
+	if first.text == "-" || strings.HasPrefix(first.text, "- ") {
+		return p.sequence(indent)
+	}
+	return p.mapping(indent)
+}
+
+func (p *yamlParser) sequence(indent int) (*node, error) {
+	n := &node{line: p.lines[p.i].no, kind: seqNode}
+	for p.i < len(p.lines) {
+		l := p.lines[p.i]
+		if l.indent < indent {
+			break
+		}
+		if l.indent > indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", l.no)
+		}
+		if l.text != "-" && !strings.HasPrefix(l.text, "- ") {
+			return nil, fmt.Errorf("line %d: expected a sequence item (- ...)", l.no)
+		}
+		rest := strings.TrimLeft(strings.TrimPrefix(l.text, "-"), " ")
+		var item *node
+		var err error
+		switch {
+		case rest == "":
+			p.i++
+			item, err = p.child(indent, l.no)
+		case isMappingLine(rest):
+			// "- key: value" starts a mapping indented past the dash.
+			inner := indent + len(l.text) - len(rest)
+			p.lines[p.i] = yamlLine{l.no, inner, rest}
+			item, err = p.mapping(inner)
+		default:
+			p.i++
+			item, err = parseFlow(rest, l.no)
+		}
+		if err != nil {
+			return nil, err
+		}
+		n.values = append(n.values, item)
+	}
+	return n, nil
+}
+
+func (p *yamlParser) mapping(indent int) (*node, error) {
+	n := &node{line: p.lines[p.i].no, kind: mapNode}
+	for p.i < len(p.lines) {
+		l := p.lines[p.i]
+		if l.indent < indent {
+			break
+		}
+		if l.indent > indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", l.no)
+		}
+		key, rest, ok := splitKey(l.text)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key: value", l.no)
+		}
+		if n.get(key) != nil {
+			return nil, fmt.Errorf("line %d: duplicate key %q", l.no, key)
+		}
+		p.i++
+		var v *node
+		var err error
+		if rest == "" {
+			v, err = p.child(indent, l.no)
+		} else {
+			v, err = parseFlow(rest, l.no)
+		}
+		if err != nil {
+			return nil, err
+		}
+		n.keys = append(n.keys, key)
+		n.lines = append(n.lines, l.no)
+		n.values = append(n.values, v)
+	}
+	return n, nil
+}
+
+// child parses the block value of a key or dash on line no, which is more
+// indented than parent. A sequence may also sit at the parent's indentation
+// under a key. A missing value is an empty scalar.
+func (p *yamlParser) child(parent, no int) (*node, error) {
+	if p.i < len(p.lines) {
+		l := p.lines[p.i]
+		if l.indent > parent || l.indent == parent && strings.HasPrefix(l.text, "- ") {
+			return p.block(l.indent)
+		}
+	}
+	return &node{line: no, kind: scalarNode}, nil
+}
+
+// isMappingLine reports whether s starts with "key:".
+func isMappingLine(s string) bool {
+	if s[0] == '[' || s[0] == '{' || s[0] == '"' || s[0] == '\'' {
+		return false
+	}
+	_, _, ok := splitKey(s)
+	return ok
+}
+
+// splitKey splits "key: value" or "key:".
+func splitKey(s string) (key, rest string, ok bool) {
+	if i := strings.Index(s, ": "); i > 0 {
+		return unquote(strings.TrimSpace(s[:i])), strings.TrimSpace(s[i+2:]), true
+	}
+	if strings.HasSuffix(s, ":") && len(s) > 1 {
+		return unquote(strings.TrimSpace(s[:len(s)-1])), "", true
+	}
+	return "", "", false
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// parseFlow parses a value written on one line: a scalar, or a flow
+// sequence or mapping such as [a, b] or {func: x, pattern: y}.
+func parseFlow(s string, line int) (*node, error) {
+	f := &flowParser{s: s, line: line}
+	n, err := f.value()
+	if err != nil {
+		return nil, err
+	}
+	f.space()
+	if f.i < len(f.s) {
+		return nil, fmt.Errorf("line %d: unexpected %q after value", line, f.s[f.i:])
+	}
+	return n, nil
+}
+
+type flowParser struct {
+	s    string
+	i    int
+	line int
+}
+
+func (f *flowParser) space() {
+	for f.i < len(f.s) && f.s[f.i] == ' ' {
+		f.i++
+	}
+}
+
+func (f *flowParser) value() (*node, error) {
+	f.space()
+	if f.i == len(f.s) {
+		return &node{line: f.line, kind: scalarNode}, nil
+	}
+	switch f.s[f.i] {
+	case '[':
+		return f.collection(']', seqNode)
+	case '{':
+		return f.collection('}', mapNode)
+	case '"', '\'':
+		q := f.s[f.i]
+		end := strings.IndexByte(f.s[f.i+1:], q)
+		if end < 0 {
+			return nil, fmt.Errorf("line %d: unterminated string", f.line)
+		}
+		v := f.s[f.i+1 : f.i+1+end]
+		f.i += end + 2
+		return &node{line: f.line, kind: scalarNode, scalar: v, quoted: true}, nil
+	}
+	start := f.i
+	for f.i < len(f.s) && !strings.ContainsRune(",]}", rune(f.s[f.i])) {
+		if f.s[f.i] == ':' && (f.i+1 == len(f.s) || f.s[f.i+1] == ' ') {
+			break
+		}
+		f.i++
+	}
+	return &node{line: f.line, kind: scalarNode, scalar: strings.TrimSpace(f.s[start:f.i])}, nil
+}
+
+func (f *flowParser) collection(close byte, kind nodeKind) (*node, error) {
+	n := &node{line: f.line, kind: kind}
+	f.i++ // opening bracket
+	for {
+		f.space()
+		if f.i < len(f.s) && f.s[f.i] == close {
+			f.i++
+			return n, nil
+		}
+		if kind == mapNode {
+			k, err := f.value()
+			if err != nil {
+				return nil, err
+			}
+			f.space()
+			if k.kind != scalarNode || f.i == len(f.s) || f.s[f.i] != ':' {
+				return nil, fmt.Errorf("line %d: expected key: value in {...}", f.line)
+			}
+			f.i++
+			if n.get(k.scalar) != nil {
+				return nil, fmt.Errorf("line %d: duplicate key %q", f.line, k.scalar)
+			}
+			n.keys = append(n.keys, k.scalar)
+			n.lines = append(n.lines, f.line)
+		}
+		v, err := f.value()
+		if err != nil {
+			return nil, err
+		}
+		n.values = append(n.values, v)
+		f.space()
+		switch {
+		case f.i < len(f.s) && f.s[f.i] == ',':
+			f.i++
+		case f.i < len(f.s) && f.s[f.i] == close:
+		default:
+			return nil, fmt.Errorf("line %d: unterminated %s", f.line, kind)
+		}
+	}
+}
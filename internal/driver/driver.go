@@ -0,0 +1,445 @@
+// Package driver runs the chanopt analyzer outside of go vet, for the
+// standalone command and subcommands that need findings as data rather
+// than text.
+package driver
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ravisastryk/chanopt/internal/config"
+	"github.com/ravisastryk/chanopt/pkg/analyzer"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/checker"
+	"golang.org/x/tools/go/packages"
+)
+
+// Config controls how packages are loaded.
+type Config struct {
+	Dir     string            // working directory for package patterns; "" means the current one
+	Tests   bool              // also analyze test files
+	Overlay map[string][]byte // contents of unsaved files, keyed by absolute path
+
+	// IncludeVendor also reports findings in vendored packages and in
+	// packages from modules other than the main one, which are normally
+	// still analyzed for facts but not reported on.
+	IncludeVendor bool
+
+	MinConfidence float64  // drop findings with a lower confidence
+	Disable       []string // patterns and checks not to report, by name or rule ID
+
+	// Pipeline also reports pipeline stages with no concurrency benefit,
+	// as the analyzer's pipeline flag does, without setting the flag.
+	Pipeline bool
+
+	// Dirs, if set, gives the configuration in effect in the directory of
+	// each finding, and replaces MinConfidence, Disable and Pipeline.
+	// Pipeline stages are analyzed for everywhere and kept only where its
+	// pipeline setting is on.
+	Dirs *config.Resolver
+
+	// Stats, if set, accumulates the per-package finding counts of the
+	// analyzed packages.
+	Stats *Rollup
+}
+
+// Finding is one diagnostic reported by the analyzer.
+type Finding struct {
+	Posn      token.Position
+	Pattern   string // diagnostic category, e.g. "IDGenerator"
+	Rule      string // rule ID of the category, e.g. "CHOPT001"
+	URL       string // documentation of the rule
+	Message   string // without the rule ID
+	Related   []Related
+	Fixes     []Fix
+	Effort    *analyzer.Effort // nil when the analyzer gives no estimate
+	Callers   int              // call sites of the flagged producer across the analyzed packages
+	CopyBytes int64            // size of a large channel element copied on every send, or 0
+	Buffer    *int64           // buffer size of a flagged producer's channel, -1 if not a constant; nil for other findings
+
+	// TestOnly is set when the flagged producer is declared in a _test.go
+	// file, or every call of it is in one, such as a benchmark helper.
+	// Such findings have no Impact on production code.
+	TestOnly bool
+
+	producer string // FullName of the flagged producer function, if any
+	pkgPath  string // the package of the finding
+	function string // the enclosing function or method, as in "(*T).Next", or ""
+}
+
+// Related is a secondary position attached to a finding.
+type Related struct {
+	Posn    token.Position
+	Message string
+}
+
+// Fix is a suggested fix resolved to byte offsets.
+type Fix struct {
+	Message string
+	Edits   []Edit
+}
+
+// Edit replaces Filename[Start:End] with NewText.
+type Edit struct {
+	Filename   string
+	Start, End int
+	NewText    []byte
+}
+
+// Run loads the packages matching patterns and analyzes them. Findings are
+// sorted by position and deduplicated across test variants.
+func Run(cfg *Config, patterns ...string) ([]Finding, error) {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	pkgs, err := load(cfg, patterns...)
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.IncludeVendor {
+		pkgs = slices.DeleteFunc(pkgs, isThirdParty)
+	}
+
+	graph, err := checker.Analyze([]*analysis.Analyzer{cfg.analyzer()}, pkgs, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	type key struct {
+		posn token.Position
+		msg  string
+	}
+	seen := map[key]bool{}
+	calls := map[string]map[token.Position]bool{} // producer -> call sites
+	var findings []Finding
+	var dirs []string // of the analyzed packages' files
+	for _, act := range graph.Roots {
+		if act.Err != nil {
+			return nil, fmt.Errorf("%s: %w", act.Package.PkgPath, act.Err)
+		}
+		for _, name := range act.Package.GoFiles {
+			dirs = append(dirs, filepath.Dir(name))
+		}
+		if cfg.Stats != nil {
+			stages, err := cfg.keepsStages(act.Package)
+			if err != nil {
+				return nil, err
+			}
+			cfg.Stats.add(act, stages)
+		}
+		fset := act.Package.Fset
+		res, _ := act.Result.(*analyzer.Result)
+		if res != nil {
+			for name, posns := range res.Calls {
+				if calls[name] == nil {
+					calls[name] = map[token.Position]bool{}
+				}
+				for _, pos := range posns {
+					calls[name][fset.Position(pos)] = true
+				}
+			}
+		}
+		for _, d := range act.Diagnostics {
+			f := newFinding(fset, d)
+			f.pkgPath, f.function = act.Package.PkgPath, enclosingFunc(act.Package.Syntax, d.Pos)
+			if res != nil {
+				if e, ok := res.Efforts[d.Pos]; ok {
+					f.Effort = &e
+				}
+				f.producer = res.Producers[d.Pos]
+				f.CopyBytes = res.CopyBytes[d.Pos]
+				if n, ok := res.Buffers[d.Pos]; ok {
+					f.Buffer = &n
+				}
+			}
+			if k := (key{f.Posn, f.Message}); seen[k] {
+				continue
+			} else {
+				seen[k] = true
+			}
+			findings = append(findings, f)
+		}
+	}
+	// Facts only flow from a package to its importers, so call sites in
+	// other packages are known only once every package has been analyzed.
+	for i := range findings {
+		f := &findings[i]
+		if f.producer == "" {
+			continue
+		}
+		f.Callers = len(calls[f.producer])
+		if f.Effort != nil {
+			f.Effort.CallSites = f.Callers
+		}
+		f.TestOnly = isTestFile(f.Posn.Filename) || f.Callers > 0 && onlyTests(calls[f.producer])
+		switch {
+		case f.TestOnly && f.Callers > 0:
+			f.Message += fmt.Sprintf("; called from %d %s, only in tests", f.Callers, plural(f.Callers, "location"))
+		case f.TestOnly:
+			f.Message += "; test-only"
+		case f.Callers > 0:
+			f.Message += fmt.Sprintf("; called from %d %s", f.Callers, plural(f.Callers, "location"))
+		}
+		if f.TestOnly && cfg.Stats != nil {
+			cfg.Stats.TestOnly[f.Pattern]++
+		}
+	}
+	findings, err = cfg.filter(findings, dirs)
+	if err != nil {
+		return nil, err
+	}
+	sortFindings(findings)
+	return findings, nil
+}
+
+// filter drops the findings cfg asks not to report. The configuration of
+// each of dirs, the directories analyzed, is resolved whether or not
+// anything was found there, so that Dirs.Hash does not vary with the
+// findings.
+func (cfg *Config) filter(findings []Finding, dirs []string) ([]Finding, error) {
+	if cfg.Dirs == nil {
+		return slices.DeleteFunc(findings, func(f Finding) bool {
+			return f.Confidence() < cfg.MinConfidence || f.disabled(cfg.Disable)
+		}), nil
+	}
+	slices.Sort(dirs)
+	for _, dir := range slices.Compact(dirs) {
+		if _, err := cfg.Dirs.For(dir); err != nil {
+			return nil, err
+		}
+	}
+	var kept []Finding
+	for _, f := range findings {
+		c, err := cfg.Dirs.For(filepath.Dir(f.Posn.Filename))
+		if err != nil {
+			return nil, err
+		}
+		if f.Confidence() < c.MinConfidence || f.disabled(c.Disable) || f.ignored(c.Ignore) ||
+			f.Pattern == "PipelineStage" && !c.Pipeline {
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return kept, nil
+}
+
+func isTestFile(name string) bool {
+	return strings.HasSuffix(name, "_test.go")
+}
+
+// onlyTests reports whether every call site is in a _test.go file.
+func onlyTests(sites map[token.Position]bool) bool {
+	for p := range sites {
+		if !isTestFile(p.Filename) {
+			return false
+		}
+	}
+	return true
+}
+
+// disabled reports whether names lists the finding's category or rule ID.
+func (f *Finding) disabled(names []string) bool {
+	return slices.ContainsFunc(names, func(name string) bool {
+		return name == f.Pattern || name != "" && name == f.Rule
+	})
+}
+
+// ignored reports whether an ignore entry matches the finding's function.
+func (f *Finding) ignored(ignore []config.Ignore) bool {
+	for i := range ignore {
+		if ignore[i].Matches(f.pkgPath, f.function, f.Pattern, f.Rule) {
+			return true
+		}
+	}
+	return false
+}
+
+func plural(n int, s string) string {
+	if n == 1 {
+		return s
+	}
+	return s + "s"
+}
+
+// newFinding resolves the positions of d against fset.
+func newFinding(fset *token.FileSet, d analysis.Diagnostic) Finding {
+	msg, rule := analyzer.CutRule(d.Message)
+	f := Finding{
+		Posn:    fset.Position(d.Pos),
+		Pattern: d.Category,
+		Rule:    rule,
+		URL:     d.URL,
+		Message: msg,
+	}
+	for _, r := range d.Related {
+		f.Related = append(f.Related, Related{fset.Position(r.Pos), r.Message})
+	}
+	for _, sf := range d.SuggestedFixes {
+		fix := Fix{Message: sf.Message}
+		for _, e := range sf.TextEdits {
+			start, end := fset.Position(e.Pos), fset.Position(e.End)
+			fix.Edits = append(fix.Edits, Edit{start.Filename, start.Offset, end.Offset, e.NewText})
+		}
+		f.Fixes = append(f.Fixes, fix)
+	}
+	return f
+}
+
+// enclosingFunc returns the name of the function declaration around pos
+// in files, relative to their package, as in "NewIDs", "(*Store).Next" or
+// "Store.Len", or "" outside of one.
+func enclosingFunc(files []*ast.File, pos token.Pos) string {
+	for _, file := range files {
+		if pos < file.FileStart || pos >= file.FileEnd {
+			continue
+		}
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || pos < fn.Pos() || pos >= fn.End() {
+				continue
+			}
+			if fn.Recv == nil || len(fn.Recv.List) == 0 {
+				return fn.Name.Name
+			}
+			recv, ptr := ast.Unparen(fn.Recv.List[0].Type), false
+			if star, ok := recv.(*ast.StarExpr); ok {
+				recv, ptr = ast.Unparen(star.X), true
+			}
+			switch x := recv.(type) {
+			case *ast.IndexExpr: // a generic type's receiver, T[K]
+				recv = x.X
+			case *ast.IndexListExpr:
+				recv = x.X
+			}
+			if ptr {
+				return "(*" + types.ExprString(recv) + ")." + fn.Name.Name
+			}
+			return types.ExprString(recv) + "." + fn.Name.Name
+		}
+	}
+	return ""
+}
+
+// sortFindings orders findings by position, then pattern and message, so
+// that output is the same however packages were scheduled.
+func sortFindings(findings []Finding) {
+	sort.Slice(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Posn != b.Posn {
+			return posLess(a.Posn, b.Posn)
+		}
+		if a.Pattern != b.Pattern {
+			return a.Pattern < b.Pattern
+		}
+		return a.Message < b.Message
+	})
+}
+
+// analyzer returns the analyzer to run for cfg: analyzer.Analyzer,
+// reporting pipeline stages if cfg asks for them or leaves them to Dirs,
+// and with Pass.ReadFile serving overlay contents, which checker does not
+// do itself; without it, suggested fixes for files with unsaved changes
+// would be computed against the saved text.
+func (cfg *Config) analyzer() *analysis.Analyzer {
+	base := analyzer.Analyzer
+	if cfg.Pipeline || cfg.Dirs != nil {
+		base = analyzer.With(analyzer.Options{PipelineStages: true})
+	}
+	if len(cfg.Overlay) == 0 {
+		return base
+	}
+	a := *base
+	a.Run = func(pass *analysis.Pass) (any, error) {
+		readFile := pass.ReadFile
+		pass.ReadFile = func(name string) ([]byte, error) {
+			if src, ok := cfg.Overlay[name]; ok {
+				return src, nil
+			}
+			return readFile(name)
+		}
+		return base.Run(pass)
+	}
+	return &a
+}
+
+// keepsStages reports whether the PipelineStage findings of pkg are kept:
+// where Dirs decides, if its directory's pipeline setting is on.
+func (cfg *Config) keepsStages(pkg *packages.Package) (bool, error) {
+	if cfg.Dirs == nil || len(pkg.GoFiles) == 0 {
+		return true, nil
+	}
+	c, err := cfg.Dirs.For(filepath.Dir(pkg.GoFiles[0]))
+	if err != nil {
+		return false, err
+	}
+	return c.Pipeline, nil
+}
+
+// isThirdParty reports whether pkg is code the user cannot change: a
+// package under a vendor directory or from a module other than the main
+// one, whether it was named by import path or matched by a pattern.
+// Packages loaded without module information, such as ad hoc files, are
+// kept.
+func isThirdParty(pkg *packages.Package) bool {
+	if pkg.Module != nil && !pkg.Module.Main {
+		return true
+	}
+	for _, name := range pkg.CompiledGoFiles {
+		if slices.Contains(strings.Split(filepath.ToSlash(name), "/"), "vendor") {
+			return true
+		}
+	}
+	return false
+}
+
+// load runs packages.Load and turns package errors into an error.
+func load(cfg *Config, patterns ...string) ([]*packages.Package, error) {
+	env, patterns, err := loadEnv(cfg.Dir, patterns)
+	if err != nil {
+		return nil, err
+	}
+	pcfg := &packages.Config{
+		Mode:    packages.LoadAllSyntax | packages.NeedModule,
+		Dir:     cfg.Dir,
+		Env:     env,
+		Tests:   cfg.Tests,
+		Overlay: cfg.Overlay,
+	}
+	pkgs, err := packages.Load(pcfg, patterns...)
+	if err != nil {
+		return nil, err
+	}
+	if err := packageErrors(pkgs); err != nil {
+		return nil, err
+	}
+	return pkgs, nil
+}
+
+// packageErrors joins the load, parse and type errors of pkgs and their
+// dependencies.
+func packageErrors(pkgs []*packages.Package) error {
+	var errs []error
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		for _, e := range pkg.Errors {
+			errs = append(errs, e)
+		}
+	})
+	return errors.Join(errs...)
+}
+
+func posLess(a, b token.Position) bool {
+	if a.Filename != b.Filename {
+		return a.Filename < b.Filename
+	}
+	if a.Line != b.Line {
+		return a.Line < b.Line
+	}
+	return a.Column < b.Column
+}
@@ -0,0 +1,435 @@
+package driver
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ravisastryk/chanopt/internal/config"
+	"github.com/ravisastryk/chanopt/pkg/analyzer"
+)
+
+func TestVerifyFixRejectsBrokenCaller(t *testing.T) {
+	cfg := &Config{Dir: "testdata/brokenfix"}
+	findings, err := Run(cfg, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 1 || len(findings[0].Fixes) != 2 {
+		t.Fatalf("want one finding with two alternative fixes, got %+v", findings)
+	}
+	want := analyzer.Effort{Lines: 11, CallSites: 1, SignatureChange: true, Autofix: true}
+	if e := findings[0].Effort; e == nil || *e != want {
+		t.Errorf("Effort = %+v, want %+v", e, want)
+	}
+	err = VerifyFix(cfg, findings[0].Fixes[0])
+	if err == nil || !strings.Contains(err.Error(), "receive from non-chan") {
+		t.Fatalf("VerifyFix = %v, want a receive type error", err)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old := []byte("a\nb\nc\nd\ne\nf\ng\nh\n")
+	new := []byte("a\nb\nc\nd\nE\nf\ng\nh\n")
+	want := "--- x.go\n+++ x.go\n@@ -2,7 +2,7 @@\n b\n c\n d\n-e\n+E\n f\n g\n h\n"
+	if got := Diff("x.go", old, new); got != want {
+		t.Errorf("Diff =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestOverlay(t *testing.T) {
+	findings, err := Run(&Config{Dir: "testdata/overlay"}, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 0 {
+		t.Fatalf("saved file: want no findings, got %+v", findings)
+	}
+
+	overlay, err := LoadOverlay("testdata/overlay/overlay.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	findings, err = Run(&Config{Dir: "testdata/overlay", Overlay: overlay}, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 1 || findings[0].Pattern != "IDGenerator" {
+		t.Fatalf("unsaved buffer: want one IDGenerator finding, got %+v", findings)
+	}
+	if len(findings[0].Fixes) == 0 {
+		t.Fatalf("unsaved buffer: want a fix computed against the overlay, got none")
+	}
+}
+
+func TestRunFileOutsideModule(t *testing.T) {
+	src, err := os.ReadFile("testdata/brokenfix/ids.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(t.TempDir(), "ids.go")
+	cfg := &Config{Overlay: map[string][]byte{name: src}}
+	findings, err := RunFile(cfg, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 1 || findings[0].Posn.Filename != name || len(findings[0].Fixes) == 0 {
+		t.Fatalf("want one IDGenerator finding with a fix in %s, got %+v", name, findings)
+	}
+}
+
+// TestRunFileUnresolvedImport runs a file whose package cannot be loaded,
+// for an import that does not resolve, which is analyzed on its own.
+func TestRunFileUnresolvedImport(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/m\n\ngo 1.23\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	src, err := os.ReadFile("testdata/brokenfix/ids.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src = bytes.Replace(src, []byte("\nfunc "), []byte("\nimport _ \"example.com/missing\"\n\nfunc "), 1)
+	name := filepath.Join(dir, "ids.go")
+	findings, err := RunFile(&Config{Dir: dir, Overlay: map[string][]byte{name: src}}, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 1 || findings[0].Pattern != "IDGenerator" {
+		t.Fatalf("want one IDGenerator finding in %s, got %+v", name, findings)
+	}
+}
+
+// TestRunFileUnresolvedImportChecks is TestRunFileUnresolvedImport for
+// the checks whose analyzers Analyzer requires, which the fallback must
+// run as well.
+func TestRunFileUnresolvedImportChecks(t *testing.T) {
+	for _, tc := range []struct{ file, rule string }{
+		{"../../pkg/analyzer/testdata/src/ctxstop/ctxstop.go", "CHOPT107"},
+		{"../../pkg/analyzer/testdata/src/sendclose/sendclose.go", "CHOPT108"},
+	} {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/m\n\ngo 1.23\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		src, err := os.ReadFile(tc.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		src = bytes.Replace(src, []byte("\nimport "), []byte("\nimport _ \"example.com/missing\"\n\nimport "), 1)
+		name := filepath.Join(dir, filepath.Base(tc.file))
+		findings, err := RunFile(&Config{Dir: dir, Overlay: map[string][]byte{name: src}}, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.ContainsFunc(findings, func(f Finding) bool { return f.Rule == tc.rule }) {
+			t.Errorf("%s: no %s finding in %+v", tc.file, tc.rule, findings)
+		}
+	}
+}
+
+// TestRunFileUnresolvedImportIgnore checks that ignore entries by function
+// name apply to the findings of the fallback too.
+func TestRunFileUnresolvedImportIgnore(t *testing.T) {
+	dir := t.TempDir()
+	for name, data := range map[string]string{
+		"go.mod":        "module example.com/m\n\ngo 1.23\n",
+		config.FileName: "root: true\nignore:\n  - {func: \".*Mock.*\", pattern: IDGenerator}\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	src, err := os.ReadFile("testdata/ignore/store.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	src = bytes.Replace(src, []byte("\n// Store"), []byte("\nimport _ \"example.com/missing\"\n\n// Store"), 1)
+	name := filepath.Join(dir, "store.go")
+	findings, err := RunFile(&Config{Dir: dir, Overlay: map[string][]byte{name: src}, Dirs: &config.Resolver{}}, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 1 || findings[0].function != "(*Store).IDs" {
+		t.Errorf("want only the finding in (*Store).IDs, got %+v", findings)
+	}
+}
+
+func TestTop(t *testing.T) {
+	findings, err := Run(&Config{Dir: "../../demos"}, "./antipatterns")
+	if err != nil {
+		t.Fatal(err)
+	}
+	top := Top(findings, 2)
+	if len(top) != 2 {
+		t.Fatalf("Top(_, 2) returned %d findings", len(top))
+	}
+	for _, f := range findings {
+		if f.Impact() > top[1].Impact() && f.Posn != top[0].Posn && f.Posn != top[1].Posn {
+			t.Errorf("%s (%s, impact %.1f) outranks the top two", f.Posn, f.Pattern, f.Impact())
+		}
+	}
+	if top[0].Impact() < top[1].Impact() {
+		t.Errorf("Top not in descending order: %.1f < %.1f", top[0].Impact(), top[1].Impact())
+	}
+}
+
+func TestSortBy(t *testing.T) {
+	findings, err := Run(&Config{Dir: "../../demos"}, "./antipatterns")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SortBy(findings, "impact"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < len(findings); i++ {
+		if findings[i].Impact() > findings[i-1].Impact() {
+			t.Errorf("impact order: %s (%.1f) after %s (%.1f)", findings[i].Posn, findings[i].Impact(), findings[i-1].Posn, findings[i-1].Impact())
+		}
+	}
+	if err := SortBy(findings, "pattern"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < len(findings); i++ {
+		if findings[i].Pattern < findings[i-1].Pattern {
+			t.Errorf("pattern order: %s after %s", findings[i].Pattern, findings[i-1].Pattern)
+		}
+	}
+	if err := SortBy(findings, "position"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < len(findings); i++ {
+		if posLess(findings[i].Posn, findings[i-1].Posn) {
+			t.Errorf("position order: %s after %s", findings[i].Posn, findings[i-1].Posn)
+		}
+	}
+	if err := SortBy(findings, "severity"); err == nil {
+		t.Error("SortBy accepted an unknown order")
+	}
+}
+
+func TestCallersAcrossPackages(t *testing.T) {
+	findings, err := Run(&Config{Dir: "testdata/callers"}, "./gen", "./use")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 1 {
+		t.Fatalf("want one finding, got %+v", findings)
+	}
+	f := findings[0]
+	if f.Callers != 3 || f.Effort.CallSites != 3 {
+		t.Errorf("Callers = %d, Effort.CallSites = %d, want 3", f.Callers, f.Effort.CallSites)
+	}
+	if !strings.HasSuffix(f.Message, "; called from 3 locations") {
+		t.Errorf("message lacks caller count: %s", f.Message)
+	}
+	if f.Rule != "CHOPT001" || !strings.HasSuffix(f.URL, "#chopt001") {
+		t.Errorf("Rule = %q, URL = %q", f.Rule, f.URL)
+	}
+
+	findings, err = Run(&Config{Dir: "testdata/callers", Disable: []string{"CHOPT001"}}, "./gen", "./use")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 0 {
+		t.Errorf("disabled by rule ID, got %+v", findings)
+	}
+}
+
+func TestStats(t *testing.T) {
+	cfg := &Config{Dir: "testdata/callers", Stats: &Rollup{}, Disable: []string{"IDGenerator"}}
+	if _, err := Run(cfg, "./gen", "./use"); err != nil {
+		t.Fatal(err)
+	}
+	r := cfg.Stats
+	if r.Packages != 2 || r.Findings != 1 || r.ByPattern["IDGenerator"] != 1 || r.ByRule["CHOPT001"] != 1 {
+		t.Errorf("rollup = %+v, want one IDGenerator finding in two packages", r)
+	}
+	if len(r.ByPackage) != 1 || r.ByPackage["github.com/ravisastryk/chanopt/internal/driver/testdata/callers/gen"]["IDGenerator"] != 1 {
+		t.Errorf("ByPackage = %v", r.ByPackage)
+	}
+}
+
+// TestStatsPipeline checks that the rollup counts PipelineStage findings
+// only where the configuration keeps them.
+func TestStatsPipeline(t *testing.T) {
+	dir := t.TempDir()
+	src, err := os.ReadFile("../../pkg/analyzer/testdata/src/pipeline/pipeline.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range map[string][]byte{"go.mod": []byte("module example.com/m\n\ngo 1.23\n"), "pipeline.go": src} {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, tt := range []struct {
+		yaml string
+		want int
+	}{
+		{"root: true\n", 0},
+		{"root: true\npipeline: true\n", 3},
+	} {
+		if err := os.WriteFile(filepath.Join(dir, config.FileName), []byte(tt.yaml), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		cfg := &Config{Dir: dir, Stats: &Rollup{}, Dirs: &config.Resolver{}}
+		findings, err := Run(cfg, ".")
+		if err != nil {
+			t.Fatal(err)
+		}
+		stages := 0
+		for _, f := range findings {
+			if f.Pattern == "PipelineStage" {
+				stages++
+			}
+		}
+		if got := cfg.Stats.ByPattern["PipelineStage"]; got != tt.want || stages != tt.want {
+			t.Errorf("%q: %d PipelineStage findings, %d in the rollup; want %d", tt.yaml, stages, got, tt.want)
+		}
+	}
+}
+
+func TestTestOnly(t *testing.T) {
+	cfg := &Config{Dir: "testdata/testonly", Tests: true, Stats: &Rollup{}}
+	findings, err := Run(cfg, "./ids")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 2 {
+		t.Fatalf("want two findings, got %+v", findings)
+	}
+	next, seq := findings[0], findings[1]
+	if next.TestOnly || next.Impact() == 0 {
+		t.Errorf("Next, called from production code: TestOnly = %t, Impact = %g", next.TestOnly, next.Impact())
+	}
+	if !seq.TestOnly || seq.Impact() != 0 || !strings.HasSuffix(seq.Message, "; called from 1 location, only in tests") {
+		t.Errorf("Seq, called from a benchmark: TestOnly = %t, Impact = %g, message %q", seq.TestOnly, seq.Impact(), seq.Message)
+	}
+	if cfg.Stats.Findings != 2 || cfg.Stats.TestOnly["IDGenerator"] != 1 {
+		t.Errorf("rollup = %+v, want one of two findings test-only", cfg.Stats)
+	}
+}
+
+func TestIgnoreByFunc(t *testing.T) {
+	findings, err := Run(&Config{Dir: "testdata/ignore", Dirs: &config.Resolver{}}, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 1 || findings[0].function != "(*Store).IDs" {
+		t.Errorf("want only the finding in (*Store).IDs, got %+v", findings)
+	}
+}
+
+// TestConfigHashWithoutFindings checks that the configuration hash covers
+// the files of packages without findings, so that it does not change with
+// what the code's findings are.
+func TestConfigHashWithoutFindings(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/m\n\ngo 1.23\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "quiet.go"), []byte("package m\n\nfunc Quiet() int { return 1 }\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	hash := func(yaml string) string {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, config.FileName), []byte(yaml), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		dirs := &config.Resolver{}
+		findings, err := Run(&Config{Dir: dir, Dirs: dirs}, ".")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(findings) != 0 {
+			t.Fatalf("want no findings, got %+v", findings)
+		}
+		return dirs.Hash()
+	}
+	if a, b := hash("root: true\ndisable: [Singleton]\n"), hash("root: true\ndisable: [LazyInit]\n"); a == b {
+		t.Errorf("Hash is %s for different configuration files", a)
+	}
+}
+
+func TestPlanFixes(t *testing.T) {
+	findings, err := Run(&Config{Dir: "testdata/callers"}, "./gen", "./use")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := FixPlan{Pattern: "IDGenerator", Rule: "CHOPT001", Fixes: 1, Files: 1, Signatures: 1, CallSites: 3}
+	plans := PlanFixes(findings, nil)
+	if len(plans) != 2 || plans[0] != want || plans[1].Pattern != "" || plans[1].CallSites != 3 {
+		t.Errorf("PlanFixes = %+v, want %+v and a total", plans, want)
+	}
+
+	plans = PlanFixes(findings, []SkippedFix{{Finding: findings[0], Reason: "overlaps another fix"}})
+	if len(plans) != 2 || plans[0].Fixes != 0 || plans[0].Files != 0 || plans[0].Skipped != 1 {
+		t.Errorf("with the fix skipped, PlanFixes = %+v", plans)
+	}
+}
+
+func TestVendorSkipped(t *testing.T) {
+	t.Setenv("GOFLAGS", "-mod=vendor")
+	cfg := &Config{Dir: "testdata/vendored"}
+	findings, err := Run(cfg, "./...", "example.com/dep")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 1 || filepath.Base(findings[0].Posn.Filename) != "app.go" {
+		t.Fatalf("want one finding in app.go, got %+v", findings)
+	}
+
+	cfg.IncludeVendor = true
+	findings, err = Run(cfg, "./...", "example.com/dep")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 2 {
+		t.Fatalf("with IncludeVendor, want findings in app.go and dep.go, got %+v", findings)
+	}
+}
+
+func TestWorkspace(t *testing.T) {
+	findings, err := Run(&Config{Dir: "testdata/workspace"}, "./...")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var files []string
+	for _, f := range findings {
+		files = append(files, filepath.Base(f.Posn.Filename))
+	}
+	if !slices.Equal(files, []string{"a.go", "b.go"}) {
+		t.Fatalf("want a finding in each workspace module, got %+v", findings)
+	}
+	if findings[0].Callers != 1 {
+		t.Errorf("a.IDs: Callers = %d, want 1 from module b", findings[0].Callers)
+	}
+}
+
+func TestImpactCountsElementCopy(t *testing.T) {
+	f := Finding{Pattern: "BoundedIterator", Message: "chanopt: BoundedIterator pattern (92% confidence)"}
+	small := f.Impact()
+	f.CopyBytes = 1024
+	if got, want := f.Impact()-small, analyzer.CopyNs(1024)*0.92; math.Abs(got-want) > 1e-9 {
+		t.Errorf("copy adds %.2f ns of impact, want %.2f", got, want)
+	}
+}
+
+func TestImpactUnbuffered(t *testing.T) {
+	f := Finding{Pattern: "IDGenerator", Message: "chanopt: IDGenerator pattern (95% confidence)"}
+	unknown := f.Impact()
+	var zero, buffered int64 = 0, 64
+	f.Buffer = &buffered
+	if got := f.Impact(); got != unknown {
+		t.Errorf("buffered impact %.1f, want %.1f as without a buffer size", got, unknown)
+	}
+	f.Buffer = &zero
+	if got := f.Impact(); got <= unknown {
+		t.Errorf("unbuffered impact %.1f, want more than buffered %.1f", got, unknown)
+	}
+}
@@ -0,0 +1,156 @@
+package driver
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/ravisastryk/chanopt/pkg/analyzer"
+	"golang.org/x/tools/go/analysis"
+)
+
+// RunFile analyzes the single file filename, whose contents may come from
+// cfg.Overlay. When the file belongs to a loadable package the analysis has
+// full type information; otherwise the file is type-checked on its own,
+// with imports resolved from source and type errors ignored, and the
+// findings are best effort.
+func RunFile(cfg *Config, filename string) ([]Finding, error) {
+	if !filepath.IsAbs(filename) {
+		filename = filepath.Join(cfg.Dir, filename)
+	}
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return nil, err
+	}
+	src, err := cfg.ReadFile(abs)
+	if err != nil {
+		return nil, err
+	}
+
+	c := *cfg
+	c.Tests = cfg.Tests || strings.HasSuffix(abs, "_test.go")
+	if findings, err := Run(&c, "file="+abs); err == nil {
+		var own []Finding
+		for _, f := range findings {
+			if f.Posn.Filename == abs {
+				own = append(own, f)
+			}
+		}
+		return own, nil
+	}
+	findings, err := runIsolated(cfg.analyzer(), abs, src)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.filter(findings, []string{filepath.Dir(abs)})
+}
+
+// runIsolated type-checks src as a package of one file and runs a, a copy
+// of the analyzer, on it directly.
+func runIsolated(a *analysis.Analyzer, filename string, src []byte) (findings []Finding, err error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filename, src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	files := []*ast.File{file}
+	info := &types.Info{
+		Types:        map[ast.Expr]types.TypeAndValue{},
+		Defs:         map[*ast.Ident]types.Object{},
+		Uses:         map[*ast.Ident]types.Object{},
+		Implicits:    map[ast.Node]types.Object{},
+		Scopes:       map[ast.Node]*types.Scope{},
+		Selections:   map[*ast.SelectorExpr]*types.Selection{},
+		FileVersions: map[*ast.File]string{},
+	}
+	tc := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+		Error:    func(error) {}, // best effort: keep whatever checked
+	}
+	pkg, _ := tc.Check(file.Name.Name, fset, files, info)
+
+	var diags []analysis.Diagnostic
+	pass := &analysis.Pass{
+		Analyzer:   a,
+		Fset:       fset,
+		Files:      files,
+		Pkg:        pkg,
+		TypesInfo:  info,
+		TypesSizes: types.SizesFor("gc", runtime.GOARCH),
+		ResultOf:   map[*analysis.Analyzer]any{},
+		Report:     func(d analysis.Diagnostic) { diags = append(diags, d) },
+		ReadFile: func(name string) ([]byte, error) {
+			if name == filename {
+				return src, nil
+			}
+			return nil, fs.ErrNotExist
+		},
+		// A lone file has no analyzed dependencies to share facts with.
+		ImportObjectFact:  func(types.Object, analysis.Fact) bool { return false },
+		ExportObjectFact:  func(types.Object, analysis.Fact) {},
+		ImportPackageFact: func(*types.Package, analysis.Fact) bool { return false },
+		ExportPackageFact: func(analysis.Fact) {},
+	}
+	// Incomplete type information can reach code paths the analyzer never
+	// sees under go vet; report that as an error rather than crash.
+	defer func() {
+		if r := recover(); r != nil {
+			findings, err = nil, fmt.Errorf("%s: analysis without full type information failed: %v", filename, r)
+		}
+	}()
+	for _, req := range a.Requires {
+		if err := runRequired(pass, req); err != nil {
+			return nil, err
+		}
+	}
+	v, err := a.Run(pass)
+	if err != nil {
+		return nil, err
+	}
+	res := v.(*analyzer.Result)
+	for _, d := range diags {
+		f := newFinding(fset, d)
+		f.pkgPath, f.function = pkg.Path(), enclosingFunc(files, d.Pos)
+		if e, ok := res.Efforts[d.Pos]; ok {
+			f.Effort = &e
+		}
+		f.CopyBytes = res.CopyBytes[d.Pos]
+		if n, ok := res.Buffers[d.Pos]; ok {
+			f.Buffer = &n
+		}
+		findings = append(findings, f)
+	}
+	sortFindings(findings)
+	return findings, nil
+}
+
+// runRequired runs a, after the analyzers it requires, on the package of
+// pass, unless it has run already, and records its result in
+// pass.ResultOf. Its diagnostics are dropped: as under the checker, the
+// analyzer that requires it reports what it finds.
+func runRequired(pass *analysis.Pass, a *analysis.Analyzer) error {
+	if _, ok := pass.ResultOf[a]; ok {
+		return nil
+	}
+	for _, req := range a.Requires {
+		if err := runRequired(pass, req); err != nil {
+			return err
+		}
+	}
+	p := *pass
+	p.Analyzer = a
+	p.Report = func(analysis.Diagnostic) {}
+	v, err := a.Run(&p)
+	if err != nil {
+		return fmt.Errorf("%s: %w", a.Name, err)
+	}
+	pass.ResultOf[a] = v
+	return nil
+}
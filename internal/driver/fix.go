@@ -0,0 +1,182 @@
+package driver
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// ApplyFixes computes the new contents of every file touched by the first
+// fix of each finding. A fix overlapping one already accepted for the same
+// file is left out and its finding returned in skipped.
+func ApplyFixes(cfg *Config, findings []Finding) (files map[string][]byte, skipped []Finding, err error) {
+	accepted := map[string][]Edit{}
+	for _, f := range findings {
+		if len(f.Fixes) == 0 {
+			continue
+		}
+		fix := f.Fixes[0]
+		if conflicts(accepted, fix.Edits) {
+			skipped = append(skipped, f)
+			continue
+		}
+		for _, e := range fix.Edits {
+			accepted[e.Filename] = append(accepted[e.Filename], e)
+		}
+	}
+	files = map[string][]byte{}
+	for name, edits := range accepted {
+		src, err := cfg.ReadFile(name)
+		if err != nil {
+			return nil, nil, err
+		}
+		if files[name], err = applyEdits(src, edits); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return files, skipped, nil
+}
+
+// VerifyFix type-checks the packages containing the files fix edits, with
+// the fix applied through a go/packages overlay. A non-nil error means the
+// fix does not compile; callers elsewhere in the module are not checked.
+func VerifyFix(cfg *Config, fix Fix) error {
+	byFile := map[string][]Edit{}
+	for _, e := range fix.Edits {
+		byFile[e.Filename] = append(byFile[e.Filename], e)
+	}
+	overlay := maps.Clone(cfg.Overlay)
+	if overlay == nil {
+		overlay = map[string][]byte{}
+	}
+	var patterns []string
+	for name, edits := range byFile {
+		src, err := cfg.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		if overlay[name], err = applyEdits(src, edits); err != nil {
+			return err
+		}
+		patterns = append(patterns, "file="+name)
+	}
+	env, patterns, err := loadEnv(cfg.Dir, patterns)
+	if err != nil {
+		return err
+	}
+	pcfg := &packages.Config{
+		Mode:    packages.LoadSyntax,
+		Dir:     cfg.Dir,
+		Env:     env,
+		Tests:   cfg.Tests,
+		Overlay: overlay,
+	}
+	pkgs, err := packages.Load(pcfg, patterns...)
+	if err != nil {
+		return err
+	}
+	// go list reports the same compile errors against the overlay's temp
+	// file; prefer the type checker's, which carry the real file name.
+	var listErrs, errs []error
+	for _, pkg := range pkgs {
+		for _, e := range pkg.Errors {
+			if e.Kind == packages.ListError {
+				listErrs = append(listErrs, e)
+			} else {
+				errs = append(errs, e)
+			}
+		}
+	}
+	if len(errs) == 0 {
+		errs = listErrs
+	}
+	return errors.Join(errs...)
+}
+
+// conflicts reports whether any of edits overlaps an accepted edit.
+func conflicts(accepted map[string][]Edit, edits []Edit) bool {
+	for _, e := range edits {
+		for _, a := range accepted[e.Filename] {
+			if e.Start < a.End && a.Start < e.End || e.Start == a.Start {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// applyEdits applies non-overlapping edits to src.
+func applyEdits(src []byte, edits []Edit) ([]byte, error) {
+	edits = append([]Edit(nil), edits...)
+	sort.Slice(edits, func(i, j int) bool { return edits[i].Start < edits[j].Start })
+	var out bytes.Buffer
+	last := 0
+	for _, e := range edits {
+		if e.Start < last || e.End > len(src) || e.Start > e.End {
+			return nil, fmt.Errorf("invalid or overlapping edit at offset %d", e.Start)
+		}
+		out.Write(src[last:e.Start])
+		out.Write(e.NewText)
+		last = e.End
+	}
+	out.Write(src[last:])
+	return out.Bytes(), nil
+}
+
+// Diff returns a unified diff between old and new contents of name, as a
+// single hunk covering everything between the common prefix and suffix.
+func Diff(name string, old, new []byte) string {
+	a, b := lines(old), lines(new)
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	if pre == len(a) && pre == len(b) {
+		return ""
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	const context = 3
+	start := max(pre-context, 0)
+	aEnd := min(len(a)-suf+context, len(a))
+	bEnd := min(len(b)-suf+context, len(b))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", name, name)
+	fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", start+1, aEnd-start, start+1, bEnd-start)
+	line := func(prefix, s string) {
+		sb.WriteString(prefix + s)
+		if !strings.HasSuffix(s, "\n") {
+			sb.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+	for _, s := range a[start:pre] {
+		line(" ", s)
+	}
+	for _, s := range a[pre : len(a)-suf] {
+		line("-", s)
+	}
+	for _, s := range b[pre : len(b)-suf] {
+		line("+", s)
+	}
+	for _, s := range a[len(a)-suf : aEnd] {
+		line(" ", s)
+	}
+	return sb.String()
+}
+
+// lines splits src after each newline, without a trailing empty element.
+func lines(src []byte) []string {
+	ls := strings.SplitAfter(string(src), "\n")
+	if ls[len(ls)-1] == "" {
+		ls = ls[:len(ls)-1]
+	}
+	return ls
+}
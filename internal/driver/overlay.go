@@ -0,0 +1,51 @@
+package driver
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// LoadOverlay reads an overlay file in the format accepted by go build
+// -overlay and gopls:
+//
+//	{"Replace": {"/abs/path/file.go": "/tmp/unsaved-buffer.go"}}
+//
+// and returns the replacement contents keyed by absolute file name.
+// Relative paths are resolved against the current directory, as go build
+// does. Deleting a file (an empty replacement path) is not supported.
+func LoadOverlay(path string) (map[string][]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var spec struct {
+		Replace map[string]string
+	}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("parsing overlay %s: %w", path, err)
+	}
+	overlay := make(map[string][]byte, len(spec.Replace))
+	for from, to := range spec.Replace {
+		if to == "" {
+			return nil, fmt.Errorf("overlay %s: deleting %s is not supported", path, from)
+		}
+		abs, err := filepath.Abs(from)
+		if err != nil {
+			return nil, err
+		}
+		if overlay[abs], err = os.ReadFile(to); err != nil {
+			return nil, fmt.Errorf("overlay %s: %w", path, err)
+		}
+	}
+	return overlay, nil
+}
+
+// ReadFile returns the contents of name, from the overlay if it has one.
+func (cfg *Config) ReadFile(name string) ([]byte, error) {
+	if src, ok := cfg.Overlay[name]; ok {
+		return src, nil
+	}
+	return os.ReadFile(name)
+}
@@ -0,0 +1,85 @@
+package driver
+
+import (
+	"sort"
+
+	"github.com/ravisastryk/chanopt/pkg/analyzer"
+)
+
+// FixPlan is what applying the fixes of one pattern's findings would
+// change, for a dry run of -fix.
+type FixPlan struct {
+	Pattern    string `json:"pattern"`
+	Rule       string `json:"rule"`
+	Fixes      int    `json:"fixes"` // fixes applied, one rewritten function each
+	Files      int    `json:"files"`
+	Signatures int    `json:"signatures"` // rewritten functions whose signature changes
+	CallSites  int    `json:"call_sites"` // calls of those functions, which expect a channel
+	Skipped    int    `json:"skipped"`
+}
+
+// SkippedFix is the finding of a fix that -fix leaves out, and why.
+type SkippedFix struct {
+	Finding Finding
+	Reason  string
+}
+
+// PlanFixes groups by pattern the fixes that ApplyFixes would apply to
+// findings, leaving out the findings in skipped. The plans are sorted by
+// rule ID, followed by a total with an empty Pattern.
+func PlanFixes(findings []Finding, skipped []SkippedFix) []FixPlan {
+	left := map[findingKey]bool{}
+	byPattern := map[string]*FixPlan{}
+	plan := func(pattern string) *FixPlan {
+		if byPattern[pattern] == nil {
+			byPattern[pattern] = &FixPlan{Pattern: pattern, Rule: analyzer.RuleID(pattern)}
+		}
+		return byPattern[pattern]
+	}
+	for _, s := range skipped {
+		left[keyOf(s.Finding)] = true
+		plan(s.Finding.Pattern).Skipped++
+	}
+	files := map[string]map[string]bool{} // pattern ("" for all) -> files
+	for _, f := range findings {
+		if len(f.Fixes) == 0 || left[keyOf(f)] {
+			continue
+		}
+		p := plan(f.Pattern)
+		p.Fixes++
+		if f.Effort != nil && f.Effort.SignatureChange {
+			p.Signatures++
+			p.CallSites += f.Callers
+		}
+		for _, pattern := range []string{f.Pattern, ""} {
+			if files[pattern] == nil {
+				files[pattern] = map[string]bool{}
+			}
+			for _, e := range f.Fixes[0].Edits {
+				files[pattern][e.Filename] = true
+			}
+		}
+	}
+	var plans []FixPlan
+	all := FixPlan{Files: len(files[""])}
+	for _, p := range byPattern {
+		p.Files = len(files[p.Pattern])
+		all.Fixes += p.Fixes
+		all.Signatures += p.Signatures
+		all.CallSites += p.CallSites
+		all.Skipped += p.Skipped
+		plans = append(plans, *p)
+	}
+	sort.Slice(plans, func(i, j int) bool { return plans[i].Rule < plans[j].Rule })
+	return append(plans, all)
+}
+
+// findingKey identifies a finding across copies of it.
+type findingKey struct {
+	posn    string
+	pattern string
+}
+
+func keyOf(f Finding) findingKey {
+	return findingKey{f.Posn.String(), f.Pattern}
+}
@@ -0,0 +1,92 @@
+package driver
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ravisastryk/chanopt/pkg/analyzer"
+)
+
+var confidenceRx = regexp.MustCompile(`(\d+)% confidence`)
+
+// Confidence returns the classifier confidence stated in the message, in
+// [0, 1], or 1 for findings that state none.
+func (f Finding) Confidence() float64 {
+	m := confidenceRx.FindStringSubmatch(f.Message)
+	if m == nil {
+		return 1
+	}
+	pct, _ := strconv.Atoi(m[1])
+	return float64(pct) / 100
+}
+
+// Impact estimates the value of fixing f: the nanoseconds per operation the
+// cost model says the replacement saves, plus the copy of a large channel
+// element, weighted by confidence. The savings of a producer with an
+// unbuffered channel are those measured unbuffered, where the pattern has
+// such a measurement. Without profile data every finding is assumed equally
+// hot. Findings that are not a pattern in the cost model,
+// such as BusyPoll, and test-only findings have zero impact.
+func (f Finding) Impact() float64 {
+	if f.TestOnly {
+		return 0
+	}
+	for p := range analyzer.Costs {
+		if p.String() == f.Pattern {
+			m := analyzer.Costs[p]
+			if f.Buffer != nil {
+				m = m.ForBuffer(*f.Buffer)
+			}
+			return (m.NsSavings() + analyzer.CopyNs(f.CopyBytes)) * f.Confidence()
+		}
+	}
+	return 0
+}
+
+// ValuePerEffort is Impact divided by the effort score, for ordering a fix
+// plan. Findings without an effort estimate count as one unit of effort.
+func (f Finding) ValuePerEffort() float64 {
+	if f.Effort == nil {
+		return f.Impact()
+	}
+	return f.Impact() / f.Effort.Score()
+}
+
+// Top returns the n findings with the highest impact, highest first. Ties
+// keep position order. n <= 0 returns all findings ranked.
+func Top(findings []Finding, n int) []Finding {
+	ranked := append([]Finding(nil), findings...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Impact() > ranked[j].Impact()
+	})
+	if n > 0 && n < len(ranked) {
+		ranked = ranked[:n]
+	}
+	return ranked
+}
+
+// SortKeys are the orders SortBy accepts, the default first.
+var SortKeys = []string{"position", "impact", "pattern"}
+
+// SortBy orders findings in place: by position; by impact, highest first;
+// or by pattern name. Ties keep position order.
+func SortBy(findings []Finding, key string) error {
+	sortFindings(findings)
+	switch key {
+	case "position":
+	case "impact":
+		sort.SliceStable(findings, func(i, j int) bool {
+			return findings[i].Impact() > findings[j].Impact()
+		})
+	case "pattern":
+		sort.SliceStable(findings, func(i, j int) bool {
+			return findings[i].Pattern < findings[j].Pattern
+		})
+	default:
+		return fmt.Errorf("unknown sort order %q (want one of %s)", key, strings.Join(SortKeys, ", "))
+	}
+	return nil
+}
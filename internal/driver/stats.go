@@ -0,0 +1,80 @@
+package driver
+
+import (
+	"maps"
+
+	"github.com/ravisastryk/chanopt/pkg/analyzer"
+	"golang.org/x/tools/go/analysis/checker"
+)
+
+// Rollup sums the analyzer.Stats facts of many packages, such as every
+// package of a monorepo, for a dashboard that tracks findings over time.
+// Like the facts, it counts findings before MinConfidence and Disable,
+// but PipelineStage findings only where they are kept.
+type Rollup struct {
+	Packages  int                       `json:"packages"` // analyzed, with or without findings
+	Findings  int                       `json:"findings"`
+	ByPattern map[string]int            `json:"by_pattern"`
+	ByRule    map[string]int            `json:"by_rule"`
+	ByPackage map[string]map[string]int `json:"by_package"` // packages with findings, by import path
+
+	// TestOnly counts by pattern the findings, included above, whose
+	// producer only tests use; see Finding.TestOnly.
+	TestOnly map[string]int `json:"test_only"`
+
+	seen map[string]bool
+}
+
+// add records the Stats fact of the package act analyzed, without its
+// PipelineStage findings unless stages is set. A package and its test
+// variant share an import path; the variant with more findings is kept,
+// as it also contains the package's non-test files.
+func (r *Rollup) add(act *checker.Action, stages bool) {
+	if r.seen == nil {
+		r.seen = map[string]bool{}
+		r.ByPattern, r.ByRule = map[string]int{}, map[string]int{}
+		r.ByPackage = map[string]map[string]int{}
+		r.TestOnly = map[string]int{}
+	}
+	path := act.Package.PkgPath
+	if !r.seen[path] {
+		r.seen[path] = true
+		r.Packages++
+	}
+	var st analyzer.Stats
+	if !act.PackageFact(act.Package.Types, &st) {
+		return
+	}
+	if _, ok := st.Counts["PipelineStage"]; ok && !stages {
+		st.Counts = maps.Clone(st.Counts)
+		delete(st.Counts, "PipelineStage")
+	}
+	if total(st.Counts) <= total(r.ByPackage[path]) {
+		return
+	}
+	for cat, n := range r.ByPackage[path] {
+		r.count(cat, -n)
+	}
+	for cat, n := range st.Counts {
+		r.count(cat, n)
+	}
+	r.ByPackage[path] = st.Counts
+}
+
+func (r *Rollup) count(cat string, n int) {
+	r.Findings += n
+	r.ByPattern[cat] += n
+	r.ByRule[analyzer.RuleID(cat)] += n
+	if r.ByPattern[cat] == 0 {
+		delete(r.ByPattern, cat)
+		delete(r.ByRule, analyzer.RuleID(cat))
+	}
+}
+
+func total(counts map[string]int) int {
+	n := 0
+	for _, c := range counts {
+		n += c
+	}
+	return n
+}
@@ -0,0 +1,19 @@
+package brokenfix
+
+// NextIDs is rewritten to func() int64 by the IDGenerator fix, which breaks
+// the receive in First.
+func NextIDs() <-chan int64 {
+	ch := make(chan int64)
+	go func() {
+		var id int64
+		for {
+			id++
+			ch <- id
+		}
+	}()
+	return ch
+}
+
+func First() int64 {
+	return <-NextIDs()
+}
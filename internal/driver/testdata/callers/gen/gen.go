@@ -0,0 +1,15 @@
+package gen
+
+func Tickets() <-chan int64 {
+	ch := make(chan int64)
+	go func() {
+		var n int64
+		for {
+			n++
+			ch <- n
+		}
+	}()
+	return ch
+}
+
+func first() int64 { return <-Tickets() }
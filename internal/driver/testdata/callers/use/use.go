@@ -0,0 +1,7 @@
+package use
+
+import "github.com/ravisastryk/chanopt/internal/driver/testdata/callers/gen"
+
+func A() int64 { return <-gen.Tickets() }
+
+func B() int64 { return <-gen.Tickets() }
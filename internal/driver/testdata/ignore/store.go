@@ -0,0 +1,43 @@
+package store
+
+// Store hands out IDs.
+type Store struct{}
+
+func (s *Store) IDs() <-chan int64 {
+	ch := make(chan int64)
+	go func() {
+		var n int64
+		for {
+			n++
+			ch <- n
+		}
+	}()
+	return ch
+}
+
+// MockStore stands in for Store in tests, next to it.
+type MockStore struct{}
+
+func (m *MockStore) IDs() <-chan int64 {
+	ch := make(chan int64)
+	go func() {
+		var n int64
+		for {
+			n++
+			ch <- n
+		}
+	}()
+	return ch
+}
+
+func NewMockIDs() <-chan int64 {
+	ch := make(chan int64)
+	go func() {
+		var n int64
+		for {
+			n++
+			ch <- n
+		}
+	}()
+	return ch
+}
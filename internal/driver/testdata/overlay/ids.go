@@ -0,0 +1,7 @@
+package overlay
+
+import "sync/atomic"
+
+var id atomic.Int64
+
+func NextID() int64 { return id.Add(1) }
@@ -0,0 +1,29 @@
+package ids
+
+// Next is used by production code and tests.
+func Next() <-chan int64 {
+	ch := make(chan int64)
+	go func() {
+		var n int64
+		for {
+			n++
+			ch <- n
+		}
+	}()
+	return ch
+}
+
+// Seq is used by a benchmark only.
+func Seq() <-chan int64 {
+	ch := make(chan int64)
+	go func() {
+		var n int64
+		for {
+			n++
+			ch <- n
+		}
+	}()
+	return ch
+}
+
+func Use() int64 { return <-Next() }
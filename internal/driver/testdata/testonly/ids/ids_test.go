@@ -0,0 +1,16 @@
+package ids
+
+import "testing"
+
+func BenchmarkSeq(b *testing.B) {
+	ch := Seq()
+	for range b.N {
+		<-ch
+	}
+}
+
+func TestNext(t *testing.T) {
+	if <-Next() != 1 {
+		t.Fatal("first ID is not 1")
+	}
+}
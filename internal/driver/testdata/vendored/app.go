@@ -0,0 +1,17 @@
+package app
+
+import "example.com/dep"
+
+func Tickets() <-chan int64 {
+	ch := make(chan int64)
+	go func() {
+		var n int64
+		for {
+			n++
+			ch <- n
+		}
+	}()
+	return ch
+}
+
+func both() (int64, int64) { return <-Tickets(), <-dep.Tickets() }
@@ -0,0 +1,13 @@
+package a
+
+func IDs() <-chan int {
+	ch := make(chan int)
+	go func() {
+		n := 0
+		for {
+			n++
+			ch <- n
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,2 @@
+module example.com/a
+go 1.25
@@ -0,0 +1,17 @@
+package b
+
+import "example.com/a"
+
+func Next() int { return <-a.IDs() }
+
+func More() <-chan int {
+	ch := make(chan int)
+	go func() {
+		n := 0
+		for {
+			n++
+			ch <- n
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,122 @@
+package driver
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// workspace is the go.work file in effect for a load, if any.
+type workspace struct {
+	modules []string // absolute directories of the modules it uses
+}
+
+// findWorkspace asks the go command for the go.work file in effect in dir
+// and the modules it uses. It returns nil outside workspace mode.
+func findWorkspace(dir string, env []string) (*workspace, error) {
+	gowork, err := goCmd(dir, env, "env", "GOWORK")
+	if err != nil {
+		return nil, err
+	}
+	if gowork == "" || gowork == "off" {
+		return nil, nil
+	}
+	out, err := goCmd(dir, env, "list", "-m", "-f", "{{.Dir}}")
+	if err != nil {
+		return nil, err
+	}
+	return &workspace{modules: strings.Fields(out)}, nil
+}
+
+func goCmd(dir string, env []string, args ...string) (string, error) {
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	cmd.Env = env
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("go %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// expand rewrites each relative dir/... pattern into one pattern per
+// workspace module under dir. The go command matches dir/... only within
+// the module containing dir, and rejects it when dir is a workspace root
+// outside every module; from a workspace root, ./... should mean all of
+// the workspace's modules. A pattern whose dir lies inside a module is
+// kept as well, for that module's packages.
+func (ws *workspace) expand(dir string, patterns []string) ([]string, error) {
+	base, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, p := range patterns {
+		prefix, ok := strings.CutSuffix(p, "/...")
+		if !ok || !(prefix == "." || prefix == ".." || strings.HasPrefix(prefix, "./") || strings.HasPrefix(prefix, "../")) {
+			out = append(out, p)
+			continue
+		}
+		root := filepath.Join(base, filepath.FromSlash(prefix))
+		inModule := false
+		for _, m := range ws.modules {
+			if within(root, m) {
+				inModule = true
+			}
+			if m != root && within(m, root) {
+				rel, err := filepath.Rel(base, m)
+				if err != nil {
+					return nil, err
+				}
+				out = append(out, "./"+filepath.ToSlash(rel)+"/...")
+			}
+		}
+		if inModule {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+// within reports whether path is dir or inside it.
+func within(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// workspaceEnv returns env without -mod=mod in GOFLAGS, which workspace
+// mode rejects.
+func workspaceEnv(env []string) []string {
+	out := make([]string, 0, len(env))
+	for _, kv := range env {
+		if flags, ok := strings.CutPrefix(kv, "GOFLAGS="); ok {
+			var keep []string
+			for _, f := range strings.Fields(flags) {
+				if f != "-mod=mod" {
+					keep = append(keep, f)
+				}
+			}
+			kv = "GOFLAGS=" + strings.Join(keep, " ")
+		}
+		out = append(out, kv)
+	}
+	return out
+}
+
+// loadEnv is the environment of the go command for a load in dir: in a
+// workspace, with GOFLAGS fixed up, and with the patterns expanded to
+// cover its modules.
+func loadEnv(dir string, patterns []string) ([]string, []string, error) {
+	env := os.Environ()
+	ws, err := findWorkspace(dir, workspaceEnv(env))
+	if err != nil || ws == nil {
+		return env, patterns, err
+	}
+	patterns, err = ws.expand(dir, patterns)
+	return workspaceEnv(env), patterns, err
+}
@@ -0,0 +1,423 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// aggChan is a buffered channel tracked by detectAggregators: a local
+// variable, package variable or struct field.
+type aggChan struct {
+	makePos   token.Pos
+	consumers []*ast.RangeStmt
+	consumer  ast.Node // function containing the range: *ast.FuncLit or *ast.FuncDecl
+	senders   map[ast.Node]bool
+	looped    bool // a sending goroutine is started in a loop
+	exported  bool // an exported function sends
+	shared    bool // a struct field or package variable, not one call's local
+	other     bool // some other use
+}
+
+// detectAggregators finds buffered channels drained by a single goroutine
+// that only folds each value into some state, while several goroutines
+// send to it:
+//
+//	type Metrics struct {
+//	    events chan Event
+//	    counts map[string]int
+//	}
+//
+//	func NewMetrics() *Metrics {
+//	    m := &Metrics{events: make(chan Event, 1024), counts: map[string]int{}}
+//	    go m.run()
+//	    return m
+//	}
+//
+//	func (m *Metrics) Record(e Event) { m.events <- e }
+//
+//	func (m *Metrics) run() {
+//	    for e := range m.events {
+//	        m.counts[e.Kind]++
+//	    }
+//	}
+//
+// The queue serializes the updates at the cost of a channel operation and
+// a goroutine wakeup each; a mutex around the state, or per-goroutine
+// shards merged at the end, does the same work in the sender.
+//
+// The channel must be ranged over exactly once, as a statement of the
+// body of a single goroutine: a function literal started with go, or a
+// function that one go statement starts, outside a loop in both cases.
+// The loop body is at most three pure statements writing state declared
+// outside it. Every other use of the channel is a send, close, len or cap.
+// There must be several senders: sends in two functions, in a goroutine
+// started in a loop, or, for a struct field or package variable, in an
+// exported function, which callers may run concurrently.
+func detectAggregators(pass *analysis.Pass) []token.Pos {
+	chans := map[*types.Var]*aggChan{}
+	track := func(lhs ast.Expr, e ast.Expr) {
+		mk, ok := e.(*ast.CallExpr)
+		if _, isChan := makeChan(e); !isChan || len(mk.Args) != 2 || !ok {
+			return
+		}
+		if n, ok := constInt(pass, mk.Args[1]); ok && n == 0 {
+			return
+		}
+		if obj := chanObj(pass, lhs); obj != nil && chans[obj] == nil {
+			shared := obj.IsField() || obj.Parent() == pass.Pkg.Scope()
+			chans[obj] = &aggChan{makePos: e.Pos(), senders: map[ast.Node]bool{}, shared: shared}
+		}
+	}
+	for _, file := range pass.Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.AssignStmt:
+				if len(n.Lhs) == len(n.Rhs) {
+					for i := range n.Lhs {
+						track(n.Lhs[i], n.Rhs[i])
+					}
+				}
+			case *ast.ValueSpec:
+				if len(n.Names) == len(n.Values) {
+					for i := range n.Names {
+						track(n.Names[i], n.Values[i])
+					}
+				}
+			case *ast.KeyValueExpr:
+				track(n.Key, n.Value)
+			}
+			return true
+		})
+	}
+	if len(chans) == 0 {
+		return nil
+	}
+
+	goOnly := goStartedFuncs(pass)
+	for _, file := range pass.Files {
+		var stack []ast.Node
+		ast.Inspect(file, func(n ast.Node) bool {
+			if n == nil {
+				stack = stack[:len(stack)-1]
+				return true
+			}
+			stack = append(stack, n)
+			id, ok := n.(*ast.Ident)
+			if !ok {
+				return true
+			}
+			v, _ := pass.TypesInfo.ObjectOf(id).(*types.Var)
+			c := chans[v]
+			if c == nil {
+				return true
+			}
+			useAggChan(pass, c, stack, goOnly)
+			return true
+		})
+	}
+
+	var found []token.Pos
+	for _, c := range chans {
+		if c.other || len(c.consumers) != 1 || c.consumer == nil || len(c.senders) == 0 || c.senders[c.consumer] {
+			continue
+		}
+		if len(c.senders) < 2 && !c.looped && !(c.exported && c.shared) {
+			continue
+		}
+		if !aggregates(pass, c.consumers[0]) {
+			continue
+		}
+		found = append(found, c.makePos)
+	}
+	return found
+}
+
+// chanObj returns the variable or field that e, the left-hand side of an
+// assignment or a composite literal key, denotes if it has channel type.
+func chanObj(pass *analysis.Pass, e ast.Expr) *types.Var {
+	var id *ast.Ident
+	switch e := ast.Unparen(e).(type) {
+	case *ast.Ident:
+		id = e
+	case *ast.SelectorExpr:
+		id = e.Sel
+	default:
+		return nil
+	}
+	obj, ok := pass.TypesInfo.ObjectOf(id).(*types.Var)
+	if !ok {
+		return nil
+	}
+	if _, ok := obj.Type().Underlying().(*types.Chan); !ok {
+		return nil
+	}
+	return obj
+}
+
+// useAggChan classifies the use of c at the top of stack.
+func useAggChan(pass *analysis.Pass, c *aggChan, stack []ast.Node, goOnly map[*types.Func]bool) {
+	i := len(stack) - 1
+	if sel, ok := stack[i-1].(*ast.SelectorExpr); ok && sel.Sel == stack[i] {
+		i-- // x.ch: the channel expression is the selector
+	}
+	expr, parent := stack[i], stack[i-1]
+	switch p := parent.(type) {
+	case *ast.SendStmt:
+		if p.Chan != expr {
+			c.other = true
+			return
+		}
+		fn, lit := enclosingFunc(stack[:i])
+		c.senders[fn] = true
+		if lit != nil && inLoop(stack[:i], lit) {
+			c.looped = true
+		}
+		if decl, ok := fn.(*ast.FuncDecl); ok && decl.Name.IsExported() {
+			c.exported = true
+		}
+	case *ast.RangeStmt:
+		if p.X != expr {
+			c.other = true
+			return
+		}
+		c.consumers = append(c.consumers, p)
+		fn, _ := enclosingFunc(stack[:i])
+		if !bodyStmt(fn, p) {
+			return
+		}
+		switch fn := fn.(type) {
+		case *ast.FuncLit:
+			if goLit(stack[:i], fn) && !inLoop(stack[:i], fn) {
+				c.consumer = fn
+			}
+		case *ast.FuncDecl:
+			if f, ok := pass.TypesInfo.Defs[fn.Name].(*types.Func); ok && goOnly[f] {
+				c.consumer = fn
+			}
+		}
+	case *ast.AssignStmt, *ast.ValueSpec, *ast.KeyValueExpr:
+		// The make tracked above; any other assignment is an alias.
+		if _, ok := makeChan(rhsOf(parent, expr)); !ok {
+			c.other = true
+		}
+	case *ast.CallExpr:
+		if !isCloseOf(p, func(e ast.Expr) bool { return e == expr }) {
+			if b, ok := typeutil.Callee(pass.TypesInfo, p).(*types.Builtin); !ok || b.Name() != "len" && b.Name() != "cap" {
+				c.other = true
+			}
+		}
+	case *ast.Field:
+		// A struct field declaration.
+	default:
+		c.other = true
+	}
+}
+
+// rhsOf returns the value assigned to lhs in an assignment, value spec or
+// composite literal element, or nil.
+func rhsOf(n ast.Node, lhs ast.Node) ast.Expr {
+	switch n := n.(type) {
+	case *ast.AssignStmt:
+		for i, l := range n.Lhs {
+			if l == lhs && i < len(n.Rhs) {
+				return n.Rhs[i]
+			}
+		}
+	case *ast.ValueSpec:
+		for i, l := range n.Names {
+			if l == lhs && i < len(n.Values) {
+				return n.Values[i]
+			}
+		}
+	case *ast.KeyValueExpr:
+		if n.Key == lhs {
+			return n.Value
+		}
+	}
+	return nil
+}
+
+// enclosingFunc returns the innermost function in stack, and that function
+// if it is a literal.
+func enclosingFunc(stack []ast.Node) (ast.Node, *ast.FuncLit) {
+	for i := len(stack) - 1; i >= 0; i-- {
+		switch fn := stack[i].(type) {
+		case *ast.FuncLit:
+			return fn, fn
+		case *ast.FuncDecl:
+			return fn, nil
+		}
+	}
+	return nil, nil
+}
+
+// goLit reports whether lit, in stack, is started as `go func() { ... }()`.
+func goLit(stack []ast.Node, lit *ast.FuncLit) bool {
+	for i := len(stack) - 1; i >= 2; i-- {
+		if stack[i] == lit {
+			call, ok := stack[i-1].(*ast.CallExpr)
+			g, ok2 := stack[i-2].(*ast.GoStmt)
+			return ok && ok2 && call.Fun == lit && g.Call == call
+		}
+	}
+	return false
+}
+
+// inLoop reports whether the go statement starting lit, in stack, is
+// inside a loop of its enclosing function.
+func inLoop(stack []ast.Node, lit *ast.FuncLit) bool {
+	if !goLit(stack, lit) {
+		return false
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		if stack[i] == lit {
+			return loopAbove(stack[:i+1])
+		}
+	}
+	return false
+}
+
+// bodyStmt reports whether stmt is one of the top-level statements of the
+// body of fn.
+func bodyStmt(fn ast.Node, stmt ast.Stmt) bool {
+	var body *ast.BlockStmt
+	switch fn := fn.(type) {
+	case *ast.FuncLit:
+		body = fn.Body
+	case *ast.FuncDecl:
+		body = fn.Body
+	}
+	if body == nil {
+		return false
+	}
+	for _, s := range body.List {
+		if s == stmt {
+			return true
+		}
+	}
+	return false
+}
+
+// goStartedFuncs returns the functions of the package that are started
+// by exactly one go statement outside a loop and not used otherwise, so
+// that they run in a single goroutine.
+func goStartedFuncs(pass *analysis.Pass) map[*types.Func]bool {
+	starts := map[*types.Func]int{}
+	other := map[*types.Func]bool{}
+	for _, file := range pass.Files {
+		goCalls := map[ast.Node]bool{}
+		var stack []ast.Node
+		ast.Inspect(file, func(n ast.Node) bool {
+			if n == nil {
+				stack = stack[:len(stack)-1]
+				return true
+			}
+			stack = append(stack, n)
+			switch n := n.(type) {
+			case *ast.GoStmt:
+				if f, ok := typeutil.Callee(pass.TypesInfo, n.Call).(*types.Func); ok {
+					starts[f]++
+					if loopAbove(stack) {
+						starts[f]++
+					}
+					fun := ast.Unparen(n.Call.Fun)
+					if sel, ok := fun.(*ast.SelectorExpr); ok {
+						fun = sel.Sel
+					}
+					goCalls[fun] = true
+				}
+			case *ast.Ident:
+				if f, ok := pass.TypesInfo.Uses[n].(*types.Func); ok && !goCalls[n] {
+					other[f] = true
+				}
+			}
+			return true
+		})
+	}
+	started := map[*types.Func]bool{}
+	for f, n := range starts {
+		if n == 1 && !other[f] {
+			started[f] = true
+		}
+	}
+	return started
+}
+
+// loopAbove reports whether the top of stack is inside a loop of its
+// enclosing function.
+func loopAbove(stack []ast.Node) bool {
+	for j := len(stack) - 2; j >= 0; j-- {
+		switch stack[j].(type) {
+		case *ast.ForStmt, *ast.RangeStmt:
+			return true
+		case *ast.FuncLit, *ast.FuncDecl:
+			return false
+		}
+	}
+	return false
+}
+
+// aggregates reports whether the body of rng is at most maxTrivialStmts pure
+// statements without channel operations that write to state declared
+// outside the loop.
+func aggregates(pass *analysis.Pass, rng *ast.RangeStmt) bool {
+	if len(rng.Body.List) == 0 || len(rng.Body.List) > maxTrivialStmts || !isPureBody(pass, rng.Body) {
+		return false
+	}
+	writes := false
+	ok := true
+	ast.Inspect(rng.Body, func(n ast.Node) bool {
+		var lhs []ast.Expr
+		switch n := n.(type) {
+		case *ast.SendStmt:
+			ok = false
+		case *ast.UnaryExpr:
+			if n.Op == token.ARROW {
+				ok = false
+			}
+		case *ast.IncDecStmt:
+			lhs = []ast.Expr{n.X}
+		case *ast.AssignStmt:
+			if n.Tok != token.DEFINE {
+				lhs = n.Lhs
+			}
+		}
+		for _, e := range lhs {
+			if id := rootIdent(e); id != nil {
+				if obj := pass.TypesInfo.ObjectOf(id); obj != nil && !encloses(rng, posNode(obj.Pos())) {
+					writes = true
+				}
+			}
+		}
+		return ok
+	})
+	return ok && writes
+}
+
+// rootIdent returns the variable at the root of x, x.f, x[i] or *x.
+func rootIdent(e ast.Expr) *ast.Ident {
+	for {
+		switch x := ast.Unparen(e).(type) {
+		case *ast.Ident:
+			return x
+		case *ast.SelectorExpr:
+			e = x.X
+		case *ast.IndexExpr:
+			e = x.X
+		case *ast.StarExpr:
+			e = x.X
+		default:
+			return nil
+		}
+	}
+}
+
+// posNode is a zero-width node at pos, for encloses.
+type posNode token.Pos
+
+func (p posNode) Pos() token.Pos { return token.Pos(p) }
+func (p posNode) End() token.Pos { return token.Pos(p) }
@@ -1,6 +1,11 @@
 package analyzer
 
 import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"sort"
+
 	"golang.org/x/tools/go/analysis"
 	"golang.org/x/tools/go/analysis/passes/inspect"
 )
@@ -11,25 +16,282 @@ import (
 //
 //	go vet -vettool=$(which chanopt) ./...
 var Analyzer = &analysis.Analyzer{
-	Name:     "chanopt",
-	Doc:      "detect channel patterns replaceable with mutex/atomic (8-127x faster)",
-	Run:      run,
-	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Name:       "chanopt",
+	Doc:        "detect channel patterns replaceable with mutex/atomic (up to 213x faster)",
+	Run:        run,
+	Requires:   []*analysis.Analyzer{inspect.Analyzer},
+	ResultType: resultType,
+	FactTypes:  []analysis.Fact{new(producerFact), new(Stats)},
+}
+
+// Options are settings of a run of Analyzer that a driver passes in
+// directly rather than through Analyzer's flags, which the whole process
+// shares.
+type Options struct {
+	// PipelineStages reports pipeline stages, as the pipeline flag does.
+	PipelineStages bool
+}
+
+// With returns a copy of Analyzer that runs with opts. It has the same
+// name, requirements, facts and result type, so a driver can run it in
+// place of Analyzer.
+func With(opts Options) *analysis.Analyzer {
+	a := *Analyzer
+	a.Run = func(pass *analysis.Pass) (any, error) { return runWith(pass, opts) }
+	return &a
 }
 
 func run(pass *analysis.Pass) (any, error) {
+	return runWith(pass, Options{})
+}
+
+func runWith(pass *analysis.Pass, opts Options) (any, error) {
+	// Buffer diagnostics and report them in position order, so output does
+	// not depend on map iteration or on the order the checks run in.
+	var diags []analysis.Diagnostic
+	emit := pass.Report
+	pass.Report = func(d analysis.Diagnostic) { diags = append(diags, d) }
+	tm := newTimer(pass)
+	defer func() {
+		tm.enter("report")
+		defer tm.done()
+		pass.Report = emit
+		sort.SliceStable(diags, func(i, j int) bool { return diagLess(diags[i], diags[j]) })
+		ig := collectIgnores(pass)
+		stats := &Stats{Counts: map[string]int{}}
+		for _, d := range diags {
+			if !ig.suppressed(pass, d) {
+				stats.Counts[d.Category]++
+				emit(withRule(d))
+			}
+		}
+		if len(stats.Counts) > 0 {
+			pass.ExportPackageFact(stats)
+		}
+	}()
+
+	d := newDeadline(pass)
+	var cache cacheStats
+	producers := map[types.Object]producer{}
+	res := &Result{Efforts: map[token.Pos]Effort{}, Producers: map[token.Pos]string{}, CopyBytes: map[token.Pos]int64{}, Buffers: map[token.Pos]int64{}}
+	tm.enter("detect")
+	for _, cp := range detect(pass, d) {
+		// The SendAfterClose check looks at the same generators, so it
+		// runs here rather than detecting them again.
+		tm.enter("checks")
+		for _, diag := range sendsAfterClose(pass, cp) {
+			pass.Report(diag)
+		}
+		tm.enter("gates")
+		if why := restarts(pass, cp); why != "" {
+			debugf(pass, "%s: skipped %s: %s",
+				pass.Fset.Position(cp.makePos), cp.funcDecl.Name.Name, why)
+			continue
+		}
+		if cp.stop != nil && !cp.stop.opaque && !cp.stop.stops {
+			reportStopLeak(pass, cp.funcDecl, cp.stop)
+		}
+		tm.enter("classify")
+		pat, conf := classifyCached(cp, pass, &cache)
+		tm.enter("gates")
+		if pat == Unknown || conf < 0.5 {
+			continue
+		}
+		var notes []string
+		own := transfersOwnership(pass, cp)
+		if own.reused != "" {
+			debugf(pass, "%s: skipped %s: it writes to %s after sending it, so the channel hands off ownership",
+				pass.Fset.Position(cp.makePos), cp.funcDecl.Name.Name, own.reused)
+			continue
+		}
+		if own.mutable != "" && (pat == Singleton || pat == ConfigBroadcaster) {
+			// Each call gets its own value from the channel; the
+			// replacement hands every caller the same one.
+			conf -= 0.15
+			notes = append(notes, "lower confidence: each receiver gets a "+own.mutable+" of its own, which the replacement would share between callers")
+		}
+		if cp.stop != nil && cp.stop.stops {
+			notes = append(notes, stopNote(pat))
+		}
+		if pat == ChanTicker {
+			if n := timerChurnNote(pass, cp.file, cp); n != "" {
+				notes = append(notes, n)
+			}
+			if forwardsTicker(pass, cp) {
+				notes = append(notes, "it only relays the ticker's ticks; return the *time.Ticker, or its C field, instead")
+			}
+		}
+		if (pat == Singleton || pat == ConfigBroadcaster) && sendInLoop(cp.funcLit.Body, cp.sends[0]) {
+			notes = append(notes, resendNote(pat))
+		}
+		buf := chanBuffer(pass, cp)
+		notes = append(notes, bufferNote(pat, buf, makeSize(cp)))
+		res.Buffers[cp.makePos] = buf
+		if pat == BoundedIterator && bufferCoversItems(pass, cp) {
+			notes = append(notes, "the buffer holds every item, so the goroutine never waits for the consumer and adds no pipelining")
+		}
+		if n := elemCopy(pass, cp); n > 0 {
+			notes = append(notes, copyNote(pass, n, cp.chanType.Elem()))
+			res.CopyBytes[cp.makePos] = n
+		}
+		tm.enter("fixes")
+		fixes := suggestFixes(pass, cp.file, cp, pat)
+		tm.enter("gates")
+		if HasAutofix(pat) && hasDirective(cp.funcDecl, "nofix") {
+			notes = append(notes, "no fix offered: "+cp.funcDecl.Name.Name+" is marked //chanopt:nofix")
+		}
+		report(pass, cp.makePos, pat, conf, fixes, notes...)
+		e := producerEffort(pass, cp.funcDecl, fixes)
+		if cp.helper != nil {
+			e.Lines += lineSpan(pass.Fset, cp.helper)
+		}
+		res.Efforts[cp.makePos] = e
+		if obj, ok := pass.TypesInfo.Defs[cp.funcDecl.Name].(*types.Func); ok {
+			producers[obj] = producer{cp.funcDecl.Name.Name, pat, cp.makePos}
+			res.Producers[cp.makePos] = obj.FullName()
+			pass.ExportObjectFact(obj, &producerFact{pat})
+		}
+	}
+	tm.enter("checks")
 	for _, file := range pass.Files {
-		for _, cp := range detect(pass, file) {
-			pat, conf := classify(cp, pass)
-			if pat == Unknown || conf < 0.5 {
+		for _, fo := range detectFanOut(pass, file) {
+			report(pass, fo.makePos, FanOutDispatcher, 0.75, nil)
+			res.Efforts[fo.makePos] = localEffort(pass, file, fo.makePos)
+		}
+		for _, pos := range detectResultCollectors(pass, file) {
+			report(pass, pos, ResultCollector, 0.8, nil)
+			res.Efforts[pos] = localEffort(pass, file, pos)
+		}
+		// Low confidence: the turn-taking is sometimes the point, as in
+		// tests of handoff order.
+		for _, pos := range detectPingPongs(pass, file) {
+			report(pass, pos, PingPong, 0.6, nil)
+			res.Efforts[pos] = localEffort(pass, file, pos)
+		}
+	}
+	for _, pos := range detectLatestValues(pass) {
+		report(pass, pos, ConfigBroadcaster, 0.7, nil)
+		for _, file := range pass.Files {
+			if file.Pos() <= pos && pos < file.End() {
+				res.Efforts[pos] = localEffort(pass, file, pos)
+			}
+		}
+	}
+	if !d.exceeded("aggregator detection") {
+		for _, pos := range detectAggregators(pass) {
+			report(pass, pos, AggregatorQueue, 0.7, nil)
+			for _, file := range pass.Files {
+				if file.Pos() <= pos && pos < file.End() {
+					res.Efforts[pos] = localEffort(pass, file, pos)
+				}
+			}
+		}
+	}
+	if !d.exceeded("sharded dispatcher detection") {
+		for _, sd := range detectShardedDispatchers(pass) {
+			if sd.exempt != "" {
+				debugf(pass, "%s: sharded dispatcher not reported: %s", pass.Fset.Position(sd.pos), sd.exempt)
 				continue
 			}
-			spec := Registry[pat]
-			pass.Reportf(cp.makePos,
-				"chanopt: %s pattern — replace channel with %s (%s speedup, %.0f%% confidence)",
-				pat, spec.Replacement, spec.Speedup, conf*100,
-			)
+			report(pass, sd.pos, ShardedDispatcher, 0.65, nil)
+			for _, file := range pass.Files {
+				if file.Pos() <= sd.pos && sd.pos < file.End() {
+					res.Efforts[sd.pos] = localEffort(pass, file, sd.pos)
+				}
+			}
 		}
 	}
-	return nil, nil
+	if !d.exceeded("memo cache detection") {
+		for _, pos := range detectMemoCaches(pass) {
+			report(pass, pos, MemoCache, 0.6, nil)
+			for _, file := range pass.Files {
+				if file.Pos() <= pos && pos < file.End() {
+					res.Efforts[pos] = localEffort(pass, file, pos)
+				}
+			}
+		}
+	}
+	if !d.exceeded("lazy init detection") {
+		for _, li := range detectLazyInit(pass) {
+			if li.pattern == LazyInit {
+				report(pass, li.makePos, LazyInit, 0.85, nil)
+			} else {
+				report(pass, li.makePos, li.pattern, 0.8, nil, onceNote(li.pattern))
+			}
+			e := Effort{Lines: lineSpan(pass.Fset, li.init)}
+			for _, fn := range li.accessors {
+				if fn != li.init {
+					e.Lines += lineSpan(pass.Fset, fn)
+				}
+			}
+			res.Efforts[li.makePos] = e
+		}
+	}
+	reportBusyPolls(pass, producers)
+	reportChanHelpers(pass)
+	reportErrGroups(pass)
+	for _, diag := range contextStops(pass) {
+		pass.Report(diag)
+	}
+	if (pipelineStages || opts.PipelineStages) && !d.exceeded("pipeline stages") {
+		reportPipelineStages(pass)
+	}
+
+	tm.enter("call sites")
+	if !d.exceeded("call sites") {
+		res.Calls = findCalls(pass, producers)
+	}
+	tm.enter("report")
+	if cache.lookups > 0 {
+		debugf(pass, "classification cache: %d of %d lookups hit (%.0f%%)",
+			cache.hits, cache.lookups, 100*float64(cache.hits)/float64(cache.lookups))
+	}
+	for pos, name := range res.Producers {
+		e := res.Efforts[pos]
+		e.CallSites = len(res.Calls[name])
+		res.Efforts[pos] = e
+	}
+	return res, nil
+}
+
+// diagLess orders diagnostics by position, then category and message.
+func diagLess(a, b analysis.Diagnostic) bool {
+	if a.Pos != b.Pos {
+		return a.Pos < b.Pos
+	}
+	if a.Category != b.Category {
+		return a.Category < b.Category
+	}
+	return a.Message < b.Message
+}
+
+// report emits the diagnostic for a finding of pat at pos. Notes are
+// appended to the message after a semicolon.
+func report(pass *analysis.Pass, pos token.Pos, pat Pattern, conf float64, fixes []analysis.SuggestedFix, notes ...string) {
+	spec := Spec(pat)
+	msg := fmt.Sprintf(
+		"chanopt: %s pattern — replace channel with %s (%s speedup, %.0f%% confidence%s)",
+		pat, spec.Replacement, spec.Speedup, conf*100, allocNote(pat),
+	)
+	for _, n := range notes {
+		msg += "; " + n
+	}
+	pass.Report(analysis.Diagnostic{
+		Pos:            pos,
+		Category:       pat.String(),
+		Message:        msg,
+		SuggestedFixes: fixes,
+	})
+}
+
+// allocNote formats the allocation savings measured for pat, if any.
+func allocNote(pat Pattern) string {
+	bytes, allocs := Costs[pat].AllocSavings()
+	switch {
+	case allocs == 0:
+		return ""
+	case bytes == 0:
+		return fmt.Sprintf(", saves %d allocs/op", allocs)
+	}
+	return fmt.Sprintf(", saves %d B/op in %d allocs/op", bytes, allocs)
 }
@@ -1,10 +1,22 @@
 package analyzer_test
 
 import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/ravisastryk/chanopt/pkg/analyzer"
 	"golang.org/x/tools/go/analysis/analysistest"
+	"golang.org/x/tools/txtar"
 )
 
 func TestPositivePatterns(t *testing.T) {
@@ -14,3 +26,321 @@ func TestPositivePatterns(t *testing.T) {
 func TestNegativePatterns(t *testing.T) {
 	analysistest.Run(t, analysistest.TestData(), analyzer.Analyzer, "negative")
 }
+
+func TestContextStop(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), analyzer.Analyzer, "ctxstop")
+}
+
+func TestSendAfterClose(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), analyzer.Analyzer, "sendclose")
+}
+
+// TestBudget checks the fallback to per-function heuristics once the
+// analysis time budget of a package is spent.
+func TestBudget(t *testing.T) {
+	flags := &analyzer.Analyzer.Flags
+	old := flags.Lookup("budget").Value.String()
+	if err := flags.Set("budget", "1ns"); err != nil {
+		t.Fatal(err)
+	}
+	defer flags.Set("budget", old)
+	analysistest.Run(t, analysistest.TestData(), analyzer.Analyzer, "budget")
+}
+
+// TestDiagnosticOrder requires diagnostics in position order, whatever
+// order the checks found them in.
+func TestDiagnosticOrder(t *testing.T) {
+	for _, r := range analysistest.Run(t, analysistest.TestData(), analyzer.Analyzer, "positive") {
+		if !sort.SliceIsSorted(r.Diagnostics, func(i, j int) bool {
+			return r.Diagnostics[i].Pos < r.Diagnostics[j].Pos
+		}) {
+			t.Errorf("%s: diagnostics not in position order", r.Pass.Pkg.Path())
+		}
+	}
+}
+
+func TestPipelineStages(t *testing.T) {
+	if err := analyzer.Analyzer.Flags.Set("pipeline", "true"); err != nil {
+		t.Fatal(err)
+	}
+	defer analyzer.Analyzer.Flags.Set("pipeline", "false")
+	analysistest.Run(t, analysistest.TestData(), analyzer.Analyzer, "pipeline")
+}
+
+// TestFixtureCoverage requires every Registry pattern to have a positive
+// fixture (a want comment in testdata/src/positive) and a negative fixture
+// (testdata/src/negative/<pattern>.go) of look-alike code that must not fire.
+// Patterns that are not Detectable still need negative fixtures; the test
+// fails as soon as one of them gains a positive fixture, so the list
+// cannot go stale.
+func TestFixtureCoverage(t *testing.T) {
+	dir := analysistest.TestData()
+	positive := wantedPatterns(t, filepath.Join(dir, "src", "positive"))
+	for p := range analyzer.Registry {
+		name := p.String()
+		neg := filepath.Join(dir, "src", "negative", strings.ToLower(name)+".go")
+		if _, err := os.Stat(neg); err != nil {
+			t.Errorf("%s: missing negative fixture %s", name, neg)
+		}
+		switch {
+		case !analyzer.Detectable(p) && positive[name]:
+			t.Errorf("%s now has a positive fixture; make it Detectable", name)
+		case analyzer.Detectable(p) && !positive[name]:
+			t.Errorf("%s: no positive fixture wants `chanopt: %s pattern`", name, name)
+		}
+	}
+}
+
+// stableIDs pins the number of every pattern. Configs and baselines store
+// these; an entry here must never change or be removed, only added to.
+var stableIDs = map[string]int{
+	"IDGenerator":       1,
+	"RoundRobin":        2,
+	"RateLimiter":       3,
+	"ConfigBroadcaster": 4,
+	"BoundedIterator":   5,
+	"CircuitBreaker":    6,
+	"ChanSemaphore":     7,
+	"Singleton":         8,
+	"FixedFanIn":        9,
+	"ChanTicker":        10,
+	"FanOutDispatcher":  11,
+	"LazyInit":          12,
+	"ResultCollector":   13,
+	"PingPong":          14,
+	"AggregatorQueue":   15,
+	"ShardedDispatcher": 16,
+	"MemoCache":         17,
+	"ErrorIterator":     18,
+}
+
+func TestPatternIDsStable(t *testing.T) {
+	for name, id := range stableIDs {
+		p, err := analyzer.ParsePattern(name)
+		if err != nil {
+			t.Errorf("ParsePattern(%q): %v", name, err)
+			continue
+		}
+		if int(p) != id {
+			t.Errorf("%s = %d, want %d; pattern numbers must not change", name, p, id)
+		}
+		if lower, _ := analyzer.ParsePattern(strings.ToLower(name)); lower != p {
+			t.Errorf("ParsePattern(%q) = %v, want %v", strings.ToLower(name), lower, p)
+		}
+	}
+	for p := range analyzer.Registry {
+		if _, ok := stableIDs[p.String()]; !ok {
+			t.Errorf("%s (%d) has no entry in stableIDs", p, int(p))
+		}
+	}
+	if _, err := analyzer.ParsePattern("Unknown"); err == nil {
+		t.Error("ParsePattern(\"Unknown\") succeeded")
+	}
+}
+
+// stableRules pins the rule ID of every check besides the Registry
+// patterns, whose rule IDs are their numbers.
+var stableRules = map[string]string{
+	"BusyPoll":       "CHOPT101",
+	"StopLeak":       "CHOPT102",
+	"OrDone":         "CHOPT103",
+	"Merge":          "CHOPT104",
+	"PipelineStage":  "CHOPT105",
+	"ErrGroup":       "CHOPT106",
+	"ContextStop":    "CHOPT107",
+	"SendAfterClose": "CHOPT108",
+}
+
+func TestRuleIDsStable(t *testing.T) {
+	for name, id := range stableIDs {
+		if got, want := analyzer.RuleID(name), fmt.Sprintf("CHOPT%03d", id); got != want {
+			t.Errorf("RuleID(%q) = %q, want %q", name, got, want)
+		}
+	}
+	for name, want := range stableRules {
+		if got := analyzer.RuleID(name); got != want {
+			t.Errorf("RuleID(%q) = %q, want %q; rule IDs must not change", name, got, want)
+		}
+	}
+	for name := range analyzer.Checks {
+		if _, ok := stableRules[name]; !ok {
+			t.Errorf("check %s has no entry in stableRules", name)
+		}
+	}
+	if cat, ok := analyzer.RuleCategory("chopt010"); !ok || cat != "ChanTicker" {
+		t.Errorf("RuleCategory(\"chopt010\") = %q, %v", cat, ok)
+	}
+	if msg, id := analyzer.CutRule("chanopt: busy-wait polling [CHOPT101]"); msg != "chanopt: busy-wait polling" || id != "CHOPT101" {
+		t.Errorf("CutRule = %q, %q", msg, id)
+	}
+}
+
+func TestOverride(t *testing.T) {
+	t.Cleanup(analyzer.ResetOverrides)
+	def := analyzer.Registry[analyzer.IDGenerator]
+	if err := analyzer.Override(analyzer.IDGenerator, analyzer.PatternSpec{Replacement: "ids.Next"}); err != nil {
+		t.Fatal(err)
+	}
+	got := analyzer.Spec(analyzer.IDGenerator)
+	if got.Replacement != "ids.Next" || got.Speedup != def.Speedup || got.Rationale != def.Rationale {
+		t.Errorf("Spec after Override = %+v", got)
+	}
+	if analyzer.Registry[analyzer.IDGenerator] != def {
+		t.Error("Override modified Registry")
+	}
+	if err := analyzer.Override(analyzer.Unknown, analyzer.PatternSpec{}); err == nil {
+		t.Error("Override(Unknown) succeeded")
+	}
+	analyzer.ResetOverrides()
+	if analyzer.Spec(analyzer.IDGenerator) != def {
+		t.Error("ResetOverrides left the override in place")
+	}
+}
+
+// TestRegistrySpeedups checks that every pattern's Speedup is the ratio
+// measured in Costs, not a figure typed in by hand.
+func TestRegistrySpeedups(t *testing.T) {
+	for p, spec := range analyzer.Registry {
+		m, ok := analyzer.Costs[p]
+		if !ok {
+			t.Errorf("%s has no entry in Costs", p)
+			continue
+		}
+		want := fmt.Sprintf("~%.1fx", m.Speedup())
+		if m.Speedup() >= 10 {
+			want = fmt.Sprintf("~%.0fx", m.Speedup())
+		}
+		if spec.Speedup != want {
+			t.Errorf("Registry[%s].Speedup = %q, measured %s", p, spec.Speedup, want)
+		}
+	}
+}
+
+// TestAllocSavings checks that the savings of a replacement are never
+// negative, even when its fewer allocations are larger.
+func TestAllocSavings(t *testing.T) {
+	for _, tc := range []struct {
+		name                  string
+		channel, optimized    analyzer.Cost
+		wantBytes, wantAllocs int64
+	}{
+		{"fewer and smaller", analyzer.Cost{BytesPerOp: 160, AllocsPerOp: 2}, analyzer.Cost{BytesPerOp: 48, AllocsPerOp: 1}, 112, 1},
+		{"fewer but larger", analyzer.Cost{BytesPerOp: 96, AllocsPerOp: 3}, analyzer.Cost{BytesPerOp: 128, AllocsPerOp: 1}, 0, 2},
+		{"as many", analyzer.Cost{BytesPerOp: 96, AllocsPerOp: 1}, analyzer.Cost{BytesPerOp: 8, AllocsPerOp: 1}, 0, 0},
+		{"more", analyzer.Cost{}, analyzer.Cost{BytesPerOp: 64, AllocsPerOp: 1}, 0, 0},
+	} {
+		m := analyzer.CostModel{Channel: tc.channel, Optimized: tc.optimized}
+		if bytes, allocs := m.AllocSavings(); bytes != tc.wantBytes || allocs != tc.wantAllocs {
+			t.Errorf("%s: AllocSavings() = %d, %d, want %d, %d", tc.name, bytes, allocs, tc.wantBytes, tc.wantAllocs)
+		}
+	}
+	for p, m := range analyzer.Costs {
+		if bytes, allocs := m.AllocSavings(); bytes < 0 || allocs < 0 {
+			t.Errorf("Costs[%s].AllocSavings() = %d, %d", p, bytes, allocs)
+		}
+	}
+}
+
+var wantRE = regexp.MustCompile(`// want .*chanopt: (\w+) pattern`)
+
+// wantedPatterns returns the pattern names expected by want comments in dir.
+func wantedPatterns(t *testing.T, dir string) map[string]bool {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := map[string]bool{}
+	for _, f := range files {
+		src, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range wantRE.FindAllSubmatch(src, -1) {
+			found[string(m[1])] = true
+		}
+	}
+	return found
+}
+
+var updateGolden = flag.Bool("update-golden", false, "rewrite testdata .golden files from the current fixes")
+
+// TestSuggestedFixes compares each file in testdata/src/fixes, with every
+// suggested fix applied, against its .golden counterpart.
+//
+//	go test ./pkg/analyzer -run TestSuggestedFixes -update-golden
+func TestSuggestedFixes(t *testing.T) {
+	if *updateGolden {
+		writeGolden(t, analysistest.Run(t, analysistest.TestData(), analyzer.Analyzer, "fixes"))
+	}
+	analysistest.RunWithSuggestedFixes(t, analysistest.TestData(), analyzer.Analyzer, "fixes")
+}
+
+// writeGolden applies every suggested fix in results and writes the formatted
+// output next to each source file as <file>.golden. A file where one
+// diagnostic offers alternative fixes gets a txtar archive instead, with
+// one section per fix message.
+func writeGolden(t *testing.T, results []*analysistest.Result) {
+	type edit struct {
+		start, end int
+		text       []byte
+	}
+	edits := map[string]map[string][]edit{} // by file and fix message
+	alternatives := map[string]bool{}
+	for _, r := range results {
+		for _, f := range r.Pass.Files {
+			edits[r.Pass.Fset.File(f.Pos()).Name()] = map[string][]edit{}
+		}
+		for _, d := range r.Diagnostics {
+			for _, fix := range d.SuggestedFixes {
+				for _, e := range fix.TextEdits {
+					tf := r.Pass.Fset.File(e.Pos)
+					edits[tf.Name()][fix.Message] = append(edits[tf.Name()][fix.Message],
+						edit{tf.Offset(e.Pos), tf.Offset(e.End), e.NewText})
+					alternatives[tf.Name()] = alternatives[tf.Name()] || len(d.SuggestedFixes) > 1
+				}
+			}
+		}
+	}
+	for name, byMsg := range edits {
+		orig, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		apply := func(es []edit) []byte {
+			src := slices.Clone(orig)
+			sort.Slice(es, func(i, j int) bool { return es[i].start > es[j].start })
+			for _, e := range es {
+				src = append(src[:e.start:e.start], append(e.text, src[e.end:]...)...)
+			}
+			out, err := format.Source(src)
+			if err != nil {
+				t.Fatalf("%s: fixed output does not parse: %v", name, err)
+			}
+			return out
+		}
+		var out []byte
+		if alternatives[name] {
+			ar := &txtar.Archive{}
+			for _, msg := range slices.Sorted(maps.Keys(byMsg)) {
+				ar.Files = append(ar.Files, txtar.File{Name: msg, Data: apply(byMsg[msg])})
+			}
+			out = txtar.Format(ar)
+		} else {
+			var all []edit
+			for _, es := range byMsg {
+				for _, e := range es {
+					// Fixes that add the same import make the same edit.
+					if !slices.ContainsFunc(all, func(a edit) bool {
+						return a.start == e.start && a.end == e.end && bytes.Equal(a.text, e.text)
+					}) {
+						all = append(all, e)
+					}
+				}
+			}
+			out = apply(all)
+		}
+		if err := os.WriteFile(name+".golden", out, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
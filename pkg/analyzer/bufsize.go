@@ -0,0 +1,131 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// bufferCoversItems reports whether cp, a bounded generator, makes its
+// channel with room for every item it sends:
+//
+//	ch := make(chan T, len(items))
+//	go func() {
+//	    defer close(ch)
+//	    for _, v := range items {
+//	        ch <- v
+//	    }
+//	}()
+//
+// The goroutine then never blocks and fills the buffer regardless of the
+// consumer, so it adds no pipelining at all. The buffer must be the same
+// expression as the loop bound, or a constant no smaller than a constant
+// bound (an array length or an integer range), and the loop must send at
+// most once per iteration.
+func bufferCoversItems(pass *analysis.Pass, cp channelProducer) bool {
+	size := makeSize(cp)
+	if size == nil || len(cp.sends) != 1 {
+		return false
+	}
+	var loop *ast.RangeStmt
+	for _, stmt := range cp.funcLit.Body.List {
+		if r, ok := stmt.(*ast.RangeStmt); ok {
+			if loop != nil {
+				return false
+			}
+			loop = r
+		}
+	}
+	if loop == nil || !encloses(loop.Body, cp.sends[0]) || containsLoop(loop.Body) {
+		return false
+	}
+	if body, bound := loopBound(pass, loop); body != nil && types.ExprString(size) == bound {
+		return true
+	}
+	buf, ok := constInt(pass, size)
+	if !ok {
+		return false
+	}
+	n, ok := constLen(pass, loop.X)
+	return ok && buf >= n
+}
+
+// chanBuffer returns the buffer size of cp's channel: 0 if it is
+// unbuffered, -1 if the size is not a constant.
+func chanBuffer(pass *analysis.Pass, cp channelProducer) int64 {
+	size := makeSize(cp)
+	if size == nil {
+		return 0
+	}
+	if n, ok := constInt(pass, size); ok {
+		return n
+	}
+	return -1
+}
+
+// bufferNote states the buffer of a producer's channel, buf as returned by
+// chanBuffer and size its expression. An unbuffered channel hands each
+// value over synchronously, which the cost model measures separately for
+// the generator patterns: the replacement saves more there.
+func bufferNote(pat Pattern, buf int64, size ast.Expr) string {
+	switch {
+	case buf < 0:
+		return "buffer of " + types.ExprString(size)
+	case buf > 0:
+		return fmt.Sprintf("buffer of %d", buf)
+	}
+	note := "unbuffered: each value is a synchronous handoff between goroutines"
+	if m := Costs[pat]; m.Unbuffered.NsPerOp > 0 {
+		note += " (" + formatSpeedup(m.ForBuffer(0).Speedup()) + " speedup measured unbuffered)"
+	}
+	return note
+}
+
+// makeSize returns the size argument of cp's make call, or nil.
+func makeSize(cp channelProducer) ast.Expr {
+	for _, stmt := range cp.funcDecl.Body.List {
+		as, ok := stmt.(*ast.AssignStmt)
+		if !ok || as.Pos() != cp.makePos {
+			continue
+		}
+		if mk, ok := as.Rhs[0].(*ast.CallExpr); ok && len(mk.Args) == 2 {
+			return mk.Args[1]
+		}
+	}
+	return nil
+}
+
+// constLen returns the number of iterations of ranging over x when it is
+// known at compile time: the length of an array, or a constant integer.
+func constLen(pass *analysis.Pass, x ast.Expr) (int64, bool) {
+	if n, ok := constInt(pass, x); ok {
+		return n, true
+	}
+	t := pass.TypesInfo.TypeOf(x)
+	if t == nil {
+		return 0, false
+	}
+	if p, ok := t.Underlying().(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	if a, ok := t.Underlying().(*types.Array); ok {
+		return a.Len(), true
+	}
+	return 0, false
+}
+
+func constInt(pass *analysis.Pass, e ast.Expr) (int64, bool) {
+	tv, ok := pass.TypesInfo.Types[e]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.Int {
+		return 0, false
+	}
+	return constant.Int64Val(tv.Value)
+}
+
+// encloses reports whether n contains the node m.
+func encloses(n, m ast.Node) bool {
+	return n.Pos() <= m.Pos() && m.End() <= n.End()
+}
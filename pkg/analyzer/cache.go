@@ -0,0 +1,193 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/types"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// cacheDir is where classification results are kept across runs; "" keeps
+// them in memory only, for the current run.
+var cacheDir string
+
+func init() {
+	Analyzer.Flags.StringVar(&cacheDir, "cache", "",
+		"`dir` in which to keep classification results across runs")
+}
+
+// classification is a memoized result of classify.
+type classification struct {
+	pat  Pattern
+	conf float64
+}
+
+// classified memoizes classify for the current process by classKey, so
+// that identical functions, such as generated ones, are classified once.
+var classified sync.Map
+
+// cacheStats counts the classification cache lookups of one package.
+type cacheStats struct {
+	lookups, hits int
+}
+
+// classifyCached is classify, memoized in memory and, with -cache, on
+// disk. The key covers everything classify looks at: the source of the
+// function and of its goroutine helper, the object each identifier in
+// them refers to, and the Go version of the file, which decides whether
+// the iterator patterns apply.
+func classifyCached(cp channelProducer, pass *analysis.Pass, st *cacheStats) (Pattern, float64) {
+	key := classKey(cp, pass)
+	st.lookups++
+	if c, ok := classified.Load(key); ok {
+		st.hits++
+		return c.(classification).pat, c.(classification).conf
+	}
+	if c, ok := readCached(key); ok {
+		st.hits++
+		classified.Store(key, c)
+		return c.pat, c.conf
+	}
+	pat, conf := classify(cp, pass)
+	c := classification{pat, conf}
+	classified.Store(key, c)
+	writeCached(key, c)
+	return pat, conf
+}
+
+// classKey hashes the Go version of the producer's file, the source of its
+// function and helper and, for each identifier, where its object comes
+// from, its kind and its type, the underlying type of a type name and the
+// value of a constant. Objects
+// of the package itself are qualified relative to it, so identical code
+// in two generated packages shares an entry.
+func classKey(cp channelProducer, pass *analysis.Pass) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x01", fileVersion(pass, cp.file))
+	qual := func(p *types.Package) string {
+		if p == pass.Pkg {
+			return ""
+		}
+		return p.Path()
+	}
+	for _, fn := range []*ast.FuncDecl{cp.funcDecl, cp.helper} {
+		if fn == nil {
+			continue
+		}
+		if err := format.Node(h, pass.Fset, fn); err != nil {
+			fmt.Fprintf(h, "%p", fn) // unprintable: never shared
+		}
+		ast.Inspect(fn, func(n ast.Node) bool {
+			id, ok := n.(*ast.Ident)
+			if !ok {
+				return true
+			}
+			obj := pass.TypesInfo.ObjectOf(id)
+			if obj == nil {
+				io.WriteString(h, "\x00-")
+				return true
+			}
+			if obj.Pkg() != nil {
+				fmt.Fprintf(h, "\x00%s", qual(obj.Pkg()))
+			}
+			fmt.Fprintf(h, "\x00%T %s", obj, types.TypeString(obj.Type(), qual))
+			switch obj := obj.(type) {
+			case *types.TypeName:
+				fmt.Fprintf(h, "=%s", types.TypeString(types.Unalias(obj.Type()).Underlying(), qual))
+			case *types.Const:
+				fmt.Fprintf(h, "=%s", obj.Val().ExactString())
+			}
+			return true
+		})
+		io.WriteString(h, "\x01")
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// buildID identifies the chanopt binary, so that results on disk are not
+// reused by a version whose classifier differs. Development builds without
+// version control information are identified by their executable's hash.
+var buildID = sync.OnceValue(func() string {
+	id := runtime.Version()
+	bi, ok := debug.ReadBuildInfo()
+	if ok {
+		id += " " + bi.Main.Path + "@" + bi.Main.Version
+		for _, s := range bi.Settings {
+			if s.Key == "vcs.revision" || s.Key == "vcs.modified" {
+				id += " " + s.Key + "=" + s.Value
+			}
+		}
+	}
+	if !ok || (bi.Main.Version == "" || bi.Main.Version == "(devel)") && !strings.Contains(id, "vcs.modified=false") {
+		if exe, err := os.Executable(); err == nil {
+			if f, err := os.Open(exe); err == nil {
+				h := sha256.New()
+				io.Copy(h, f)
+				f.Close()
+				id += " " + hex.EncodeToString(h.Sum(nil))
+			}
+		}
+	}
+	return id
+})
+
+// cachePath is the file holding the result for key in cacheDir, sharded by
+// the first byte of the name like the go build cache.
+func cachePath(key string) string {
+	sum := sha256.Sum256([]byte(buildID() + "\x00" + key))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(cacheDir, name[:2], name)
+}
+
+// readCached returns the result for key from cacheDir. The file holds the
+// pattern's stable ID and the confidence.
+func readCached(key string) (classification, bool) {
+	if cacheDir == "" {
+		return classification{}, false
+	}
+	data, err := os.ReadFile(cachePath(key))
+	if err != nil {
+		return classification{}, false
+	}
+	id, conf, ok := strings.Cut(strings.TrimSpace(string(data)), " ")
+	n, err1 := strconv.Atoi(id)
+	c, err2 := strconv.ParseFloat(conf, 64)
+	if !ok || err1 != nil || err2 != nil || n < 0 || n >= len(patternNames) {
+		return classification{}, false
+	}
+	return classification{Pattern(n), c}, true
+}
+
+// writeCached stores the result for key in cacheDir. Errors are ignored:
+// the cache only saves time. The file is renamed into place so that
+// concurrent go vet processes never read a partial entry.
+func writeCached(key string, c classification) {
+	if cacheDir == "" {
+		return
+	}
+	path := cachePath(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "tmp-")
+	if err != nil {
+		return
+	}
+	_, err = fmt.Fprintf(tmp, "%d %g\n", c.pat, c.conf)
+	if cerr := tmp.Close(); err == nil && cerr == nil && os.Rename(tmp.Name(), path) == nil {
+		return
+	}
+	os.Remove(tmp.Name())
+}
@@ -0,0 +1,166 @@
+package analyzer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+const cacheSrc = `package p
+
+type T = int
+
+func IDs() <-chan T {
+	ch := make(chan T)
+	go func() {
+		var n T
+		for {
+			n++
+			ch <- n
+		}
+	}()
+	return ch
+}
+`
+
+func TestClassifyCache(t *testing.T) {
+	classify := func(src string) (classification, cacheStats) {
+		t.Helper()
+		pass, err := typecheckGen(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var st cacheStats
+		var c classification
+		for _, cp := range detect(pass, nil) {
+			c.pat, c.conf = classifyCached(cp, pass, &st)
+		}
+		return c, st
+	}
+
+	cacheDir = t.TempDir()
+	defer func() { cacheDir = "" }()
+	classified.Clear()
+	first, st := classify(cacheSrc)
+	if first.pat != IDGenerator || st.lookups != 1 || st.hits != 0 {
+		t.Fatalf("first run: %v, %+v; want IDGenerator and a miss", first, st)
+	}
+	if c, st := classify(cacheSrc); c != first || st.hits != 1 {
+		t.Errorf("identical function: %v, %+v; want a memory hit", c, st)
+	}
+	classified.Clear()
+	if c, st := classify(cacheSrc); c != first || st.hits != 1 {
+		t.Errorf("next run: %v, %+v; want a hit from -cache", c, st)
+	}
+
+	// The same function with a different type is a different entry.
+	if _, st := classify(strings.Replace(cacheSrc, "T = int", "T = int64", 1)); st.hits != 0 {
+		t.Errorf("changed function: %+v; want a miss", st)
+	}
+}
+
+// TestClassifyCacheGoVersion checks that identical generators in files of
+// different Go versions do not share an entry, since classify reports
+// ErrorIterator only where iter.Seq2 is available.
+func TestClassifyCacheGoVersion(t *testing.T) {
+	const src = `package p
+
+type result struct {
+	n   int
+	err error
+}
+
+func Parse(xs []int, parse func(int) (int, error)) <-chan result {
+	ch := make(chan result)
+	go func() {
+		defer close(ch)
+		for _, x := range xs {
+			n, err := parse(x)
+			ch <- result{n, err}
+		}
+	}()
+	return ch
+}
+`
+	classified.Clear()
+	for _, tt := range []struct {
+		src  string
+		want Pattern
+	}{
+		{"//go:build go1.23\n\n" + src, ErrorIterator},
+		{"//go:build go1.21\n\n" + src, BoundedIterator},
+	} {
+		pass, err := typecheckGen(tt.src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var st cacheStats
+		for _, cp := range detect(pass, nil) {
+			if pat, _ := classifyCached(cp, pass, &st); pat != tt.want {
+				t.Errorf("%s: got %s, want %s", strings.SplitN(tt.src, "\n", 2)[0], pat, tt.want)
+			}
+		}
+		if st.lookups != 1 {
+			t.Fatalf("%d lookups, want 1", st.lookups)
+		}
+	}
+}
+
+// TestClassifyCacheObjectKind checks that a constant and a variable of the
+// same name and type do not share an entry: a generator re-sending the
+// constant is a Singleton, one re-sending the variable a
+// ConfigBroadcaster.
+func TestClassifyCacheObjectKind(t *testing.T) {
+	const src = `package p
+
+%s cur string = "v1"
+
+func Current() <-chan string {
+	ch := make(chan string)
+	go func() {
+		for {
+			ch <- cur
+		}
+	}()
+	return ch
+}
+`
+	classified.Clear()
+	keys := map[string]bool{}
+	for _, tt := range []struct {
+		decl string
+		want Pattern
+	}{
+		{"const", Singleton},
+		{"var", ConfigBroadcaster},
+		{"const", Singleton},
+	} {
+		pass, err := typecheckGen(fmt.Sprintf(src, tt.decl))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var st cacheStats
+		for _, cp := range detect(pass, nil) {
+			keys[classKey(cp, pass)] = true
+			if pat, _ := classifyCached(cp, pass, &st); pat != tt.want {
+				t.Errorf("%s cur: got %s, want %s", tt.decl, pat, tt.want)
+			}
+		}
+	}
+	if len(keys) != 2 {
+		t.Errorf("%d keys for a constant and a variable, want 2", len(keys))
+	}
+
+	// Constants of different values are different entries.
+	pass, err := typecheckGen(fmt.Sprintf(src, "const"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := typecheckGen(strings.Replace(fmt.Sprintf(src, "const"), `"v1"`, `"v2"`, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if classKey(detect(pass, nil)[0], pass) == classKey(detect(other, nil)[0], other) {
+		t.Errorf("constants of different values share a key")
+	}
+}
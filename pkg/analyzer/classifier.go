@@ -6,6 +6,7 @@ import (
 	"go/types"
 
 	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/types/typeutil"
 )
 
 // classify determines which of the 10 patterns a channelProducer matches.
@@ -17,42 +18,80 @@ func classify(cp channelProducer, pass *analysis.Pass) (Pattern, float64) {
 	}
 
 	// ── Safety gates (must ALL pass) ──
-	if containsMultiCaseSelect(body) {
+	drops := false
+	if containsMultiCaseSelect(body, func(sel *ast.SelectStmt) bool {
+		if isDroppingSend(sel, cp.chanName) {
+			drops = true
+			return true
+		}
+		return isStopSelect(pass, sel, cp.stop, cp.chanName)
+	}) {
 		return Unknown, 0 // genuine coordination
 	}
 	if containsIO(body, pass) {
 		return Unknown, 0 // I/O side effects
 	}
-	if rangesOverChannel(body, pass) {
+	ticks := tickerChans(pass, cp)
+	if rangesOverChannel(body, pass, ticks) {
 		return Unknown, 0 // legitimate pipeline stage
 	}
 
-	ind := extractIndicators(body, cp.chanIdent.Name, pass)
+	ind := extractIndicators(body, cp.chanName, pass, ticks)
+	sent := sentKind(pass, cp)
+	if drops && !ind.hasTimeTicker && !ind.rangesTicker {
+		return Unknown, 0 // dropping values is only a token bucket's refill
+	}
 
 	// ── Pattern matching (ordered by specificity) ──
 	switch {
+	// Ticker forwarder: relays time.Ticker ticks to a chan time.Time
+	case forwardsTicker(pass, cp):
+		return ChanTicker, 0.85
+
+	// Error iterator: range over collection + close(ch), sending
+	// (value, error) pairs, where iter.Seq2 is available
+	case ind.hasRange && ind.hasClose && fileAtLeast(pass, cp.file, "go1.23") && isErrorStream(pass, cp):
+		return ErrorIterator, 0.85
+
 	// Bounded iterator: range over collection + close(ch)
 	case ind.hasRange && ind.hasClose:
 		return BoundedIterator, 0.92
 
-	// Round-robin: modulo arithmetic + slice indexing in loop
-	case ind.hasModulo && ind.hasIndexExpr && ind.infiniteLoop:
+	// Round-robin: modulo arithmetic + slice indexing in a single loop
+	// (nested loops mean weighted or batched selection logic)
+	case ind.hasModulo && ind.hasIndexExpr && ind.infiniteLoop && !ind.nestedLoop:
 		return RoundRobin, 0.90
 
-	// ID generator: counter increment in infinite loop
-	case ind.hasIncrement && ind.infiniteLoop && !ind.hasTimeSleep:
+	// ID generator: the incremented counter itself is sent in an infinite
+	// loop, and changes from one send to the next
+	case ind.hasIncrement && ind.sendsCounter && sent == sendsVarying && ind.infiniteLoop && !ind.hasTimeSleep && !ind.hasTimeAfter:
 		return IDGenerator, 0.95
 
 	// Rate limiter: time.Ticker feeding a channel
-	case ind.hasTimeTicker:
+	case ind.hasTimeTicker || ind.rangesTicker:
 		return RateLimiter, 0.78
 
-	// Ticker/Heartbeat: time.Sleep in infinite loop sending signals
-	case ind.hasTimeSleep && ind.infiniteLoop:
+	// Ticker/Heartbeat: time.Sleep or <-time.After in infinite loop sending signals
+	// (a growing duration is a backoff; no ticker replaces it)
+	case (ind.hasTimeSleep || ind.hasTimeAfter) && ind.infiniteLoop && !ind.varyingSleep:
 		return ChanTicker, 0.80
 
-	// Singleton: sends exactly once (single send, no loop around it)
-	case len(cp.sends) == 1 && !ind.infiniteLoop && !ind.hasRange:
+	// Singleton: an infinite loop sending the same value on every receive
+	// (an empty struct is a signal, not a value)
+	case ind.infiniteLoop && len(cp.sends) == 1 && sent <= sendsInvariant && !ind.nestedLoop && !ind.hasRange && !ind.rangesChan && !isSignal(cp.chanType):
+		return Singleton, 0.80
+
+	// Config broadcaster: an infinite loop re-sending whatever shared state
+	// holds at each receive
+	case ind.infiniteLoop && len(cp.sends) == 1 && sent == sendsShared && !ind.nestedLoop && !ind.hasRange && !ind.rangesChan:
+		return ConfigBroadcaster, 0.75
+
+	// Singleton: sends exactly once (single send, no loop around it), most
+	// clearly when the value is fixed before the goroutine runs
+	case len(cp.sends) == 1 && !ind.infiniteLoop && !ind.hasRange && !ind.rangesChan && !sendInLoop(body, cp.sends[0]):
+		if sent <= sendsInvariant {
+			return Singleton, 0.85
+		}
 		return Singleton, 0.70
 
 	default:
@@ -69,18 +108,38 @@ type indicators struct {
 	hasClose      bool // close(ch)
 	hasTimeSleep  bool // time.Sleep(...)
 	hasTimeTicker bool // time.NewTicker / time.Tick
+	rangesTicker  bool // for range t.C, or over a local holding a ticker's channel
+	rangesChan    bool // for range over any channel
+	hasTimeAfter  bool // <-time.After(d)
 	infiniteLoop  bool // for { ... } with no condition
+	varyingSleep  bool // a Sleep or After duration changes between iterations (backoff)
+	nestedLoop    bool // a loop inside another loop
+	sendsCounter  bool // ch <- id where id is incremented
 }
 
-func extractIndicators(body *ast.BlockStmt, chanName string, pass *analysis.Pass) indicators {
+func extractIndicators(body *ast.BlockStmt, chanName string, pass *analysis.Pass, ticks map[types.Object]bool) indicators {
 	var ind indicators
+	counters := map[types.Object]bool{}
+	var sent, sleeps []ast.Expr
 	ast.Inspect(body, func(n ast.Node) bool {
 		switch node := n.(type) {
 		case *ast.IncDecStmt:
 			if node.Tok == token.INC {
 				ind.hasIncrement = true
+				if id, ok := node.X.(*ast.Ident); ok {
+					counters[pass.TypesInfo.ObjectOf(id)] = true
+				}
+			}
+		case *ast.SendStmt:
+			if id, ok := node.Chan.(*ast.Ident); ok && id.Name == chanName {
+				sent = append(sent, node.Value)
 			}
 		case *ast.AssignStmt:
+			if node.Tok == token.ADD_ASSIGN && len(node.Lhs) == 1 {
+				if id, ok := node.Lhs[0].(*ast.Ident); ok {
+					counters[pass.TypesInfo.ObjectOf(id)] = true
+				}
+			}
 			for _, rhs := range node.Rhs {
 				if bin, ok := rhs.(*ast.BinaryExpr); ok && bin.Op == token.REM {
 					ind.hasModulo = true
@@ -89,6 +148,17 @@ func extractIndicators(body *ast.BlockStmt, chanName string, pass *analysis.Pass
 		case *ast.IndexExpr:
 			ind.hasIndexExpr = true
 		case *ast.RangeStmt:
+			if containsLoop(node.Body) {
+				ind.nestedLoop = true
+			}
+			if isTickerChan(pass, node.X, ticks) {
+				ind.rangesTicker = true
+			}
+			if t := pass.TypesInfo.TypeOf(node.X); t != nil {
+				if _, ok := t.Underlying().(*types.Chan); ok {
+					ind.rangesChan = true
+				}
+			}
 			// Only flag hasRange if ranging over a collection (slice/array/map),
 			// not an input channel (which is a legitimate pipeline stage)
 			if tv, ok := pass.TypesInfo.Types[node.X]; ok {
@@ -105,6 +175,9 @@ func extractIndicators(body *ast.BlockStmt, chanName string, pass *analysis.Pass
 			if node.Cond == nil {
 				ind.infiniteLoop = true
 			}
+			if containsLoop(node.Body) {
+				ind.nestedLoop = true
+			}
 		case *ast.CallExpr:
 			// close(ch)
 			if ident, ok := node.Fun.(*ast.Ident); ok && ident.Name == "close" {
@@ -114,33 +187,127 @@ func extractIndicators(body *ast.BlockStmt, chanName string, pass *analysis.Pass
 					}
 				}
 			}
-			// time.Sleep, time.NewTicker, time.Tick
-			if sel, ok := node.Fun.(*ast.SelectorExpr); ok {
-				if pkg, ok := sel.X.(*ast.Ident); ok && pkg.Name == "time" {
-					switch sel.Sel.Name {
-					case "Sleep":
-						ind.hasTimeSleep = true
-					case "NewTicker", "Tick":
-						ind.hasTimeTicker = true
-					}
-				}
+			// time.Sleep, time.NewTicker, time.Tick, time.After
+			switch timeFunc(pass, node) {
+			case "Sleep":
+				ind.hasTimeSleep = true
+				sleeps = append(sleeps, node.Args...)
+			case "NewTicker", "Tick":
+				ind.hasTimeTicker = true
+			case "After":
+				ind.hasTimeAfter = true
+				sleeps = append(sleeps, node.Args...)
 			}
 		}
 		return true
 	})
+	for _, v := range sent {
+		ast.Inspect(v, func(n ast.Node) bool {
+			if id, ok := n.(*ast.Ident); ok && counters[pass.TypesInfo.ObjectOf(id)] {
+				ind.sendsCounter = true
+			}
+			return !ind.sendsCounter
+		})
+	}
+	ind.varyingSleep = varies(body, sleeps, pass)
 	return ind
 }
 
-// containsMultiCaseSelect returns true if body has a select with 2+ cases.
-// This indicates genuine coordination (e.g., with context cancellation).
-func containsMultiCaseSelect(body *ast.BlockStmt) bool {
+// varies reports whether any of exprs depends on a variable that body
+// changes, directly or through variables defined from it:
+//
+//	for attempt := 0; ; attempt++ {
+//	    time.Sleep(base << attempt)
+//	    retry <- struct{}{}
+//	}
+//
+// Sleeping for a growing duration is a backoff, not a ticker.
+func varies(body *ast.BlockStmt, exprs []ast.Expr, pass *analysis.Pass) bool {
+	if len(exprs) == 0 {
+		return false
+	}
+	changed := map[types.Object]bool{}
+	mark := func(e ast.Expr) {
+		if id, ok := ast.Unparen(e).(*ast.Ident); ok && pass.TypesInfo.ObjectOf(id) != nil {
+			changed[pass.TypesInfo.ObjectOf(id)] = true
+		}
+	}
+	var defines []*ast.AssignStmt
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.IncDecStmt:
+			mark(n.X)
+		case *ast.AssignStmt:
+			if n.Tok == token.DEFINE {
+				defines = append(defines, n)
+				return true
+			}
+			for _, lhs := range n.Lhs {
+				mark(lhs)
+			}
+		}
+		return true
+	})
+	dependsOnChanged := func(e ast.Expr) bool {
+		found := false
+		ast.Inspect(e, func(n ast.Node) bool {
+			if id, ok := n.(*ast.Ident); ok && changed[pass.TypesInfo.Uses[id]] {
+				found = true
+			}
+			return !found
+		})
+		return found
+	}
+	// d := base << attempt makes d vary with attempt.
+	for grew := true; grew; {
+		grew = false
+		for _, as := range defines {
+			if len(as.Lhs) != len(as.Rhs) {
+				continue
+			}
+			for i, rhs := range as.Rhs {
+				if id, ok := as.Lhs[i].(*ast.Ident); ok && !changed[pass.TypesInfo.Defs[id]] && dependsOnChanged(rhs) {
+					mark(id)
+					grew = true
+				}
+			}
+		}
+	}
+	for _, e := range exprs {
+		if dependsOnChanged(e) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsLoop reports whether body contains a for or range statement,
+// not counting loops inside nested function literals.
+func containsLoop(body *ast.BlockStmt) bool {
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.ForStmt, *ast.RangeStmt:
+			found = true
+		case *ast.FuncLit:
+			return false
+		}
+		return !found
+	})
+	return found
+}
+
+// containsMultiCaseSelect returns true if body has a select with 2+ cases,
+// other than those allowed by ignore. This indicates genuine coordination
+// (e.g., with context cancellation).
+func containsMultiCaseSelect(body *ast.BlockStmt, ignore func(*ast.SelectStmt) bool) bool {
 	found := false
 	ast.Inspect(body, func(n ast.Node) bool {
 		if found {
 			return false
 		}
 		if sel, ok := n.(*ast.SelectStmt); ok && sel.Body != nil {
-			if len(sel.Body.List) >= 2 {
+			if len(sel.Body.List) >= 2 && !ignore(sel) {
 				found = true
 			}
 		}
@@ -149,6 +316,37 @@ func containsMultiCaseSelect(body *ast.BlockStmt) bool {
 	return found
 }
 
+// isDroppingSend reports whether sel only sends on the channel ch, or
+// does nothing if the send would block:
+//
+//	select {
+//	case ch <- struct{}{}:
+//	default:
+//	}
+//
+// A rate limiter's ticker drops the token it cannot add to a full bucket.
+func isDroppingSend(sel *ast.SelectStmt, ch string) bool {
+	if len(sel.Body.List) != 2 {
+		return false
+	}
+	var sends, skips bool
+	for _, c := range sel.Body.List {
+		cc := c.(*ast.CommClause)
+		if len(cc.Body) != 0 {
+			return false
+		}
+		switch comm := cc.Comm.(type) {
+		case nil:
+			skips = true
+		case *ast.SendStmt:
+			if id, ok := comm.Chan.(*ast.Ident); ok && id.Name == ch {
+				sends = true
+			}
+		}
+	}
+	return sends && skips
+}
+
 // containsIO returns true if the goroutine body calls net/os/io/database.
 func containsIO(body *ast.BlockStmt, pass *analysis.Pass) bool {
 	ioPkgs := map[string]bool{
@@ -184,10 +382,12 @@ func containsIO(body *ast.BlockStmt, pass *analysis.Pass) bool {
 	return found
 }
 
-// rangesOverChannel returns true if the goroutine ranges over an input channel parameter.
-// This indicates a pipeline stage (channel-to-channel transformation), not a generator.
-// Ranging over ticker.C or other internal channels is fine (not a pipeline stage).
-func rangesOverChannel(body *ast.BlockStmt, pass *analysis.Pass) bool {
+// rangesOverChannel returns true if the goroutine ranges over an input
+// channel: a parameter, or a channel obtained from a call or from outside
+// the generator. This indicates a pipeline stage (channel-to-channel
+// transformation), not a generator. Ranging over ticker.C, or over a local
+// variable holding a ticker's channel (ticks), is not a pipeline stage.
+func rangesOverChannel(body *ast.BlockStmt, pass *analysis.Pass, ticks map[types.Object]bool) bool {
 	found := false
 	ast.Inspect(body, func(n ast.Node) bool {
 		if found {
@@ -205,19 +405,120 @@ func rangesOverChannel(body *ast.BlockStmt, pass *analysis.Pass) bool {
 			return true // not an identifier, continue searching
 		}
 
-		// Check if this identifier is a channel type
 		if tv, ok := pass.TypesInfo.Types[rangeStmt.X]; ok {
 			if _, isChanType := tv.Type.Underlying().(*types.Chan); isChanType {
-				// Check if it's a function parameter (not a local variable)
-				if obj := pass.TypesInfo.ObjectOf(ident); obj != nil {
-					// Parameters have parent scope of the function, locals are in inner scope
-					// For now, conservatively filter out any channel identifier
-					// This catches input channels from function parameters
-					found = true
-				}
+				found = !ticks[pass.TypesInfo.ObjectOf(ident)]
 			}
 		}
 		return !found
 	})
 	return found
 }
+
+// tickerChans returns the tickers cp creates and the local variables that
+// only ever hold their channels:
+//
+//	t := time.NewTicker(d)
+//	c := t.C          // or c := time.Tick(d)
+//	go func() {
+//	    for range c { ch <- struct{}{} }
+//	}()
+//
+// A variable also assigned anything else, such as an input channel, is
+// left out, and so is a ticker passed in by the caller.
+func tickerChans(pass *analysis.Pass, cp channelProducer) map[types.Object]bool {
+	ticks := map[types.Object]bool{}
+	collect := func(match func(ast.Expr) bool) {
+		found, other := map[types.Object]bool{}, map[types.Object]bool{}
+		assign := func(lhs, rhs ast.Expr) {
+			id, ok := lhs.(*ast.Ident)
+			if !ok || pass.TypesInfo.ObjectOf(id) == nil {
+				return
+			}
+			if match(rhs) {
+				found[pass.TypesInfo.ObjectOf(id)] = true
+			} else {
+				other[pass.TypesInfo.ObjectOf(id)] = true
+			}
+		}
+		for _, body := range []*ast.BlockStmt{cp.funcDecl.Body, cp.funcLit.Body} {
+			ast.Inspect(body, func(n ast.Node) bool {
+				switch n := n.(type) {
+				case *ast.AssignStmt:
+					if len(n.Lhs) == len(n.Rhs) {
+						for i := range n.Lhs {
+							assign(n.Lhs[i], n.Rhs[i])
+						}
+					}
+				case *ast.ValueSpec:
+					if len(n.Names) == len(n.Values) {
+						for i := range n.Names {
+							assign(n.Names[i], n.Values[i])
+						}
+					}
+				}
+				return true
+			})
+		}
+		for obj := range found {
+			if !other[obj] {
+				ticks[obj] = true
+			}
+		}
+	}
+	collect(func(e ast.Expr) bool { return isTimeCall(pass, e, "NewTicker") })
+	collect(func(e ast.Expr) bool { return isTickerChan(pass, e, ticks) })
+	return ticks
+}
+
+// isTickerChan reports whether e is a ticker's channel: the C field of one
+// of the tickers in ticks, a call of time.Tick, or one of the channel
+// variables in ticks.
+func isTickerChan(pass *analysis.Pass, e ast.Expr, ticks map[types.Object]bool) bool {
+	switch e := ast.Unparen(e).(type) {
+	case *ast.SelectorExpr:
+		id, ok := e.X.(*ast.Ident)
+		return ok && isTickerC(pass, e) && ticks[pass.TypesInfo.ObjectOf(id)]
+	case *ast.CallExpr:
+		return isTimeCall(pass, e, "Tick")
+	case *ast.Ident:
+		return ticks[pass.TypesInfo.ObjectOf(e)]
+	}
+	return false
+}
+
+// isTimeCall reports whether e calls the named function of package time.
+func isTimeCall(pass *analysis.Pass, e ast.Expr, name string) bool {
+	call, ok := ast.Unparen(e).(*ast.CallExpr)
+	return ok && timeFunc(pass, call) == name
+}
+
+// timeFunc returns the name of the function of package time that call
+// calls, or "". The package is resolved by type, so a renamed import of
+// time counts and a local variable named time does not.
+func timeFunc(pass *analysis.Pass, call *ast.CallExpr) string {
+	f := typeutil.StaticCallee(pass.TypesInfo, call)
+	if f == nil || f.Pkg() == nil || f.Pkg().Path() != "time" {
+		return ""
+	}
+	return f.Name()
+}
+
+// timerChurnNote warns that before Go 1.23, each <-time.After(d) in a loop
+// leaves a timer that is not collected until it fires.
+func timerChurnNote(pass *analysis.Pass, file *ast.File, cp channelProducer) string {
+	if fileAtLeast(pass, file, "go1.23") {
+		return ""
+	}
+	found := false
+	ast.Inspect(cp.funcLit.Body, func(n ast.Node) bool {
+		if u, ok := n.(*ast.UnaryExpr); ok && u.Op == token.ARROW && isTimeCall(pass, u.X, "After") {
+			found = true
+		}
+		return !found
+	})
+	if !found {
+		return ""
+	}
+	return "before Go 1.23 each time.After in the loop also leaks a timer until it fires"
+}
@@ -0,0 +1,143 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// producer is a function in the current package whose returned channel was
+// classified as a replaceable pattern.
+type producer struct {
+	name    string
+	pattern Pattern
+	makePos token.Pos
+}
+
+// reportBusyPolls flags consumer loops that spin on a producer's channel:
+//
+//	for {
+//	    select {
+//	    case v := <-ch: ...
+//	    default:
+//	    }
+//	}
+//
+// where ch comes from a call to a function in producers. Replacing the
+// producer with its suggested primitive removes the spin as well.
+func reportBusyPolls(pass *analysis.Pass, producers map[types.Object]producer) {
+	if len(producers) == 0 {
+		return
+	}
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil {
+				continue
+			}
+			// Local variables holding a producer's channel.
+			fed := map[types.Object]producer{}
+			ast.Inspect(fn.Body, func(n ast.Node) bool {
+				switch n := n.(type) {
+				case *ast.AssignStmt:
+					if len(n.Lhs) != 1 || len(n.Rhs) != 1 {
+						break
+					}
+					id, ok := n.Lhs[0].(*ast.Ident)
+					if !ok {
+						break
+					}
+					if p, ok := producerCall(pass, n.Rhs[0], producers); ok {
+						fed[pass.TypesInfo.ObjectOf(id)] = p
+					}
+				case *ast.ForStmt:
+					if n.Cond != nil {
+						break
+					}
+					for _, stmt := range n.Body.List {
+						sel, ok := stmt.(*ast.SelectStmt)
+						if !ok {
+							continue
+						}
+						if p, ok := pollsProducer(pass, sel, producers, fed); ok {
+							pass.Report(analysis.Diagnostic{
+								Pos:      sel.Pos(),
+								Category: "BusyPoll",
+								Message: fmt.Sprintf(
+									"chanopt: busy-wait polling of %s (%s pattern) — with %s there is nothing to poll",
+									p.name, p.pattern, Spec(p.pattern).Replacement,
+								),
+								Related: []analysis.RelatedInformation{{
+									Pos:     p.makePos,
+									Message: fmt.Sprintf("%s channel created here", p.name),
+								}},
+							})
+						}
+					}
+				}
+				return true
+			})
+		}
+	}
+}
+
+// pollsProducer reports whether sel has a default clause and receives from
+// a producer's channel, either directly or through a variable in fed.
+func pollsProducer(pass *analysis.Pass, sel *ast.SelectStmt, producers, fed map[types.Object]producer) (producer, bool) {
+	hasDefault := false
+	var found producer
+	ok := false
+	for _, s := range sel.Body.List {
+		cc := s.(*ast.CommClause)
+		if cc.Comm == nil {
+			hasDefault = true
+			continue
+		}
+		recv := commRecv(cc.Comm)
+		if recv == nil {
+			continue
+		}
+		if id, isIdent := ast.Unparen(recv).(*ast.Ident); isIdent {
+			if p, hit := fed[pass.TypesInfo.ObjectOf(id)]; hit {
+				found, ok = p, true
+			}
+		} else if p, hit := producerCall(pass, recv, producers); hit {
+			found, ok = p, true
+		}
+	}
+	return found, ok && hasDefault
+}
+
+// commRecv returns the channel operand of a receive comm clause.
+func commRecv(comm ast.Stmt) ast.Expr {
+	var e ast.Expr
+	switch s := comm.(type) {
+	case *ast.ExprStmt:
+		e = s.X
+	case *ast.AssignStmt:
+		if len(s.Rhs) == 1 {
+			e = s.Rhs[0]
+		}
+	}
+	if u, ok := ast.Unparen(e).(*ast.UnaryExpr); ok && u.Op == token.ARROW {
+		return u.X
+	}
+	return nil
+}
+
+// producerCall reports whether e is a call to one of producers.
+func producerCall(pass *analysis.Pass, e ast.Expr, producers map[types.Object]producer) (producer, bool) {
+	call, ok := ast.Unparen(e).(*ast.CallExpr)
+	if !ok {
+		return producer{}, false
+	}
+	id, ok := ast.Unparen(call.Fun).(*ast.Ident)
+	if !ok {
+		return producer{}, false
+	}
+	p, ok := producers[pass.TypesInfo.Uses[id]]
+	return p, ok
+}
@@ -0,0 +1,69 @@
+package analyzer
+
+// Cost is the measured per-operation cost of one side of a pattern, as
+// reported by `go test -bench=. -benchmem` in demos/bench_test.go.
+type Cost struct {
+	NsPerOp     float64
+	BytesPerOp  int64
+	AllocsPerOp int64
+}
+
+// CostModel pairs the channel implementation of a pattern with its
+// optimized replacement. Entries live in costmodel_gen.go, regenerated
+// with `make costmodel`.
+type CostModel struct {
+	Channel   Cost
+	Optimized Cost
+
+	// Unbuffered is the channel implementation measured with an unbuffered
+	// channel, where each value is a synchronous handoff between the
+	// goroutines, for the generator patterns whose Channel benchmark uses
+	// a buffer of 64. It is zero when not measured.
+	Unbuffered Cost
+}
+
+// ForBuffer returns the model for a channel with a buffer of n, or of a
+// size that is not a constant if n < 0: with Channel replaced by
+// Unbuffered when n is 0 and Unbuffered was measured. Any buffer counts as
+// buffered; small ones fall in between.
+func (m CostModel) ForBuffer(n int64) CostModel {
+	if n == 0 && m.Unbuffered.NsPerOp > 0 {
+		m.Channel = m.Unbuffered
+	}
+	return m
+}
+
+// Speedup returns how many times faster the replacement is, or 0 if not
+// measured.
+func (m CostModel) Speedup() float64 {
+	if m.Optimized.NsPerOp <= 0 {
+		return 0
+	}
+	return m.Channel.NsPerOp / m.Optimized.NsPerOp
+}
+
+// AllocSavings returns the bytes and allocations per operation saved by the
+// replacement. Both are zero when the replacement allocates as often or
+// more, and bytes is zero when its fewer allocations are as large or larger.
+func (m CostModel) AllocSavings() (bytes, allocs int64) {
+	if m.Channel.AllocsPerOp <= m.Optimized.AllocsPerOp {
+		return 0, 0
+	}
+	return max(m.Channel.BytesPerOp-m.Optimized.BytesPerOp, 0),
+		m.Channel.AllocsPerOp - m.Optimized.AllocsPerOp
+}
+
+// NsSavings returns the time per operation saved by the replacement, or
+// zero when the replacement is not faster.
+func (m CostModel) NsSavings() float64 {
+	return max(m.Channel.NsPerOp-m.Optimized.NsPerOp, 0)
+}
+
+// copyBytesPerNs is the assumed memmove throughput for channel elements.
+const copyBytesPerNs = 10
+
+// CopyNs estimates the time one channel send spends copying an element of
+// n bytes, on top of the Costs measured with word-sized elements.
+func CopyNs(n int64) float64 {
+	return float64(n) / copyBytesPerNs
+}
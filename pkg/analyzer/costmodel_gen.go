@@ -0,0 +1,82 @@
+// Code generated by scripts/costgen from demos/bench.txt; DO NOT EDIT.
+
+package analyzer
+
+// Costs holds the measured cost model for each pattern.
+var Costs = map[Pattern]CostModel{
+	AggregatorQueue: {
+		Channel:   Cost{NsPerOp: 50.37, BytesPerOp: 0, AllocsPerOp: 0},
+		Optimized: Cost{NsPerOp: 19.35, BytesPerOp: 0, AllocsPerOp: 0},
+	},
+	BoundedIterator: {
+		Channel:    Cost{NsPerOp: 8508, BytesPerOp: 688, AllocsPerOp: 2},
+		Unbuffered: Cost{NsPerOp: 35882, BytesPerOp: 160, AllocsPerOp: 2},
+		Optimized:  Cost{NsPerOp: 60.94, BytesPerOp: 0, AllocsPerOp: 0},
+	},
+	ChanSemaphore: {
+		Channel:   Cost{NsPerOp: 56.99, BytesPerOp: 0, AllocsPerOp: 0},
+		Optimized: Cost{NsPerOp: 32.43, BytesPerOp: 0, AllocsPerOp: 0},
+	},
+	ChanTicker: {
+		Channel:   Cost{NsPerOp: 353.6, BytesPerOp: 0, AllocsPerOp: 0},
+		Optimized: Cost{NsPerOp: 132.4, BytesPerOp: 0, AllocsPerOp: 0},
+	},
+	CircuitBreaker: {
+		Channel:   Cost{NsPerOp: 60.84, BytesPerOp: 0, AllocsPerOp: 0},
+		Optimized: Cost{NsPerOp: 0.6295, BytesPerOp: 0, AllocsPerOp: 0},
+	},
+	ConfigBroadcaster: {
+		Channel:   Cost{NsPerOp: 63.42, BytesPerOp: 0, AllocsPerOp: 0},
+		Optimized: Cost{NsPerOp: 1.021, BytesPerOp: 0, AllocsPerOp: 0},
+	},
+	ErrorIterator: {
+		Channel:   Cost{NsPerOp: 24644, BytesPerOp: 160, AllocsPerOp: 2},
+		Optimized: Cost{NsPerOp: 115.6, BytesPerOp: 0, AllocsPerOp: 0},
+	},
+	FanOutDispatcher: {
+		Channel:   Cost{NsPerOp: 253883, BytesPerOp: 451, AllocsPerOp: 6},
+		Optimized: Cost{NsPerOp: 2632, BytesPerOp: 336, AllocsPerOp: 5},
+	},
+	FixedFanIn: {
+		Channel:   Cost{NsPerOp: 1156, BytesPerOp: 144, AllocsPerOp: 3},
+		Optimized: Cost{NsPerOp: 1177, BytesPerOp: 128, AllocsPerOp: 4},
+	},
+	IDGenerator: {
+		Channel:    Cost{NsPerOp: 52.93, BytesPerOp: 0, AllocsPerOp: 0},
+		Unbuffered: Cost{NsPerOp: 219.7, BytesPerOp: 0, AllocsPerOp: 0},
+		Optimized:  Cost{NsPerOp: 7.206, BytesPerOp: 0, AllocsPerOp: 0},
+	},
+	LazyInit: {
+		Channel:   Cost{NsPerOp: 59.85, BytesPerOp: 0, AllocsPerOp: 0},
+		Optimized: Cost{NsPerOp: 3.753, BytesPerOp: 0, AllocsPerOp: 0},
+	},
+	MemoCache: {
+		Channel:   Cost{NsPerOp: 465.8, BytesPerOp: 0, AllocsPerOp: 0},
+		Optimized: Cost{NsPerOp: 31.35, BytesPerOp: 0, AllocsPerOp: 0},
+	},
+	PingPong: {
+		Channel:   Cost{NsPerOp: 633.5, BytesPerOp: 0, AllocsPerOp: 0},
+		Optimized: Cost{NsPerOp: 21.2, BytesPerOp: 0, AllocsPerOp: 0},
+	},
+	RateLimiter: {
+		Channel:   Cost{NsPerOp: 57.5, BytesPerOp: 0, AllocsPerOp: 0},
+		Optimized: Cost{NsPerOp: 17.38, BytesPerOp: 0, AllocsPerOp: 0},
+	},
+	ResultCollector: {
+		Channel:   Cost{NsPerOp: 11332, BytesPerOp: 624, AllocsPerOp: 17},
+		Optimized: Cost{NsPerOp: 4911, BytesPerOp: 1168, AllocsPerOp: 18},
+	},
+	RoundRobin: {
+		Channel:    Cost{NsPerOp: 55.81, BytesPerOp: 0, AllocsPerOp: 0},
+		Unbuffered: Cost{NsPerOp: 247.3, BytesPerOp: 0, AllocsPerOp: 0},
+		Optimized:  Cost{NsPerOp: 19.78, BytesPerOp: 0, AllocsPerOp: 0},
+	},
+	ShardedDispatcher: {
+		Channel:   Cost{NsPerOp: 103.7, BytesPerOp: 0, AllocsPerOp: 0},
+		Optimized: Cost{NsPerOp: 25.83, BytesPerOp: 0, AllocsPerOp: 0},
+	},
+	Singleton: {
+		Channel:   Cost{NsPerOp: 56.05, BytesPerOp: 0, AllocsPerOp: 0},
+		Optimized: Cost{NsPerOp: 0.6206, BytesPerOp: 0, AllocsPerOp: 0},
+	},
+}
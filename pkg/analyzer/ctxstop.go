@@ -0,0 +1,175 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+// contextStops finds loops that select on a ticker and on a stop channel
+// of struct{} in a function that has a context.Context parameter, or is
+// nested in one:
+//
+//	func (p *Poller) Run(ctx context.Context) {
+//	    t := time.NewTicker(p.every)
+//	    defer t.Stop()
+//	    for {
+//	        select {
+//	        case <-p.stop:
+//	            return
+//	        case <-t.C:
+//	            p.poll(ctx)
+//	        }
+//	    }
+//	}
+//
+// Two shutdown signals mean two ways to get shutdown wrong: callers must
+// both cancel ctx and close p.stop, and a loop that waits on only one of
+// them outlives the other. Selecting on ctx.Done(), and cancelling the
+// context where the channel was closed, leaves one. A stop channel that
+// is ctx.Done() under another name is not reported.
+func contextStops(pass *analysis.Pass) []analysis.Diagnostic {
+	insp := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	var diags []analysis.Diagnostic
+	insp.WithStack([]ast.Node{(*ast.SelectStmt)(nil)}, func(n ast.Node, push bool, stack []ast.Node) bool {
+		if !push || len(stack) < 3 {
+			return true
+		}
+		switch stack[len(stack)-3].(type) {
+		case *ast.ForStmt, *ast.RangeStmt:
+		default:
+			return true
+		}
+		if ctx := contextInScope(pass, stack); ctx != nil {
+			if d, ok := contextStop(pass, n.(*ast.SelectStmt), ctx); ok {
+				diags = append(diags, d)
+			}
+		}
+		return true
+	})
+	return diags
+}
+
+// contextInScope returns the innermost context.Context parameter of the
+// functions on stack, or nil.
+func contextInScope(pass *analysis.Pass, stack []ast.Node) types.Object {
+	for i := len(stack) - 1; i >= 0; i-- {
+		var ftype *ast.FuncType
+		switch n := stack[i].(type) {
+		case *ast.FuncDecl:
+			ftype = n.Type
+		case *ast.FuncLit:
+			ftype = n.Type
+		default:
+			continue
+		}
+		for _, field := range ftype.Params.List {
+			for _, name := range field.Names {
+				if obj := pass.TypesInfo.Defs[name]; obj != nil && name.Name != "_" && isNamed(obj.Type(), "context", "Context") {
+					return obj
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// contextStop returns the diagnostic for sel, the select of a loop, if it
+// has a ticker case and a stop channel case.
+func contextStop(pass *analysis.Pass, sel *ast.SelectStmt, ctx types.Object) (analysis.Diagnostic, bool) {
+	var stop *ast.CommClause
+	var stopName string
+	ticker, onCtx := false, false
+	for _, s := range sel.Body.List {
+		cc := s.(*ast.CommClause)
+		x := recvOperand(cc.Comm)
+		if x == nil {
+			continue
+		}
+		t := pass.TypesInfo.TypeOf(x)
+		if t == nil {
+			continue
+		}
+		ch, ok := t.Underlying().(*types.Chan)
+		if !ok {
+			continue
+		}
+		call, isCall := x.(*ast.CallExpr)
+		switch {
+		case isNamed(ch.Elem(), "time", "Time"):
+			ticker = true // t.C, a time.Tick channel or time.After(d)
+		case isCall:
+			onCtx = onCtx || isCtxDone(pass, call)
+		case isSignal(ch) && stop == nil:
+			id := rootIdent(x)
+			if id == nil || pass.TypesInfo.Uses[id] == nil || aliasesCtxDone(pass, pass.TypesInfo.Uses[id]) {
+				continue
+			}
+			stop, stopName = cc, types.ExprString(x)
+		}
+	}
+	if stop == nil || !ticker {
+		return analysis.Diagnostic{}, false
+	}
+	msg := "chanopt: ticker loop stops on " + stopName + " although " + ctx.Name() + " is in scope" +
+		" — select on " + ctx.Name() + ".Done() and cancel the context where " + stopName + " is closed"
+	if onCtx {
+		msg = "chanopt: ticker loop stops on both " + ctx.Name() + ".Done() and " + stopName +
+			" — drop " + stopName + " and cancel the context where it is closed, so there is one shutdown signal"
+	}
+	return analysis.Diagnostic{Pos: stop.Pos(), Category: "ContextStop", Message: msg}, true
+}
+
+// recvOperand returns the channel operand of a receive communication
+// clause, `case <-x:` or `case v := <-x:`, or nil.
+func recvOperand(comm ast.Stmt) ast.Expr {
+	var e ast.Expr
+	switch s := comm.(type) {
+	case *ast.ExprStmt:
+		e = s.X
+	case *ast.AssignStmt:
+		if len(s.Rhs) == 1 {
+			e = s.Rhs[0]
+		}
+	}
+	if u, ok := ast.Unparen(e).(*ast.UnaryExpr); ok && u.Op == token.ARROW {
+		return ast.Unparen(u.X)
+	}
+	return nil
+}
+
+// isCtxDone reports whether call is the Done method of a context.Context.
+func isCtxDone(pass *analysis.Pass, call *ast.CallExpr) bool {
+	sel, ok := ast.Unparen(call.Fun).(*ast.SelectorExpr)
+	return ok && sel.Sel.Name == "Done" && isNamed(pass.TypesInfo.TypeOf(sel.X), "context", "Context")
+}
+
+// aliasesCtxDone reports whether the variable v is assigned the Done
+// channel of a context, as in `stop := ctx.Done()`.
+func aliasesCtxDone(pass *analysis.Pass, v types.Object) bool {
+	found := false
+	for _, file := range pass.Files {
+		if file.Pos() > v.Pos() || v.Pos() >= file.End() {
+			continue
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			as, ok := n.(*ast.AssignStmt)
+			if !ok || len(as.Lhs) != len(as.Rhs) {
+				return true
+			}
+			for i, lhs := range as.Lhs {
+				if id, ok := lhs.(*ast.Ident); ok && pass.TypesInfo.ObjectOf(id) == v {
+					call, ok := ast.Unparen(as.Rhs[i]).(*ast.CallExpr)
+					found = ok && isCtxDone(pass, call)
+					return !found
+				}
+			}
+			return true
+		})
+	}
+	return found
+}
@@ -0,0 +1,135 @@
+package analyzer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+var (
+	verbose     bool          // enables debugf
+	debugTiming bool          // enables timer
+	budget      time.Duration // see deadline
+)
+
+func init() {
+	Analyzer.Flags.BoolVar(&verbose, "v", false,
+		"print analysis details, such as time budget downgrades and cache hit rates, to standard error")
+	Analyzer.Flags.BoolVar(&debugTiming, "debug-timing", false,
+		"print how long each package took to analyze, split into phases (detect, classify, gates, fixes, ...), to standard error")
+	Analyzer.Flags.DurationVar(&budget, "budget", 10*time.Second,
+		"time `limit` per package for the package-wide analyses before falling back to per-function heuristics; 0 means none")
+}
+
+var (
+	debugMu  sync.Mutex
+	debugOut io.Writer = os.Stderr // replaced by tests
+)
+
+// debugf prints a line of -v output about the package of pass.
+func debugf(pass *analysis.Pass, format string, args ...any) {
+	if !verbose {
+		return
+	}
+	debugLine(pass, fmt.Sprintf(format, args...))
+}
+
+func debugLine(pass *analysis.Pass, msg string) {
+	debugMu.Lock()
+	defer debugMu.Unlock()
+	fmt.Fprintf(debugOut, "chanopt: %s: %s\n", pass.Pkg.Path(), msg)
+}
+
+// phases are the parts of run that -debug-timing reports, in order:
+// finding generators, classifying them, the checks that lower confidence,
+// add notes or skip a finding, building suggested fixes, the package-wide
+// detectors and checks, counting call sites, and sorting, filtering and
+// emitting the diagnostics.
+var phases = []string{"detect", "classify", "gates", "fixes", "checks", "call sites", "report"}
+
+// timer splits the time run spends on one package into phases, for
+// -debug-timing. Time is charged to the phase entered last. A nil timer
+// measures nothing.
+type timer struct {
+	pass  *analysis.Pass
+	start time.Time
+	phase string
+	lap   time.Time
+	spent map[string]time.Duration
+}
+
+func newTimer(pass *analysis.Pass) *timer {
+	if !debugTiming {
+		return nil
+	}
+	now := time.Now()
+	return &timer{pass: pass, start: now, lap: now, spent: map[string]time.Duration{}}
+}
+
+// enter charges the time since the last call to the current phase and
+// starts phase.
+func (t *timer) enter(phase string) {
+	if t == nil {
+		return
+	}
+	now := time.Now()
+	if t.phase != "" {
+		t.spent[t.phase] += now.Sub(t.lap)
+	}
+	t.phase, t.lap = phase, now
+}
+
+// done ends the current phase and prints the package's total and the
+// phases it went through:
+//
+//	chanopt: example.com/app: analyzed in 12.4ms (detect 1.1ms, classify 3.2ms, ...)
+func (t *timer) done() {
+	if t == nil {
+		return
+	}
+	t.enter("")
+	var parts []string
+	for _, p := range phases {
+		if d, ok := t.spent[p]; ok {
+			parts = append(parts, p+" "+d.Round(time.Microsecond).String())
+		}
+	}
+	debugLine(t.pass, fmt.Sprintf("analyzed in %v (%s)",
+		time.Since(t.start).Round(time.Microsecond), strings.Join(parts, ", ")))
+}
+
+// deadline bounds the time one package spends in the analyses that look
+// across the whole package: resolving goroutine helpers in other files,
+// lazy-init accessors, pipeline stages and call sites. Huge generated
+// packages can make these slow; once the budget is spent they are skipped
+// and only the per-function heuristics run. A nil deadline never expires.
+type deadline struct {
+	pass    *analysis.Pass
+	at      time.Time
+	skipped map[string]bool
+}
+
+func newDeadline(pass *analysis.Pass) *deadline {
+	if budget <= 0 {
+		return nil
+	}
+	return &deadline{pass: pass, at: time.Now().Add(budget), skipped: map[string]bool{}}
+}
+
+// exceeded reports whether the budget is spent, in which case the caller
+// skips what. Each skipped analysis is noted once in -v output.
+func (d *deadline) exceeded(what string) bool {
+	if d == nil || time.Now().Before(d.at) {
+		return false
+	}
+	if !d.skipped[what] {
+		d.skipped[what] = true
+		debugf(d.pass, "analysis time budget of %v exceeded; skipping %s", budget, what)
+	}
+	return true
+}
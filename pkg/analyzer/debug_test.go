@@ -0,0 +1,40 @@
+package analyzer
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+)
+
+func TestTimer(t *testing.T) {
+	pass, err := typecheckGen(cacheSrc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	oldOut, oldTiming := debugOut, debugTiming
+	debugOut, debugTiming = &buf, true
+	defer func() { debugOut, debugTiming = oldOut, oldTiming }()
+
+	tm := newTimer(pass)
+	tm.enter("detect")
+	tm.enter("gates")
+	tm.enter("classify")
+	tm.enter("gates")
+	tm.enter("report")
+	tm.done()
+
+	// Phases print in their fixed order, once each, and only if entered.
+	want := regexp.MustCompile(`^chanopt: p: analyzed in \S+ \(detect \S+, classify \S+, gates \S+, report \S+\)\n$`)
+	if !want.MatchString(buf.String()) {
+		t.Errorf("got %q, want a match for %s", buf.String(), want)
+	}
+
+	debugTiming = false
+	if newTimer(pass) != nil {
+		t.Error("newTimer returned a timer without -debug-timing")
+	}
+	var nilTimer *timer
+	nilTimer.enter("detect")
+	nilTimer.done()
+}
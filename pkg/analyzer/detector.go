@@ -12,81 +12,249 @@ import (
 // created channel which is then returned.
 type channelProducer struct {
 	sends     []*ast.SendStmt
-	funcLit   *ast.FuncLit
+	file      *ast.File
+	funcDecl  *ast.FuncDecl
+	funcLit   *ast.FuncLit // the goroutine body; synthesized for a helper
+	helper    *ast.FuncDecl
 	chanIdent *ast.Ident
+	chanName  string // the channel's name in funcLit: a helper's parameter
 	chanType  *types.Chan
 	makePos   token.Pos
 	bufSize   int
+	stop      *stopFunc // non-nil if the function also returns a stop func
+	literal   bool      // funcDecl is synthesized for a function literal
 }
 
-// detect scans a file for the generator idiom:
+// detect scans the package for the generator idiom:
 //
 //	func F() <-chan T {
 //	    ch := make(chan T [, N])
 //	    go func() { ... ch <- v ... }()
 //	    return ch
 //	}
-func detect(pass *analysis.Pass, file *ast.File) []channelProducer {
+//
+// The goroutine may also run a helper function of the package, from any of
+// its files, that is passed the channel: go fill(ch, items). Helpers are
+// no longer resolved once the deadline expires.
+//
+// Function literals that return a channel are generators too, wherever
+// they appear: `var NewIDs = func() <-chan int64 { ... }`, a struct's
+// function-typed field set to one, or an argument. Each is matched as a
+// function named after the variable or field it is assigned to.
+func detect(pass *analysis.Pass, d *deadline) []channelProducer {
+	funcs := funcIndex(pass)
 	var results []channelProducer
 
-	for _, decl := range file.Decls {
-		fn, ok := decl.(*ast.FuncDecl)
-		if !ok || fn.Body == nil || fn.Type.Results == nil {
-			continue
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil || fn.Type.Results == nil {
+				continue
+			}
+			if funcs != nil && d.exceeded("goroutine helper resolution") {
+				funcs = nil
+			}
+			if !returnsChan(fn.Type.Results) {
+				continue
+			}
+			if cp, ok := detectProducer(pass, fn, funcs); ok {
+				cp.file = file
+				results = append(results, cp)
+			}
 		}
-		if !returnsChan(fn.Type.Results) {
-			continue
+		for _, fn := range funcLitRoots(file) {
+			if cp, ok := detectProducer(pass, fn, funcs); ok {
+				cp.file = file
+				cp.literal = true
+				results = append(results, cp)
+			}
 		}
+	}
 
-		var chanVar *ast.Ident
-		var makePos token.Pos
-		var bufSize int
-		var goStmts []*ast.GoStmt
-
-		for _, stmt := range fn.Body.List {
-			switch s := stmt.(type) {
-			case *ast.AssignStmt:
-				if id, pos, buf, found := extractMakeChan(s); found {
-					chanVar = id
-					makePos = pos
-					bufSize = buf
+	return results
+}
+
+// funcLitRoots returns a declaration for each function literal in file
+// that returns a channel, named after what it is assigned to: a variable,
+// as in `NewIDs`, a field, as in `s.Next` or `Next` in a composite
+// literal, or else "func literal".
+func funcLitRoots(file *ast.File) []*ast.FuncDecl {
+	names := map[*ast.FuncLit]string{}
+	name := func(lhs, rhs ast.Expr) {
+		if lit, ok := ast.Unparen(rhs).(*ast.FuncLit); ok {
+			names[lit] = types.ExprString(lhs)
+		}
+	}
+	var lits []*ast.FuncLit
+	ast.Inspect(file, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.ValueSpec:
+			if len(n.Names) == len(n.Values) {
+				for i, id := range n.Names {
+					name(id, n.Values[i])
 				}
-			case *ast.GoStmt:
-				goStmts = append(goStmts, s)
+			}
+		case *ast.AssignStmt:
+			if len(n.Lhs) == len(n.Rhs) {
+				for i, lhs := range n.Lhs {
+					name(lhs, n.Rhs[i])
+				}
+			}
+		case *ast.KeyValueExpr:
+			name(n.Key, n.Value)
+		case *ast.FuncLit:
+			if n.Type.Results != nil && returnsChan(n.Type.Results) {
+				lits = append(lits, n)
 			}
 		}
-
-		// Must have exactly one channel and one goroutine.
-		if chanVar == nil || len(goStmts) != 1 {
-			continue
+		return true
+	})
+	var decls []*ast.FuncDecl
+	for _, lit := range lits {
+		n := names[lit]
+		if n == "" {
+			n = "func literal"
 		}
+		decls = append(decls, &ast.FuncDecl{
+			Name: &ast.Ident{NamePos: lit.Pos(), Name: n},
+			Type: lit.Type,
+			Body: lit.Body,
+		})
+	}
+	return decls
+}
 
-		funcLit, ok := goStmts[0].Call.Fun.(*ast.FuncLit)
-		if !ok {
-			continue
+// detectProducer matches the generator idiom in fn.
+func detectProducer(pass *analysis.Pass, fn *ast.FuncDecl, funcs map[*types.Func]*ast.FuncDecl) (channelProducer, bool) {
+	var chanVar *ast.Ident
+	var makePos token.Pos
+	var bufSize int
+	var goStmts []*ast.GoStmt
+
+	for _, stmt := range fn.Body.List {
+		switch s := stmt.(type) {
+		case *ast.AssignStmt:
+			// With several channels, such as a done channel next to
+			// the generator's, keep the one that is returned; of a
+			// value and an error channel, the value channel.
+			if id, pos, buf, found := extractMakeChan(s); found && (chanVar == nil || returned(fn, id.Name) &&
+				!(returned(fn, chanVar.Name) && isErrorChan(pass.TypesInfo.TypeOf(id)))) {
+				chanVar = id
+				makePos = pos
+				bufSize = buf
+			}
+		case *ast.GoStmt:
+			goStmts = append(goStmts, s)
 		}
+	}
 
-		sends := collectSends(funcLit, chanVar.Name)
-		if len(sends) == 0 {
-			continue
+	// Must have exactly one channel and one goroutine.
+	if chanVar == nil || len(goStmts) != 1 {
+		return channelProducer{}, false
+	}
+
+	funcLit, ok := goStmts[0].Call.Fun.(*ast.FuncLit)
+	chanName := chanVar.Name
+	var helper *ast.FuncDecl
+	if !ok {
+		helper, chanName = helperCall(pass, goStmts[0].Call, chanVar, funcs)
+		if helper == nil {
+			return channelProducer{}, false
 		}
+		funcLit = &ast.FuncLit{Type: helper.Type, Body: helper.Body}
+	}
+
+	sends := collectSends(funcLit, chanName)
+	if len(sends) == 0 {
+		return channelProducer{}, false
+	}
+
+	var ct *types.Chan
+	if obj := pass.TypesInfo.ObjectOf(chanVar); obj != nil {
+		ct, _ = obj.Type().(*types.Chan)
+	}
 
-		var ct *types.Chan
-		if obj := pass.TypesInfo.ObjectOf(chanVar); obj != nil {
-			ct, _ = obj.Type().(*types.Chan)
+	return channelProducer{
+		funcDecl:  fn,
+		funcLit:   funcLit,
+		helper:    helper,
+		chanIdent: chanVar,
+		chanName:  chanName,
+		chanType:  ct,
+		makePos:   makePos,
+		sends:     sends,
+		bufSize:   bufSize,
+		stop:      returnedStopFunc(pass, fn, funcLit),
+	}, true
+}
+
+// funcIndex maps the package-level functions of the package to their
+// declarations, across all files.
+func funcIndex(pass *analysis.Pass) map[*types.Func]*ast.FuncDecl {
+	funcs := map[*types.Func]*ast.FuncDecl{}
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Body != nil {
+				if obj, ok := pass.TypesInfo.Defs[fn.Name].(*types.Func); ok {
+					funcs[obj] = fn
+				}
+			}
 		}
+	}
+	return funcs
+}
 
-		results = append(results, channelProducer{
-			funcLit:   funcLit,
-			chanIdent: chanVar,
-			chanType:  ct,
-			makePos:   makePos,
-			sends:     sends,
-			bufSize:   bufSize,
-		})
+// helperCall resolves `go helper(..., ch, ...)` to the declaration of
+// helper and the name of the parameter receiving ch. The channel must be
+// passed once, to a non-variadic parameter.
+func helperCall(pass *analysis.Pass, call *ast.CallExpr, ch *ast.Ident, funcs map[*types.Func]*ast.FuncDecl) (*ast.FuncDecl, string) {
+	id, ok := ast.Unparen(call.Fun).(*ast.Ident)
+	if !ok {
+		return nil, ""
+	}
+	obj, ok := pass.TypesInfo.Uses[id].(*types.Func)
+	if !ok {
+		return nil, ""
+	}
+	helper := funcs[obj]
+	if helper == nil || obj.Type().(*types.Signature).Variadic() {
+		return nil, ""
+	}
+	var params []*ast.Ident
+	for _, field := range helper.Type.Params.List {
+		params = append(params, field.Names...)
+	}
+	if len(params) != len(call.Args) {
+		return nil, ""
+	}
+	name := ""
+	for i, arg := range call.Args {
+		if refersTo(pass, arg, pass.TypesInfo.ObjectOf(ch)) {
+			if name != "" || !isObj(pass, arg, pass.TypesInfo.ObjectOf(ch)) || params[i].Name == "_" {
+				return nil, ""
+			}
+			name = params[i].Name
+		}
+	}
+	if name == "" {
+		return nil, ""
 	}
+	return helper, name
+}
 
-	return results
+// returned reports whether the last statement of fn returns the variable
+// name as one of its results.
+func returned(fn *ast.FuncDecl, name string) bool {
+	ret, ok := fn.Body.List[len(fn.Body.List)-1].(*ast.ReturnStmt)
+	if !ok {
+		return false
+	}
+	for _, r := range ret.Results {
+		if id, ok := r.(*ast.Ident); ok && id.Name == name {
+			return true
+		}
+	}
+	return false
 }
 
 // returnsChan checks if any return value is a channel type.
@@ -108,19 +276,29 @@ func extractMakeChan(s *ast.AssignStmt) (*ast.Ident, token.Pos, int, bool) {
 	if !ok {
 		return nil, 0, 0, false
 	}
-	call, ok := s.Rhs[0].(*ast.CallExpr)
+	buf, ok := makeChan(s.Rhs[0])
 	if !ok {
 		return nil, 0, 0, false
 	}
+	return id, s.Pos(), buf, true
+}
+
+// makeChan reports whether e is `make(chan T [, N])` and returns N, or 0
+// when the size is absent or not an integer literal.
+func makeChan(e ast.Expr) (int, bool) {
+	call, ok := e.(*ast.CallExpr)
+	if !ok {
+		return 0, false
+	}
 	fn, ok := call.Fun.(*ast.Ident)
 	if !ok || fn.Name != "make" {
-		return nil, 0, 0, false
+		return 0, false
 	}
 	if len(call.Args) < 1 {
-		return nil, 0, 0, false
+		return 0, false
 	}
 	if _, ok := call.Args[0].(*ast.ChanType); !ok {
-		return nil, 0, 0, false
+		return 0, false
 	}
 	buf := 0
 	if len(call.Args) >= 2 {
@@ -130,7 +308,7 @@ func extractMakeChan(s *ast.AssignStmt) (*ast.Ident, token.Pos, int, bool) {
 			}
 		}
 	}
-	return id, s.Pos(), buf, true
+	return buf, true
 }
 
 // collectSends finds all `ch <- expr` statements inside a function literal.
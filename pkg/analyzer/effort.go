@@ -0,0 +1,120 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"reflect"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// Result is the value the analyzer returns for a package, for drivers that
+// want more than diagnostics.
+type Result struct {
+	// Efforts holds the remediation estimate of each finding, keyed by
+	// the position of its diagnostic.
+	Efforts map[token.Pos]Effort
+
+	// Producers names the function of each producer finding, keyed like
+	// Efforts, as given by types.Func.FullName.
+	Producers map[token.Pos]string
+
+	// Calls holds the positions of the calls in this package of every
+	// flagged producer, including those of imported packages, keyed by
+	// FullName. Merging Calls over all analyzed packages gives a
+	// producer's call sites.
+	Calls map[string][]token.Pos
+
+	// CopyBytes holds the element size of producer findings whose
+	// channel copies a large value on every send, keyed like Efforts.
+	CopyBytes map[token.Pos]int64
+
+	// Buffers holds the buffer size of the channel of producer findings,
+	// keyed like Efforts: 0 for an unbuffered channel, -1 for a size that
+	// is not a constant.
+	Buffers map[token.Pos]int64
+}
+
+var resultType = reflect.TypeOf((*Result)(nil))
+
+// Effort estimates the work of replacing a finding by hand.
+type Effort struct {
+	Lines           int  // lines in the function to rewrite
+	CallSites       int  // calls of the producer within its package, or all analyzed packages when a driver sums Result.Calls
+	SignatureChange bool // the replacement changes the producer's result type
+	Autofix         bool // a suggested fix is available
+}
+
+// Score is a unitless effort figure for value/effort ranking: a base cost,
+// one unit per ten lines, one per call site to update when the signature
+// changes, halved when an autofix does the work.
+func (e Effort) Score() float64 {
+	s := 1 + float64(e.Lines)/10
+	if e.SignatureChange {
+		s += float64(e.CallSites)
+	}
+	if e.Autofix {
+		s /= 2
+	}
+	return s
+}
+
+// producerEffort estimates the effort of replacing the generator fn. Its
+// CallSites are filled in once the package's calls have been counted.
+func producerEffort(pass *analysis.Pass, fn *ast.FuncDecl, fixes []analysis.SuggestedFix) Effort {
+	return Effort{
+		Lines:           lineSpan(pass.Fset, fn),
+		SignatureChange: true,
+		Autofix:         len(fixes) > 0,
+	}
+}
+
+// localEffort estimates the effort of a finding confined to the function
+// enclosing pos, whose signature does not change.
+func localEffort(pass *analysis.Pass, file *ast.File, pos token.Pos) Effort {
+	for _, decl := range file.Decls {
+		if decl.Pos() <= pos && pos < decl.End() {
+			return Effort{Lines: lineSpan(pass.Fset, decl)}
+		}
+	}
+	return Effort{}
+}
+
+func lineSpan(fset *token.FileSet, n ast.Node) int {
+	return fset.Position(n.End()).Line - fset.Position(n.Pos()).Line + 1
+}
+
+// producerFact marks a function flagged as a channel producer, so that
+// packages calling it can count their call sites.
+type producerFact struct {
+	Pattern Pattern
+}
+
+func (*producerFact) AFact() {}
+
+func (f *producerFact) String() string { return "producer(" + f.Pattern.String() + ")" }
+
+// findCalls finds, per producer, the calls in this package of functions
+// flagged here (local) or in a dependency (through producerFact).
+func findCalls(pass *analysis.Pass, local map[types.Object]producer) map[string][]token.Pos {
+	calls := map[string][]token.Pos{}
+	for _, file := range pass.Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			fn := typeutil.StaticCallee(pass.TypesInfo, call)
+			if fn == nil {
+				return true
+			}
+			if _, ok := local[fn]; ok || pass.ImportObjectFact(fn, new(producerFact)) {
+				calls[fn.FullName()] = append(calls[fn.FullName()], call.Pos())
+			}
+			return true
+		})
+	}
+	return calls
+}
@@ -0,0 +1,35 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// largeElem is the element size, in bytes, from which the copy made by
+// each channel send is worth mentioning. The benchmarks behind Costs send
+// word-sized values, so they do not include it.
+const largeElem = 128
+
+// elemCopy returns the size of cp's channel element type if it is at
+// least largeElem bytes, or 0.
+func elemCopy(pass *analysis.Pass, cp channelProducer) int64 {
+	if cp.chanType == nil {
+		return 0
+	}
+	sizes := pass.TypesSizes
+	if sizes == nil {
+		sizes = types.SizesFor("gc", "amd64")
+	}
+	if n := sizes.Sizeof(cp.chanType.Elem()); n >= largeElem {
+		return n
+	}
+	return 0
+}
+
+// copyNote describes the per-send copy of n bytes of elem.
+func copyNote(pass *analysis.Pass, n int64, elem types.Type) string {
+	return fmt.Sprintf("each send copies %d B of %s, which the replacement avoids (if the channel stays, send *%[2]s)",
+		n, types.TypeString(elem, types.RelativeTo(pass.Pkg)))
+}
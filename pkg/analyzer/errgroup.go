@@ -0,0 +1,222 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// reportErrGroups flags a sync.WaitGroup paired with a buffered error
+// channel that keeps the goroutines' errors until Wait returns:
+//
+//	var wg sync.WaitGroup
+//	errCh := make(chan error, 1)
+//	for _, it := range items {
+//	    wg.Add(1)
+//	    go func() {
+//	        defer wg.Done()
+//	        if err := work(it); err != nil {
+//	            select {
+//	            case errCh <- err:
+//	            default:
+//	            }
+//	        }
+//	    }()
+//	}
+//	wg.Wait()
+//	close(errCh)
+//	return <-errCh
+//
+// errgroup.Group does the same bookkeeping: g.Go runs the goroutine and
+// g.Wait returns the first error, and errgroup.WithContext also cancels
+// the others. The channel must be sent on only from goroutines that call
+// Done on the WaitGroup, and only received from or closed after Wait.
+func reportErrGroups(pass *analysis.Pass) {
+	for _, file := range pass.Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			block, ok := n.(*ast.BlockStmt)
+			if !ok {
+				return true
+			}
+			for i, stmt := range block.List {
+				as, ok := stmt.(*ast.AssignStmt)
+				if !ok || as.Tok != token.DEFINE {
+					continue
+				}
+				id, pos, _, ok := extractMakeChan(as)
+				if !ok {
+					continue
+				}
+				args := as.Rhs[0].(*ast.CallExpr).Args
+				if len(args) != 2 {
+					continue // unbuffered: senders block until after Wait
+				}
+				if n, ok := constInt(pass, args[1]); ok && n == 0 {
+					continue
+				}
+				ch := pass.TypesInfo.Defs[id]
+				if ch == nil || !isErrorChan(ch.Type()) {
+					continue
+				}
+				if wg := collectsErrors(pass, ch, block.List[i+1:]); wg != nil {
+					pass.Report(analysis.Diagnostic{
+						Pos:      pos,
+						Category: "ErrGroup",
+						Message: "chanopt: " + wg.Name() + " and " + ch.Name() + " reimplement errgroup — use golang.org/x/sync/errgroup:" +
+							" g.Go starts each goroutine and g.Wait returns the first error (errgroup.WithContext also cancels the rest)",
+					})
+				}
+			}
+			return true
+		})
+	}
+}
+
+// isErrorChan reports whether t is chan error.
+func isErrorChan(t types.Type) bool {
+	if t == nil {
+		return false
+	}
+	c, ok := t.Underlying().(*types.Chan)
+	return ok && c.Dir() == types.SendRecv && types.Identical(c.Elem(), types.Universe.Lookup("error").Type())
+}
+
+// collectsErrors returns the WaitGroup that stmts wait on before reading
+// ch, if ch is sent on only in goroutines that call Done on it, and used
+// after the Wait only to receive or close. It returns nil otherwise.
+func collectsErrors(pass *analysis.Pass, ch types.Object, stmts []ast.Stmt) types.Object {
+	wait := -1
+	var wg types.Object
+	for i, stmt := range stmts {
+		if obj := waitGroupCall(pass, stmt, "Wait"); obj != nil {
+			wait, wg = i, obj
+			break
+		}
+	}
+	if wg == nil {
+		return nil
+	}
+
+	uses, sends := 0, 0
+	for _, stmt := range stmts[:wait] {
+		uses += countUses(pass, stmt, ch)
+		ast.Inspect(stmt, func(n ast.Node) bool {
+			var lit *ast.FuncLit
+			switch n := n.(type) {
+			case *ast.GoStmt:
+				if l, ok := n.Call.Fun.(*ast.FuncLit); ok && callsDone(pass, l.Body, wg) {
+					lit = l
+				}
+			case *ast.CallExpr:
+				// wg.Go(func() { ... }), since Go 1.25.
+				if waitGroupMethod(pass, n, "Go") == wg && len(n.Args) == 1 {
+					lit, _ = n.Args[0].(*ast.FuncLit)
+				}
+			}
+			if lit == nil {
+				return true
+			}
+			ast.Inspect(lit.Body, func(n ast.Node) bool {
+				if s, ok := n.(*ast.SendStmt); ok && isObj(pass, s.Chan, ch) && !refersTo(pass, s.Value, ch) {
+					sends++
+				}
+				return true
+			})
+			return false
+		})
+	}
+	if sends == 0 || uses != sends {
+		return nil
+	}
+
+	uses, reads := 0, 0
+	for _, stmt := range stmts[wait+1:] {
+		uses += countUses(pass, stmt, ch)
+		ast.Inspect(stmt, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.UnaryExpr:
+				if n.Op == token.ARROW && isObj(pass, n.X, ch) {
+					reads++
+				}
+			case *ast.RangeStmt:
+				if isObj(pass, n.X, ch) {
+					reads++
+				}
+			case *ast.CallExpr:
+				if isCloseOf(n, func(e ast.Expr) bool { return isObj(pass, e, ch) }) {
+					reads++
+				}
+			case *ast.FuncLit:
+				return false // a use here escapes the sequence
+			}
+			return true
+		})
+	}
+	if reads == 0 || uses != reads {
+		return nil
+	}
+	return wg
+}
+
+// waitGroupCall returns the sync.WaitGroup whose method name stmt calls,
+// possibly deferred, or nil.
+func waitGroupCall(pass *analysis.Pass, stmt ast.Stmt, name string) types.Object {
+	switch s := stmt.(type) {
+	case *ast.ExprStmt:
+		if call, ok := s.X.(*ast.CallExpr); ok {
+			return waitGroupMethod(pass, call, name)
+		}
+	case *ast.DeferStmt:
+		return waitGroupMethod(pass, s.Call, name)
+	}
+	return nil
+}
+
+// waitGroupMethod returns the sync.WaitGroup whose method name call calls,
+// or nil.
+func waitGroupMethod(pass *analysis.Pass, call *ast.CallExpr, name string) types.Object {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != name {
+		return nil
+	}
+	id, ok := ast.Unparen(sel.X).(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	obj := pass.TypesInfo.ObjectOf(id)
+	if obj == nil {
+		return nil
+	}
+	t := obj.Type()
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	if !isNamed(t, "sync", "WaitGroup") {
+		return nil
+	}
+	return obj
+}
+
+// callsDone reports whether body calls wg.Done as one of its statements.
+func callsDone(pass *analysis.Pass, body *ast.BlockStmt, wg types.Object) bool {
+	for _, stmt := range body.List {
+		if waitGroupCall(pass, stmt, "Done") == wg {
+			return true
+		}
+	}
+	return false
+}
+
+// countUses counts the identifiers in n that refer to obj.
+func countUses(pass *analysis.Pass, n ast.Node, obj types.Object) int {
+	uses := 0
+	ast.Inspect(n, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && pass.TypesInfo.Uses[id] == obj {
+			uses++
+		}
+		return true
+	})
+	return uses
+}
@@ -0,0 +1,172 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// errorStream is how a generator hands its consumer a value and an error
+// for each item: in one struct,
+//
+//	type Result struct {
+//	    Rec Record
+//	    Err error
+//	}
+//
+//	func Parse(lines []string) <-chan Result {
+//	    ch := make(chan Result)
+//	    go func() {
+//	        defer close(ch)
+//	        for _, l := range lines {
+//	            rec, err := parse(l)
+//	            ch <- Result{rec, err}
+//	        }
+//	    }()
+//	    return ch
+//	}
+//
+// or on a value channel and an error channel returned together, as in
+// `func Walk(root string) (<-chan string, <-chan error)`. Either way the
+// consumer ranges over a finite stream of pairs, which an iter.Seq2[T,
+// error] yields without a goroutine, and which stops the producer when
+// the consumer breaks out of its loop.
+type errorStream struct {
+	value types.Type // T, the type of the values
+
+	// For a struct, the indexes of its value and error fields.
+	valueField, errField int
+
+	// For paired channels, the error channel; nil for a struct.
+	errChan types.Object
+}
+
+// errorStreamOf reports whether cp sends (value, error) pairs, as a struct
+// of exactly those two fields, or on its channel and an error channel
+// that its function returns with it and the goroutine sends on.
+func errorStreamOf(pass *analysis.Pass, cp channelProducer) (errorStream, bool) {
+	if cp.chanType == nil {
+		return errorStream{}, false
+	}
+	if st, ok := cp.chanType.Elem().Underlying().(*types.Struct); ok && st.NumFields() == 2 {
+		for i := range 2 {
+			if isError(st.Field(i).Type()) && !isError(st.Field(1-i).Type()) {
+				return errorStream{value: st.Field(1 - i).Type(), valueField: 1 - i, errField: i}, true
+			}
+		}
+		return errorStream{}, false
+	}
+	if isError(cp.chanType.Elem()) || cp.helper != nil {
+		return errorStream{}, false
+	}
+	fn := cp.funcDecl
+	ret, ok := fn.Body.List[len(fn.Body.List)-1].(*ast.ReturnStmt)
+	if !ok || len(ret.Results) != 2 {
+		return errorStream{}, false
+	}
+	for _, r := range ret.Results {
+		id, ok := r.(*ast.Ident)
+		if !ok || id.Name == cp.chanIdent.Name {
+			continue
+		}
+		obj := pass.TypesInfo.Uses[id]
+		if obj == nil || !isErrorChan(obj.Type()) {
+			continue
+		}
+		if firstSend(cp.funcLit.Body, func(e ast.Expr) bool { return isObj(pass, e, obj) }) != nil {
+			return errorStream{value: cp.chanType.Elem(), valueField: -1, errField: -1, errChan: obj}, true
+		}
+	}
+	return errorStream{}, false
+}
+
+// isErrorStream reports whether cp sends (value, error) pairs.
+func isErrorStream(pass *analysis.Pass, cp channelProducer) bool {
+	_, ok := errorStreamOf(pass, cp)
+	return ok
+}
+
+// isError reports whether t is the error interface.
+func isError(t types.Type) bool {
+	return types.Identical(t, types.Universe.Lookup("error").Type())
+}
+
+// errIteratorFix rewrites a generator of (value, error) structs into a
+// function returning iter.Seq2[T, error] that yields the two fields of
+// each value sent. Callers change from reading the fields of what they
+// receive to ranging over both:
+//
+//	for rec, err := range Parse(lines) { ... }
+//
+// Paired channels are left to the author, since callers receive from the
+// error channel at a point of their choosing.
+func errIteratorFix(pass *analysis.Pass, file *ast.File, cp channelProducer) []analysis.SuggestedFix {
+	es, ok := errorStreamOf(pass, cp)
+	if !ok || es.errChan != nil || !fileAtLeast(pass, file, "go1.23") {
+		return nil
+	}
+	elem := cp.chanType.Elem()
+	st := elem.Underlying().(*types.Struct)
+	return yieldFix(pass, file, cp, func(src *source, im *fixImports) (yieldShape, bool) {
+		qual := func(p *types.Package) string {
+			if p == pass.Pkg {
+				return ""
+			}
+			return im.name(p.Path())
+		}
+		value := types.TypeString(es.value, qual)
+		args := func(v ast.Expr) (string, bool) {
+			fields := [2]string{zeroText(st.Field(0).Type(), qual), zeroText(st.Field(1).Type(), qual)}
+			switch v := ast.Unparen(v).(type) {
+			case *ast.Ident:
+				fields = [2]string{v.Name + "." + st.Field(0).Name(), v.Name + "." + st.Field(1).Name()}
+			case *ast.CompositeLit:
+				if !types.Identical(pass.TypesInfo.TypeOf(v), elem) {
+					return "", false
+				}
+				for i, e := range v.Elts {
+					if kv, ok := e.(*ast.KeyValueExpr); ok {
+						i = 0
+						if key, _ := kv.Key.(*ast.Ident); key == nil || key.Name != st.Field(0).Name() {
+							i = 1
+						}
+						e = kv.Value
+					}
+					fields[i] = src.text(e.Pos(), e.End())
+				}
+			default:
+				return "", false
+			}
+			return fields[es.valueField] + ", " + fields[es.errField], true
+		}
+		return yieldShape{
+			seq:    im.name("iter") + ".Seq2[" + value + ", error]",
+			params: value + ", error",
+			title:  "iter.Seq2[" + types.TypeString(es.value, types.RelativeTo(pass.Pkg)) + ", error]",
+			args:   args,
+		}, true
+	})
+}
+
+// zeroText returns the zero value of t as source, qualified by qual.
+func zeroText(t types.Type, qual types.Qualifier) string {
+	if _, ok := t.(*types.TypeParam); ok {
+		return "*new(" + types.TypeString(t, qual) + ")"
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case u.Info()&types.IsBoolean != 0:
+			return "false"
+		case u.Info()&types.IsString != 0:
+			return `""`
+		case u.Info()&types.IsNumeric != 0:
+			return "0"
+		}
+		return "nil"
+	case *types.Struct, *types.Array:
+		return types.TypeString(t, qual) + "{}"
+	}
+	return "nil"
+}
@@ -0,0 +1,190 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// maxTrivialStmts is the largest per-job body still considered trivial.
+const maxTrivialStmts = 3
+
+// purePkgs are packages whose functions are treated as pure computation
+// inside a worker body.
+var purePkgs = map[string]bool{
+	"math": true, "math/bits": true, "strconv": true,
+	"strings": true, "unicode": true, "unicode/utf8": true,
+}
+
+// fanOut is a detected fixed-size worker pool draining a jobs channel.
+type fanOut struct {
+	makePos token.Pos
+	jobs    *ast.Ident
+}
+
+// detectFanOut scans a file for the fixed worker pool idiom with trivial
+// per-job work:
+//
+//	jobs := make(chan T)
+//	for w := 0; w < N; w++ {
+//	    go func() {
+//	        for j := range jobs { out[j] = f(j) } // ≤ 3 pure statements
+//	    }()
+//	}
+//
+// N must be a constant or runtime.NumCPU()/runtime.GOMAXPROCS(...).
+func detectFanOut(pass *analysis.Pass, file *ast.File) []fanOut {
+	var results []fanOut
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+
+		// Local jobs channels created with make.
+		chans := map[types.Object]ast.Stmt{}
+		for _, stmt := range fn.Body.List {
+			if s, ok := stmt.(*ast.AssignStmt); ok {
+				if id, _, _, found := extractMakeChan(s); found {
+					if obj := pass.TypesInfo.ObjectOf(id); obj != nil {
+						chans[obj] = s
+					}
+				}
+			}
+		}
+		if len(chans) == 0 {
+			continue
+		}
+
+		reported := map[types.Object]bool{}
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			if _, ok := n.(*ast.FuncLit); ok {
+				return false
+			}
+			body := fixedCountLoopBody(pass, n)
+			if body == nil {
+				return true
+			}
+			for _, stmt := range body.List {
+				g, ok := stmt.(*ast.GoStmt)
+				if !ok {
+					continue
+				}
+				lit, ok := g.Call.Fun.(*ast.FuncLit)
+				if !ok {
+					continue
+				}
+				obj, id := trivialWorker(pass, lit, chans)
+				if obj == nil || reported[obj] {
+					continue
+				}
+				reported[obj] = true
+				results = append(results, fanOut{makePos: chans[obj].Pos(), jobs: id})
+			}
+			return true
+		})
+	}
+	return results
+}
+
+// fixedCountLoopBody returns the body of n if n is a loop whose trip count is
+// a constant or the CPU count: `for i := 0; i < N; i++` or `for range N`.
+func fixedCountLoopBody(pass *analysis.Pass, n ast.Node) *ast.BlockStmt {
+	switch loop := n.(type) {
+	case *ast.ForStmt:
+		cond, ok := loop.Cond.(*ast.BinaryExpr)
+		if ok && (cond.Op == token.LSS || cond.Op == token.LEQ) && isFixedCount(pass, cond.Y) {
+			return loop.Body
+		}
+	case *ast.RangeStmt:
+		if tv, ok := pass.TypesInfo.Types[loop.X]; ok {
+			if b, ok := tv.Type.Underlying().(*types.Basic); ok && b.Info()&types.IsInteger != 0 && isFixedCount(pass, loop.X) {
+				return loop.Body
+			}
+		}
+	}
+	return nil
+}
+
+// isFixedCount reports whether e is a constant or runtime.NumCPU() /
+// runtime.GOMAXPROCS(...).
+func isFixedCount(pass *analysis.Pass, e ast.Expr) bool {
+	if tv, ok := pass.TypesInfo.Types[e]; ok && tv.Value != nil {
+		return true
+	}
+	call, ok := ast.Unparen(e).(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+	return ok && fn.Pkg() != nil && fn.Pkg().Path() == "runtime" &&
+		(fn.Name() == "NumCPU" || fn.Name() == "GOMAXPROCS")
+}
+
+// trivialWorker checks that lit only ranges over one of chans doing trivial
+// work (besides deferred Done calls) and returns that channel.
+func trivialWorker(pass *analysis.Pass, lit *ast.FuncLit, chans map[types.Object]ast.Stmt) (types.Object, *ast.Ident) {
+	var rng *ast.RangeStmt
+	for _, stmt := range lit.Body.List {
+		switch s := stmt.(type) {
+		case *ast.RangeStmt:
+			if rng != nil {
+				return nil, nil
+			}
+			rng = s
+		case *ast.DeferStmt:
+			if sel, ok := s.Call.Fun.(*ast.SelectorExpr); !ok || sel.Sel.Name != "Done" {
+				return nil, nil
+			}
+		default:
+			return nil, nil
+		}
+	}
+	if rng == nil {
+		return nil, nil
+	}
+	id, ok := rng.X.(*ast.Ident)
+	if !ok {
+		return nil, nil
+	}
+	obj := pass.TypesInfo.ObjectOf(id)
+	if _, ok := chans[obj]; !ok {
+		return nil, nil
+	}
+	if len(rng.Body.List) > maxTrivialStmts || !isPureBody(pass, rng.Body) {
+		return nil, nil
+	}
+	return obj, id
+}
+
+// isPureBody reports whether body is straight-line computation: no loops,
+// goroutines, selects, defers, closures, or calls other than builtins,
+// conversions and functions from purePkgs.
+func isPureBody(pass *analysis.Pass, body *ast.BlockStmt) bool {
+	pure := true
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.ForStmt, *ast.RangeStmt, *ast.GoStmt, *ast.SelectStmt,
+			*ast.DeferStmt, *ast.FuncLit:
+			pure = false
+		case *ast.CallExpr:
+			if tv, ok := pass.TypesInfo.Types[n.Fun]; ok && tv.IsType() {
+				break // conversion
+			}
+			switch callee := typeutil.Callee(pass.TypesInfo, n).(type) {
+			case *types.Builtin:
+			case *types.Func:
+				if callee.Pkg() == nil || !purePkgs[callee.Pkg().Path()] {
+					pure = false
+				}
+			default:
+				pure = false
+			}
+		}
+		return pure
+	})
+	return pure
+}
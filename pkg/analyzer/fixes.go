@@ -0,0 +1,400 @@
+package analyzer
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"go/types"
+	"go/version"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// suggestFixes returns the automated rewrites available for a classified
+// producer. Fixes are only offered for the exact generator shape
+//
+//	ch := make(chan T)
+//	go func() { ... }()
+//	return ch
+//
+// and only when every use of ch inside the goroutine can be rewritten.
+// A function marked //chanopt:nofix is reported but never rewritten.
+func suggestFixes(pass *analysis.Pass, file *ast.File, cp channelProducer, pat Pattern) []analysis.SuggestedFix {
+	// A literal's type may be fixed by the variable or field that holds
+	// it, so its signature is left alone.
+	if cp.literal || !isPlainGenerator(cp) || hasDirective(cp.funcDecl, "nofix") {
+		return nil
+	}
+	switch pat {
+	case BoundedIterator:
+		return iteratorFix(pass, file, cp)
+	case IDGenerator:
+		return counterFix(pass, file, cp)
+	case ErrorIterator:
+		return errIteratorFix(pass, file, cp)
+	}
+	return nil
+}
+
+// HasAutofix reports whether a suggested fix can be offered for p. The fix
+// still depends on the shape of each producer; see suggestFixes.
+func HasAutofix(p Pattern) bool {
+	return p == BoundedIterator || p == IDGenerator || p == ErrorIterator
+}
+
+// hasDirective reports whether the doc comment of fn contains the
+// directive //chanopt:name, such as
+//
+//	// Counter is part of the v1 API; callers receive from the channel.
+//	//
+//	//chanopt:nofix
+//	func Counter() <-chan int64 { ... }
+func hasDirective(fn *ast.FuncDecl, name string) bool {
+	if fn.Doc == nil {
+		return false
+	}
+	for _, c := range fn.Doc.List {
+		text, ok := strings.CutPrefix(c.Text, "//chanopt:"+name)
+		if ok && (text == "" || text[0] == ' ' || text[0] == '\t') {
+			return true
+		}
+	}
+	return false
+}
+
+// isPlainGenerator reports whether cp's function consists of nothing but the
+// make/go/return triple and returns the channel as its only result.
+func isPlainGenerator(cp channelProducer) bool {
+	fn := cp.funcDecl
+	if fn == nil || len(fn.Body.List) != 3 {
+		return false
+	}
+	if res := fn.Type.Results.List; len(res) != 1 || len(res[0].Names) > 1 {
+		return false
+	}
+	if _, ok := fn.Type.Results.List[0].Type.(*ast.ChanType); !ok {
+		return false
+	}
+	if s, ok := fn.Body.List[0].(*ast.AssignStmt); !ok || s.Lhs[0] != ast.Expr(cp.chanIdent) {
+		return false
+	}
+	g, ok := fn.Body.List[1].(*ast.GoStmt)
+	if !ok || g.Call.Fun != ast.Expr(cp.funcLit) || len(g.Call.Args) != 0 {
+		return false
+	}
+	if len(cp.funcLit.Type.Params.List) != 0 {
+		return false
+	}
+	ret, ok := fn.Body.List[2].(*ast.ReturnStmt)
+	if !ok || len(ret.Results) != 1 {
+		return false
+	}
+	id, ok := ret.Results[0].(*ast.Ident)
+	return ok && id.Name == cp.chanIdent.Name
+}
+
+// iteratorFix rewrites a BoundedIterator into a function returning
+// iter.Seq[T]. Callers that range over the result keep compiling.
+func iteratorFix(pass *analysis.Pass, file *ast.File, cp channelProducer) []analysis.SuggestedFix {
+	if !fileAtLeast(pass, file, "go1.23") {
+		return nil
+	}
+	ct := cp.funcDecl.Type.Results.List[0].Type.(*ast.ChanType)
+	return yieldFix(pass, file, cp, func(src *source, im *fixImports) (yieldShape, bool) {
+		elem := src.text(ct.Value.Pos(), ct.End())
+		return yieldShape{
+			seq:    im.name("iter") + ".Seq[" + elem + "]",
+			params: elem,
+			title:  "iter.Seq[" + elem + "]",
+			args:   func(v ast.Expr) (string, bool) { return src.text(v.Pos(), v.End()), true },
+		}, true
+	})
+}
+
+// yieldShape describes the iterator a generator is rewritten into.
+type yieldShape struct {
+	seq    string                          // the iterator type, as in "iter.Seq[int]"
+	params string                          // the parameters of yield, as in "int"
+	title  string                          // the iterator type in the fix's title
+	args   func(v ast.Expr) (string, bool) // the arguments of yield for a sent value v
+}
+
+// yieldFix rewrites cp's function to return the iterator that shape
+// describes, yielding what the goroutine sends and returning when yield
+// does. It gives up on sends in select cases or nested closures, and on
+// any other use of the channel than sending and closing it.
+func yieldFix(pass *analysis.Pass, file *ast.File, cp channelProducer, shape func(*source, *fixImports) (yieldShape, bool)) []analysis.SuggestedFix {
+	src, ok := readSource(pass, file)
+	if !ok {
+		return nil
+	}
+	im := newFixImports(pass, file, src)
+	it, ok := shape(src, im)
+	if !ok {
+		return nil
+	}
+	chanObj := pass.TypesInfo.ObjectOf(cp.chanIdent)
+	isChan := func(e ast.Expr) bool {
+		id, ok := ast.Unparen(e).(*ast.Ident)
+		return ok && pass.TypesInfo.ObjectOf(id) == chanObj
+	}
+
+	var inner []textEdit
+	safe := true
+	ast.Inspect(cp.funcLit.Body, func(n ast.Node) bool {
+		if !safe {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			// yield inside a nested closure would not stop the iterator.
+			if refersTo(pass, n, chanObj) {
+				safe = false
+			}
+			return false
+		case *ast.CommClause:
+			if s, ok := n.Comm.(*ast.SendStmt); ok && isChan(s.Chan) {
+				safe = false
+			}
+		case *ast.SendStmt:
+			if isChan(n.Chan) {
+				args, ok := it.args(n.Value)
+				if !ok {
+					safe = false
+					return false
+				}
+				inner = append(inner, textEdit{n.Pos(), n.End(), "if !yield(" + args + ") {\nreturn\n}"})
+				return false
+			}
+		case *ast.DeferStmt:
+			if isCloseOf(n.Call, isChan) {
+				inner = append(inner, src.lineEdit(n))
+				return false
+			}
+		case *ast.ExprStmt:
+			if call, ok := n.X.(*ast.CallExpr); ok && isCloseOf(call, isChan) {
+				inner = append(inner, src.lineEdit(n))
+				return false
+			}
+		case *ast.Ident:
+			if pass.TypesInfo.ObjectOf(n) == chanObj {
+				safe = false // len(ch), passing ch along, ...
+			}
+		}
+		return true
+	})
+	if !safe {
+		return nil
+	}
+
+	fn := cp.funcDecl
+	results := fn.Type.Results
+	lit := src.apply(cp.funcLit.Body.Pos(), cp.funcLit.Body.End(), inner)
+	decl := src.text(fn.Pos(), results.Pos()) + it.seq +
+		src.text(results.End(), fn.Body.Lbrace) +
+		"{\nreturn func(yield func(" + it.params + ") bool) " + lit + "\n}"
+
+	return rewriteDecl(im, fn, decl, "Convert "+fn.Name.Name+" to "+it.title)
+}
+
+// atomicTypes maps counter types to their sync/atomic wrapper.
+var atomicTypes = map[types.BasicKind]string{
+	types.Int32:  "Int32",
+	types.Int64:  "Int64",
+	types.Uint32: "Uint32",
+	types.Uint64: "Uint64",
+}
+
+// counterFix rewrites the canonical IDGenerator
+//
+//	var id T
+//	for { id++; ch <- id }
+//
+// into a function returning func() T backed by an atomic counter. Unlike
+// iteratorFix this changes how callers obtain values. Two alternatives
+// are offered, so that an editor lists both: the atomic.Int64-style type
+// added in Go 1.19, first and preferred, and the atomic.AddInt64-style
+// function on a plain variable, which is also the only fix for older files.
+func counterFix(pass *analysis.Pass, file *ast.File, cp channelProducer) []analysis.SuggestedFix {
+	body := cp.funcLit.Body.List
+	if len(body) != 2 {
+		return nil
+	}
+	decl, ok := body[0].(*ast.DeclStmt)
+	if !ok {
+		return nil
+	}
+	gen, ok := decl.Decl.(*ast.GenDecl)
+	if !ok || gen.Tok != token.VAR || len(gen.Specs) != 1 {
+		return nil
+	}
+	spec := gen.Specs[0].(*ast.ValueSpec)
+	if len(spec.Names) != 1 || len(spec.Values) != 0 {
+		return nil
+	}
+	counter := pass.TypesInfo.ObjectOf(spec.Names[0])
+	basic, ok := counter.Type().(*types.Basic)
+	if !ok {
+		return nil
+	}
+	wrapper, ok := atomicTypes[basic.Kind()]
+	if !ok || cp.chanType == nil || !types.Identical(cp.chanType.Elem(), basic) {
+		return nil
+	}
+
+	loop, ok := body[1].(*ast.ForStmt)
+	if !ok || loop.Init != nil || loop.Cond != nil || loop.Post != nil || len(loop.Body.List) != 2 {
+		return nil
+	}
+	inc, ok := loop.Body.List[0].(*ast.IncDecStmt)
+	if !ok || inc.Tok != token.INC || !isObj(pass, inc.X, counter) {
+		return nil
+	}
+	send, ok := loop.Body.List[1].(*ast.SendStmt)
+	if !ok || !isObj(pass, send.Value, counter) {
+		return nil
+	}
+
+	src, ok := readSource(pass, file)
+	if !ok {
+		return nil
+	}
+	fn := cp.funcDecl
+	results := fn.Type.Results
+	typ := basic.Name()
+	name := counter.Name()
+	head := src.text(fn.Pos(), results.Pos()) + "func() " + typ +
+		src.text(results.End(), fn.Body.Lbrace)
+	var fixes []analysis.SuggestedFix
+	if fileAtLeast(pass, file, "go1.19") {
+		im := newFixImports(pass, file, src)
+		fixes = append(fixes, rewriteDecl(im, fn,
+			head+"{\nvar "+name+" "+im.name("sync/atomic")+"."+wrapper+
+				"\nreturn func() "+typ+" {\nreturn "+name+".Add(1)\n}\n}",
+			"Replace "+fn.Name.Name+" with an atomic."+wrapper+" counter")...)
+	}
+	im := newFixImports(pass, file, src)
+	return append(fixes, rewriteDecl(im, fn,
+		head+"{\nvar "+name+" "+typ+
+			"\nreturn func() "+typ+" {\nreturn "+im.name("sync/atomic")+".Add"+wrapper+"(&"+name+", 1)\n}\n}",
+		"Replace "+fn.Name.Name+" with an atomic.Add"+wrapper+" counter")...)
+}
+
+// rewriteDecl builds a fix replacing fn with the gofmt'd text of decl,
+// with the import edits im computes for it.
+func rewriteDecl(im *fixImports, fn *ast.FuncDecl, decl, msg string) []analysis.SuggestedFix {
+	formatted, err := format.Source([]byte("package p\n\n" + decl))
+	if err != nil {
+		return nil
+	}
+	formatted = bytes.TrimPrefix(formatted, []byte("package p\n\n"))
+	formatted = bytes.TrimSuffix(formatted, []byte("\n"))
+
+	edits := []analysis.TextEdit{{Pos: fn.Pos(), End: fn.End(), NewText: formatted}}
+	imports, ok := im.imports(edits)
+	if !ok {
+		return nil
+	}
+	return []analysis.SuggestedFix{{Message: msg, TextEdits: append(edits, imports...)}}
+}
+
+// fileAtLeast reports whether file is compiled with at least Go version v.
+// Unknown versions are assumed new enough.
+func fileAtLeast(pass *analysis.Pass, file *ast.File, v string) bool {
+	fv := fileVersion(pass, file)
+	return fv == "" || version.Compare(fv, v) >= 0
+}
+
+// fileVersion returns the Go version of file, or of its package if the
+// file sets none, or "" if neither is known.
+func fileVersion(pass *analysis.Pass, file *ast.File) string {
+	fv := pass.TypesInfo.FileVersions[file]
+	if fv == "" && pass.Pkg != nil {
+		fv = pass.Pkg.GoVersion()
+	}
+	return fv
+}
+
+func isCloseOf(call *ast.CallExpr, isChan func(ast.Expr) bool) bool {
+	id, ok := call.Fun.(*ast.Ident)
+	return ok && id.Name == "close" && len(call.Args) == 1 && isChan(call.Args[0])
+}
+
+func isObj(pass *analysis.Pass, e ast.Expr, obj types.Object) bool {
+	id, ok := ast.Unparen(e).(*ast.Ident)
+	return ok && pass.TypesInfo.ObjectOf(id) == obj
+}
+
+// refersTo reports whether n mentions obj.
+func refersTo(pass *analysis.Pass, n ast.Node, obj types.Object) bool {
+	found := false
+	ast.Inspect(n, func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && pass.TypesInfo.ObjectOf(id) == obj {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+// source is the content of one file, addressable by token.Pos.
+type source struct {
+	tok *token.File
+	src []byte
+}
+
+// textEdit is a pending replacement within a source.
+type textEdit struct {
+	from, to token.Pos
+	text     string
+}
+
+func readSource(pass *analysis.Pass, file *ast.File) (*source, bool) {
+	tok := pass.Fset.File(file.Pos())
+	if tok == nil || pass.ReadFile == nil {
+		return nil, false
+	}
+	src, err := pass.ReadFile(tok.Name())
+	if err != nil || len(src) != tok.Size() {
+		return nil, false
+	}
+	return &source{tok: tok, src: src}, true
+}
+
+func (s *source) text(from, to token.Pos) string {
+	return string(s.src[s.tok.Offset(from):s.tok.Offset(to)])
+}
+
+// lineEdit deletes n together with its line, if n is alone on it.
+func (s *source) lineEdit(n ast.Node) textEdit {
+	from, to := s.tok.Offset(n.Pos()), s.tok.Offset(n.End())
+	start := from
+	for start > 0 && (s.src[start-1] == ' ' || s.src[start-1] == '\t') {
+		start--
+	}
+	end := to
+	for end < len(s.src) && (s.src[end] == ' ' || s.src[end] == '\t') {
+		end++
+	}
+	if (start == 0 || s.src[start-1] == '\n') && end < len(s.src) && s.src[end] == '\n' {
+		from, to = start, end+1
+	}
+	return textEdit{s.tok.Pos(from), s.tok.Pos(to), ""}
+}
+
+// apply returns the text between from and to with edits applied.
+func (s *source) apply(from, to token.Pos, edits []textEdit) string {
+	sort.Slice(edits, func(i, j int) bool { return edits[i].from < edits[j].from })
+	var b strings.Builder
+	cur := from
+	for _, e := range edits {
+		b.WriteString(s.text(cur, e.from))
+		b.WriteString(e.text)
+		cur = e.to
+	}
+	b.WriteString(s.text(cur, to))
+	return b.String()
+}
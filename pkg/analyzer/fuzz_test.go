@@ -0,0 +1,166 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"sync"
+	"testing"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+// FuzzClassify builds random but type-correct generator functions from the
+// fuzz input and checks that the analyzer never panics and never reports
+// high confidence for code that a safety gate must reject.
+//
+//	go test ./pkg/analyzer -run '^$' -fuzz FuzzClassify -fuzztime 30s
+func FuzzClassify(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{0, 0, 1, 0, 0})          // infinite loop, n++ (IDGenerator)
+	f.Add([]byte{0, 1, 2, 2, 1, 0, 2})    // closed range over items
+	f.Add([]byte{1, 0, 0, 2, 6, 0})       // select with ctx.Done
+	f.Add([]byte{2, 1, 3, 1, 4, 2, 5, 9}) // range over input channel
+	f.Fuzz(func(t *testing.T, choices []byte) {
+		g := genFunc(choices)
+		pass, err := typecheckGen(g.src)
+		if err != nil {
+			t.Fatalf("generator produced invalid code: %v\n%s", err, g.src)
+		}
+		for _, cp := range detect(pass, nil) {
+			pat, conf := classify(cp, pass)
+			if g.gated && conf >= 0.9 {
+				t.Errorf("gated code classified as %s at %.2f:\n%s", pat, conf, g.src)
+			}
+		}
+		if _, err := run(pass); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+// generated is a fuzzed generator function and whether it contains a
+// construct (coordination, I/O, pipeline input) that a safety gate rejects.
+type generated struct {
+	src   string
+	gated bool
+}
+
+var (
+	fuzzLoops = []struct {
+		head  string
+		gated bool
+	}{
+		{"for {", false},
+		{"for i := 0; i < len(items); i++ {", false},
+		{"for _, n = range items {", false},
+		{"for range in {", true},
+		{"for i := 0; ; i = (i + 1) % len(items) {", false},
+		{"{", false},
+		{"for range time.Tick(d) {", false},
+	}
+	fuzzStmts = []struct {
+		src   string
+		gated bool
+	}{
+		{"n++", false},
+		{"n = (n + 1) % 7", false},
+		{"time.Sleep(d)", false},
+		{"ch <- n", false},
+		{"ch <- items[n%len(items)]", false},
+		{"_ = os.Getpid()", true},
+		{"select {\ncase ch <- n:\ncase <-ctx.Done():\nreturn\n}", true},
+		{"select {\ncase ch <- n:\ndefault:\n}", true},
+		{"if n > 3 {\nreturn\n}", false},
+		{"t := time.NewTicker(d)\nt.Stop()", false},
+		{"n += len(items)", false},
+	}
+)
+
+// genFunc deterministically turns choices into a generator function.
+func genFunc(choices []byte) generated {
+	next := func(n int) int {
+		if len(choices) == 0 {
+			return 0
+		}
+		c := int(choices[0])
+		choices = choices[1:]
+		return c % n
+	}
+	var g generated
+	var b strings.Builder
+	b.WriteString("package p\n\nimport (\n\t\"context\"\n\t\"os\"\n\t\"time\"\n)\n\n")
+	b.WriteString("var _, _, _ = context.Background, os.Getpid, time.Now\n\n")
+	b.WriteString("func Gen(ctx context.Context, items []int, in <-chan int, d time.Duration) <-chan int {\n")
+	if buf := next(3); buf > 0 {
+		fmt.Fprintf(&b, "ch := make(chan int, %d)\n", buf)
+	} else {
+		b.WriteString("ch := make(chan int)\n")
+	}
+	b.WriteString("go func() {\nn := 0\n_ = n\n")
+	if next(2) == 1 {
+		b.WriteString("defer close(ch)\n")
+	}
+	loop := fuzzLoops[next(len(fuzzLoops))]
+	g.gated = g.gated || loop.gated
+	b.WriteString(loop.head + "\n")
+	for i, k := 0, next(5); i < k; i++ {
+		s := fuzzStmts[next(len(fuzzStmts))]
+		g.gated = g.gated || s.gated
+		b.WriteString("{\n" + s.src + "\n}\n")
+	}
+	b.WriteString("ch <- n\n}\n}()\nreturn ch\n}\n")
+	g.src = b.String()
+	return g
+}
+
+var (
+	fuzzFset     = token.NewFileSet()
+	fuzzImporter = importer.ForCompiler(fuzzFset, "source", nil)
+	fuzzMu       sync.Mutex // guards the shared importer
+)
+
+// typecheckGen parses and type-checks src into a Pass suitable for run.
+func typecheckGen(src string) (*analysis.Pass, error) {
+	fuzzMu.Lock()
+	defer fuzzMu.Unlock()
+	file, err := parser.ParseFile(fuzzFset, "gen.go", src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	info := &types.Info{
+		Types:        map[ast.Expr]types.TypeAndValue{},
+		Defs:         map[*ast.Ident]types.Object{},
+		Uses:         map[*ast.Ident]types.Object{},
+		Implicits:    map[ast.Node]types.Object{},
+		Scopes:       map[ast.Node]*types.Scope{},
+		Selections:   map[*ast.SelectorExpr]*types.Selection{},
+		FileVersions: map[*ast.File]string{},
+	}
+	conf := types.Config{Importer: fuzzImporter}
+	pkg, err := conf.Check("p", fuzzFset, []*ast.File{file}, info)
+	if err != nil {
+		return nil, err
+	}
+	pass := &analysis.Pass{
+		Analyzer:  Analyzer,
+		Fset:      fuzzFset,
+		Files:     []*ast.File{file},
+		Pkg:       pkg,
+		TypesInfo: info,
+		Report:    func(analysis.Diagnostic) {},
+		ResultOf:  map[*analysis.Analyzer]any{inspect.Analyzer: inspector.New([]*ast.File{file})},
+		ReadFile:  func(string) ([]byte, error) { return []byte(src), nil },
+
+		ImportObjectFact:  func(types.Object, analysis.Fact) bool { return false },
+		ExportObjectFact:  func(types.Object, analysis.Fact) {},
+		ExportPackageFact: func(analysis.Fact) {},
+	}
+	return pass, nil
+}
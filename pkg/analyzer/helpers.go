@@ -0,0 +1,200 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// reportChanHelpers flags local reimplementations of the orDone and merge
+// helpers popularized by "Concurrency in Go". Copies of them often drop
+// the inner select on done, or have no way to stop at all, and leak their
+// goroutines when the consumer goes away; context cancellation or the
+// vetted versions in pkg/replacements do the same job.
+func reportChanHelpers(pass *analysis.Pass) {
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil || fn.Type.Results == nil || !returnsChan(fn.Type.Results) {
+				continue
+			}
+			switch {
+			case isOrDone(pass, fn):
+				pass.Report(analysis.Diagnostic{
+					Pos:      fn.Name.Pos(),
+					Category: "OrDone",
+					Message: "chanopt: " + fn.Name.Name + " reimplements orDone — select on ctx.Done() where the channel is consumed," +
+						" or use replacements.OrDone(ctx, c); hand-rolled copies often leak the forwarding goroutine",
+				})
+			case isMerge(pass, fn):
+				pass.Report(analysis.Diagnostic{
+					Pos:      fn.Name.Pos(),
+					Category: "Merge",
+					Message: "chanopt: " + fn.Name.Name + " reimplements channel merge — use replacements.Merge(ctx, cs...)," +
+						" which releases its goroutines on cancellation instead of leaking them when the consumer stops",
+				})
+			}
+		}
+	}
+}
+
+// isOrDone reports whether fn forwards one channel parameter to its result
+// from a goroutine that selects on receiving from it and from a done
+// channel of struct{}:
+//
+//	func orDone(done <-chan struct{}, c <-chan T) <-chan T {
+//	    out := make(chan T)
+//	    go func() {
+//	        defer close(out)
+//	        for {
+//	            select {
+//	            case <-done: return
+//	            case v, ok := <-c: ... out <- v ...
+//	            }
+//	        }
+//	    }()
+//	    return out
+//	}
+func isOrDone(pass *analysis.Pass, fn *ast.FuncDecl) bool {
+	var done, src types.Object
+	for _, field := range fn.Type.Params.List {
+		for _, name := range field.Names {
+			obj := pass.TypesInfo.Defs[name]
+			if obj == nil {
+				continue
+			}
+			ch, ok := obj.Type().Underlying().(*types.Chan)
+			if !ok {
+				continue
+			}
+			if st, ok := ch.Elem().Underlying().(*types.Struct); ok && st.NumFields() == 0 && done == nil {
+				done = obj
+			} else {
+				src = obj
+			}
+		}
+	}
+	if done == nil || src == nil {
+		return false
+	}
+	lit := soleGoroutine(fn)
+	if lit == nil {
+		return false
+	}
+	var onDone, forwards bool
+	ast.Inspect(lit.Body, func(n ast.Node) bool {
+		cc, ok := n.(*ast.CommClause)
+		if !ok {
+			return true
+		}
+		x := commRecv(cc.Comm)
+		switch {
+		case x == nil:
+		case isObj(pass, x, done):
+			onDone = true
+		case isObj(pass, x, src):
+			// The received value must be sent on: case v, ok := <-c: ... out <- v
+			as, ok := cc.Comm.(*ast.AssignStmt)
+			if !ok {
+				break
+			}
+			id, ok := as.Lhs[0].(*ast.Ident)
+			if !ok || id.Name == "_" {
+				break
+			}
+			v := pass.TypesInfo.ObjectOf(id)
+			for _, stmt := range cc.Body {
+				ast.Inspect(stmt, func(n ast.Node) bool {
+					if send, ok := n.(*ast.SendStmt); ok && isObj(pass, send.Value, v) {
+						forwards = true
+					}
+					return true
+				})
+			}
+		}
+		return true
+	})
+	return onDone && forwards
+}
+
+// isMerge reports whether fn takes a slice of channels, starts a goroutine
+// per element that ranges over it, and closes its result once they are
+// all done:
+//
+//	func merge(cs ...<-chan T) <-chan T {
+//	    var wg sync.WaitGroup
+//	    out := make(chan T)
+//	    for _, c := range cs {
+//	        wg.Add(1)
+//	        go func(c <-chan T) { for v := range c { out <- v }; wg.Done() }(c)
+//	    }
+//	    go func() { wg.Wait(); close(out) }()
+//	    return out
+//	}
+func isMerge(pass *analysis.Pass, fn *ast.FuncDecl) bool {
+	var inputs types.Object
+	for _, field := range fn.Type.Params.List {
+		for _, name := range field.Names {
+			obj := pass.TypesInfo.Defs[name]
+			if obj == nil {
+				continue
+			}
+			if sl, ok := obj.Type().Underlying().(*types.Slice); ok {
+				if _, ok := sl.Elem().Underlying().(*types.Chan); ok {
+					inputs = obj
+				}
+			}
+		}
+	}
+	if inputs == nil {
+		return false
+	}
+	var spawns, closes bool
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.RangeStmt:
+			if isObj(pass, n.X, inputs) && containsGo(n.Body) {
+				spawns = true
+			}
+		case *ast.CallExpr:
+			if id, ok := n.Fun.(*ast.Ident); ok && id.Name == "close" {
+				if _, ok := pass.TypesInfo.Uses[id].(*types.Builtin); ok {
+					closes = true
+				}
+			}
+		}
+		return true
+	})
+	return spawns && closes
+}
+
+// soleGoroutine returns the function literal of the only go statement at
+// the top level of fn's body, or nil.
+func soleGoroutine(fn *ast.FuncDecl) *ast.FuncLit {
+	var lit *ast.FuncLit
+	for _, stmt := range fn.Body.List {
+		g, ok := stmt.(*ast.GoStmt)
+		if !ok {
+			continue
+		}
+		if lit != nil {
+			return nil
+		}
+		if lit, ok = g.Call.Fun.(*ast.FuncLit); !ok {
+			return nil
+		}
+	}
+	return lit
+}
+
+func containsGo(n ast.Node) bool {
+	found := false
+	ast.Inspect(n, func(n ast.Node) bool {
+		if _, ok := n.(*ast.GoStmt); ok {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
@@ -0,0 +1,402 @@
+package analyzer
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// fixImports keeps the imports of a file right for one suggested fix. A
+// fix asks for the name under which to refer to each package its new text
+// uses, and once the text is built, imports computes the edits that add
+// the missing imports and drop those the fix leaves unused:
+//
+//	im := newFixImports(pass, file, src)
+//	text := "var n " + im.name("sync/atomic") + ".Int64"
+//	edits := []analysis.TextEdit{{Pos: fn.Pos(), End: fn.End(), NewText: []byte(text)}}
+//	more, ok := im.imports(edits)
+//
+// A package is referred to by the name the file already imports it under.
+// A new import takes the package's own name unless another import or a
+// package-level declaration of the file has it, as when the file imports
+// go.uber.org/atomic, and then a name made from the whole path, such as
+// syncatomic. New imports go into the first import declaration, standard
+// library packages into its group of standard library imports and others
+// into its group of other imports, in sorted order, and the declaration is
+// reformatted as gofmt would. An import is dropped when the edits remove
+// every use of it.
+type fixImports struct {
+	pass  *analysis.Pass
+	file  *ast.File
+	src   *source
+	names map[string]string // name by path, for the paths the fix uses
+}
+
+func newFixImports(pass *analysis.Pass, file *ast.File, src *source) *fixImports {
+	return &fixImports{pass: pass, file: file, src: src, names: map[string]string{}}
+}
+
+// name returns the name to qualify the identifiers of the package path
+// with in the fix's text, and records that the fix uses the package.
+func (im *fixImports) name(path string) string {
+	if name, ok := im.names[path]; ok {
+		return name
+	}
+	name := ""
+	for _, spec := range im.file.Imports {
+		if p, _ := strconv.Unquote(spec.Path.Value); p == path {
+			if pn := im.pass.TypesInfo.PkgNameOf(spec); pn != nil && pn.Name() != "_" && pn.Name() != "." {
+				name = pn.Name()
+				break
+			}
+		}
+	}
+	if name == "" {
+		name = im.freeName(path)
+	}
+	im.names[path] = name
+	return name
+}
+
+// freeName returns the name for a new import of path.
+func (im *fixImports) freeName(path string) string {
+	name := pkgName(im.pass, path)
+	taken := func(name string) bool {
+		for _, spec := range im.file.Imports {
+			if pn := im.pass.TypesInfo.PkgNameOf(spec); pn != nil && pn.Name() == name {
+				return true
+			}
+		}
+		for _, n := range im.names {
+			if n == name {
+				return true
+			}
+		}
+		return im.pass.Pkg.Scope().Lookup(name) != nil
+	}
+	if !taken(name) {
+		return name
+	}
+	alias := strings.Map(func(r rune) rune {
+		if 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' {
+			return r
+		}
+		return -1
+	}, path)
+	for base, i := alias, 2; taken(alias); i++ {
+		alias = base + strconv.Itoa(i)
+	}
+	return alias
+}
+
+// pkgName returns the declared name of the package path if the package
+// being analyzed depends on it, or else its last path element, skipping a
+// major version suffix such as /v2.
+func pkgName(pass *analysis.Pass, path string) string {
+	seen := map[*types.Package]bool{}
+	var find func(pkgs []*types.Package) string
+	find = func(pkgs []*types.Package) string {
+		for _, p := range pkgs {
+			if seen[p] {
+				continue
+			}
+			seen[p] = true
+			if p.Path() == path {
+				return p.Name()
+			}
+			if name := find(p.Imports()); name != "" {
+				return name
+			}
+		}
+		return ""
+	}
+	if name := find(pass.Pkg.Imports()); name != "" {
+		return name
+	}
+	elems := strings.Split(path, "/")
+	last := elems[len(elems)-1]
+	if len(elems) > 1 && len(last) > 1 && last[0] == 'v' && strings.Trim(last[1:], "0123456789") == "" {
+		last = elems[len(elems)-2]
+	}
+	return strings.NewReplacer("-", "", ".", "").Replace(last)
+}
+
+// imports returns the edits to the import declarations of the file that
+// the fix made of edits needs, or false if they cannot be made without
+// losing a comment that is not attached to an import.
+func (im *fixImports) imports(edits []analysis.TextEdit) ([]analysis.TextEdit, bool) {
+	// Paths to add: those the fix names that the file does not import
+	// under that name.
+	var add []*ast.ImportSpec
+	for path, name := range im.names {
+		imported := slices.ContainsFunc(im.file.Imports, func(spec *ast.ImportSpec) bool {
+			p, _ := strconv.Unquote(spec.Path.Value)
+			pn := im.pass.TypesInfo.PkgNameOf(spec)
+			return p == path && pn != nil && pn.Name() == name
+		})
+		if imported {
+			continue
+		}
+		spec := &ast.ImportSpec{Path: &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(path)}}
+		if name != pkgName(im.pass, path) {
+			spec.Name = ast.NewIdent(name)
+		}
+		add = append(add, spec)
+	}
+	slices.SortFunc(add, func(a, b *ast.ImportSpec) int { return strings.Compare(a.Path.Value, b.Path.Value) })
+
+	// Imports to drop: those used in the edited ranges but not outside
+	// them or in the new text. An import that is already unused is a
+	// compile error that is not the fix's to repair.
+	used, edited := map[*types.PkgName]bool{}, map[*types.PkgName]bool{}
+	for id, obj := range im.pass.TypesInfo.Uses {
+		pn, ok := obj.(*types.PkgName)
+		if !ok || id.Pos() < im.file.Pos() || id.Pos() >= im.file.End() {
+			continue
+		}
+		if slices.ContainsFunc(edits, func(e analysis.TextEdit) bool { return e.Pos <= id.Pos() && id.Pos() < e.End }) {
+			edited[pn] = true
+		} else {
+			used[pn] = true
+		}
+	}
+	qualifiers := map[string]bool{}
+	for _, e := range edits {
+		for name := range qualifiersIn(e.NewText) {
+			qualifiers[name] = true
+		}
+	}
+	drop := map[*ast.ImportSpec]bool{}
+	for _, spec := range im.file.Imports {
+		pn := im.pass.TypesInfo.PkgNameOf(spec)
+		if pn != nil && edited[pn] && !used[pn] && !qualifiers[pn.Name()] {
+			drop[spec] = true
+		}
+	}
+	if len(add) == 0 && len(drop) == 0 {
+		return nil, true
+	}
+
+	var out []analysis.TextEdit
+	first := true
+	for _, d := range im.file.Decls {
+		gen, ok := d.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT {
+			continue
+		}
+		var adding []*ast.ImportSpec
+		if first {
+			adding, first = add, false
+		}
+		if len(adding) == 0 && !slices.ContainsFunc(gen.Specs, func(s ast.Spec) bool { return drop[s.(*ast.ImportSpec)] }) {
+			continue
+		}
+		e, ok := im.rewriteImportDecl(gen, adding, drop)
+		if !ok {
+			return nil, false
+		}
+		out = append(out, e)
+	}
+	if first && len(add) > 0 {
+		// No import declaration yet: start one after the package clause.
+		text, ok := formatImportDecl([][]string{specTexts(add)})
+		if !ok {
+			return nil, false
+		}
+		end := im.file.Name.End()
+		out = append(out, analysis.TextEdit{Pos: end, End: end, NewText: []byte("\n\n" + text)})
+	}
+	return out, true
+}
+
+// rewriteImportDecl returns an edit replacing gen by the declaration with
+// the imports in drop removed and those in add inserted, gofmt'd. The
+// declaration is deleted, with its line, if no import is left.
+func (im *fixImports) rewriteImportDecl(gen *ast.GenDecl, add []*ast.ImportSpec, drop map[*ast.ImportSpec]bool) (analysis.TextEdit, bool) {
+	// Comments in the declaration that belong to no import would be lost.
+	attached := map[*ast.CommentGroup]bool{gen.Doc: true}
+	for _, s := range gen.Specs {
+		spec := s.(*ast.ImportSpec)
+		attached[spec.Doc], attached[spec.Comment] = true, true
+	}
+	for _, c := range im.file.Comments {
+		if gen.Pos() <= c.Pos() && c.End() <= gen.End() && !attached[c] {
+			return analysis.TextEdit{}, false
+		}
+	}
+
+	// Keep the groups of the declaration, which are separated by blank
+	// lines, and the source text of each import with its comments.
+	type group struct {
+		specs []string
+		std   bool // all standard library
+		other bool // some not standard library
+	}
+	var groups []*group
+	lastLine := 0
+	for _, s := range gen.Specs {
+		spec := s.(*ast.ImportSpec)
+		from, to := spec.Pos(), spec.End()
+		if spec.Doc != nil {
+			from = spec.Doc.Pos()
+		}
+		if spec.Comment != nil {
+			to = spec.Comment.End()
+		}
+		line := im.pass.Fset.Position(from).Line
+		if len(groups) == 0 || line > lastLine+1 {
+			groups = append(groups, &group{std: true})
+		}
+		lastLine = im.pass.Fset.Position(to).Line
+		g := groups[len(groups)-1]
+		p, _ := strconv.Unquote(spec.Path.Value)
+		if !isStdlib(p) {
+			g.std, g.other = false, true
+		}
+		if !drop[spec] {
+			g.specs = append(g.specs, im.src.text(from, to))
+		}
+	}
+	for _, spec := range add {
+		p, _ := strconv.Unquote(spec.Path.Value)
+		var into *group
+		if isStdlib(p) {
+			if i := slices.IndexFunc(groups, func(g *group) bool { return g.std }); i >= 0 {
+				into = groups[i]
+			} else {
+				into = &group{std: true}
+				groups = slices.Insert(groups, 0, into)
+			}
+		} else {
+			for _, g := range groups {
+				if g.other {
+					into = g
+				}
+			}
+			if into == nil {
+				into = &group{other: true}
+				groups = append(groups, into)
+			}
+		}
+		into.specs = append(into.specs, specTexts([]*ast.ImportSpec{spec})...)
+	}
+
+	var kept [][]string
+	for _, g := range groups {
+		if len(g.specs) > 0 {
+			kept = append(kept, g.specs)
+		}
+	}
+	from, to := gen.Pos(), gen.End()
+	if gen.Doc != nil {
+		from = gen.Doc.Pos()
+	}
+	if len(kept) == 0 {
+		e := im.src.lineEdit(gen)
+		if gen.Doc != nil {
+			e.from = from
+		}
+		// Also take the blank line that separated it from the next
+		// declaration.
+		tok := im.src.tok
+		if off := tok.Offset(e.to); off < len(im.src.src) && im.src.src[off] == '\n' {
+			e.to = tok.Pos(off + 1)
+		}
+		return analysis.TextEdit{Pos: e.from, End: e.to}, true
+	}
+	text, ok := formatImportDecl(kept)
+	if !ok {
+		return analysis.TextEdit{}, false
+	}
+	if gen.Doc != nil {
+		text = im.src.text(gen.Doc.Pos(), gen.Doc.End()) + "\n" + text
+	}
+	return analysis.TextEdit{Pos: from, End: to, NewText: []byte(text)}, true
+}
+
+// formatImportDecl returns the gofmt'd import declaration of the groups of
+// import specs, given as source text.
+func formatImportDecl(groups [][]string) (string, bool) {
+	var b strings.Builder
+	b.WriteString("package p\n\n")
+	if len(groups) == 1 && len(groups[0]) == 1 && !strings.Contains(groups[0][0], "//") && !strings.Contains(groups[0][0], "/*") {
+		b.WriteString("import " + groups[0][0] + "\n")
+	} else {
+		b.WriteString("import (\n")
+		for i, g := range groups {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			slices.SortStableFunc(g, func(a, b string) int { return strings.Compare(specPath(a), specPath(b)) })
+			for _, s := range g {
+				b.WriteString(s + "\n")
+			}
+		}
+		b.WriteString(")\n")
+	}
+	out, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return "", false
+	}
+	out = bytes.TrimPrefix(out, []byte("package p\n\n"))
+	return string(bytes.TrimSuffix(out, []byte("\n"))), true
+}
+
+// specPath returns the quoted path of the import spec in text, which may
+// start with a doc comment and a name.
+func specPath(text string) string {
+	if i := strings.IndexByte(text, '"'); i >= 0 {
+		return text[i:]
+	}
+	return text
+}
+
+func specTexts(specs []*ast.ImportSpec) []string {
+	var texts []string
+	for _, spec := range specs {
+		if spec.Name != nil {
+			texts = append(texts, spec.Name.Name+" "+spec.Path.Value)
+		} else {
+			texts = append(texts, spec.Path.Value)
+		}
+	}
+	return texts
+}
+
+// isStdlib reports whether path is in the standard library, whose import
+// paths have no dot in their first element.
+func isStdlib(path string) bool {
+	first, _, _ := strings.Cut(path, "/")
+	return !strings.Contains(first, ".")
+}
+
+// qualifiersIn returns the identifiers followed by a period in the Go
+// source fragment text, which include the package names it refers to.
+func qualifiersIn(text []byte) map[string]bool {
+	names := map[string]bool{}
+	fset := token.NewFileSet()
+	var s scanner.Scanner
+	s.Init(fset.AddFile("", -1, len(text)), text, nil, 0)
+	prev := ""
+	for {
+		_, tok, lit := s.Scan()
+		if tok == token.EOF {
+			return names
+		}
+		if tok == token.PERIOD && prev != "" {
+			names[prev] = true
+		}
+		prev = ""
+		if tok == token.IDENT {
+			prev = lit
+		}
+	}
+}
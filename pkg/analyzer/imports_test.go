@@ -0,0 +1,126 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/format"
+	"slices"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+func TestFixImports(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		src  string
+		uses []string // paths the new F refers to, in order
+		body string   // new body of F, with %s replaced by the names of uses
+		want string   // the import section after the fix, or "" for no fix
+	}{{
+		name: "no imports",
+		src:  "package p\n\nfunc F() {}\n",
+		uses: []string{"sync/atomic"},
+		body: "var n %s.Int64; n.Add(1)",
+		want: "package p\n\nimport \"sync/atomic\"\n",
+	}, {
+		name: "single import",
+		src:  "package p\n\nimport \"fmt\"\n\nfunc F() { fmt.Println() }\n\nfunc G() { fmt.Println() }\n",
+		uses: []string{"iter"},
+		body: "var _ %s.Seq[int]",
+		want: "package p\n\nimport (\n\t\"fmt\"\n\t\"iter\"\n)\n",
+	}, {
+		name: "groups",
+		src: "package p\n\nimport (\n\t\"fmt\"\n\t\"strings\" // for Join\n\n\t\"golang.org/x/tools/txtar\"\n)\n\n" +
+			"func F() {}\n\nvar _ = fmt.Sprint(strings.Join, txtar.Parse)\n",
+		uses: []string{"sync/atomic", "golang.org/x/tools/go/ast/inspector"},
+		body: "var _ %s.Int64; var _ *%s.Inspector",
+		want: "package p\n\nimport (\n\t\"fmt\"\n\t\"strings\" // for Join\n\t\"sync/atomic\"\n\n" +
+			"\t\"golang.org/x/tools/go/ast/inspector\"\n\t\"golang.org/x/tools/txtar\"\n)\n",
+	}, {
+		name: "existing alias",
+		src:  "package p\n\nimport a \"sync/atomic\"\n\nfunc F() {}\n\nvar _ a.Int32\n",
+		uses: []string{"sync/atomic"},
+		body: "var n %s.Int64; n.Add(1)",
+		want: "package p\n\nimport a \"sync/atomic\"\n",
+	}, {
+		name: "name taken",
+		src:  "package p\n\nfunc F() {}\n\nvar atomic = 1\n",
+		uses: []string{"sync/atomic"},
+		body: "var n %s.Int64; n.Add(1)",
+		want: "package p\n\nimport syncatomic \"sync/atomic\"\n",
+	}, {
+		name: "drop unused",
+		src:  "package p\n\nimport (\n\t\"fmt\"\n\t\"time\"\n)\n\nfunc F() { time.Sleep(1) }\n\nvar _ = fmt.Sprint\n",
+		uses: []string{"sync/atomic"},
+		body: "var n %s.Int64; n.Add(1)",
+		want: "package p\n\nimport (\n\t\"fmt\"\n\t\"sync/atomic\"\n)\n",
+	}, {
+		name: "drop last",
+		src:  "package p\n\nimport \"time\"\n\nfunc F() { time.Sleep(1) }\n",
+		body: "println()",
+		want: "package p\n",
+	}, {
+		name: "keep used elsewhere",
+		src:  "package p\n\nimport \"time\"\n\nfunc F() { time.Sleep(1) }\n\nvar _ = time.Now\n",
+		body: "println()",
+		want: "package p\n\nimport \"time\"\n",
+	}, {
+		name: "loose comment",
+		src:  "package p\n\nimport (\n\t\"fmt\"\n\t// more to come\n)\n\nfunc F() {}\n\nvar _ = fmt.Sprint\n",
+		uses: []string{"sync/atomic"},
+		body: "var n %s.Int64; n.Add(1)",
+	}} {
+		t.Run(tc.name, func(t *testing.T) {
+			pass, err := typecheckGen(tc.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			file := pass.Files[0]
+			src, ok := readSource(pass, file)
+			if !ok {
+				t.Fatal("readSource failed")
+			}
+			var fn *ast.FuncDecl
+			for _, d := range file.Decls {
+				if d, ok := d.(*ast.FuncDecl); ok && d.Name.Name == "F" {
+					fn = d
+				}
+			}
+			im := newFixImports(pass, file, src)
+			body := tc.body
+			for _, path := range tc.uses {
+				body = strings.Replace(body, "%s", im.name(path), 1)
+			}
+			edits := []analysis.TextEdit{{Pos: fn.Body.Pos(), End: fn.Body.End(), NewText: []byte("{ " + body + " }")}}
+			more, ok := im.imports(edits)
+			if !ok {
+				if tc.want != "" {
+					t.Fatal("no import edits")
+				}
+				return
+			}
+			if tc.want == "" {
+				t.Fatalf("import edits %v, want none", more)
+			}
+			out := applyEdits(t, pass, tc.src, append(edits, more...))
+			if formatted, err := format.Source([]byte(out)); err != nil || string(formatted) != out {
+				t.Errorf("fixed file is not gofmt'd (%v):\n%s", err, out)
+			}
+			if got, _, _ := strings.Cut(out, "\nfunc"); got != tc.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+// applyEdits applies edits to src, the contents of the file of pass.
+func applyEdits(t *testing.T, pass *analysis.Pass, src string, edits []analysis.TextEdit) string {
+	t.Helper()
+	tok := pass.Fset.File(pass.Files[0].Pos())
+	slices.SortFunc(edits, func(a, b analysis.TextEdit) int { return int(b.Pos - a.Pos) })
+	for _, e := range edits {
+		src = src[:tok.Offset(e.Pos)] + string(e.NewText) + src[tok.Offset(e.End):]
+	}
+	return src
+}
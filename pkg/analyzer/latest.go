@@ -0,0 +1,168 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"slices"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// detectLatestValues finds channels of capacity one used as a store for
+// the latest value, a ConfigBroadcaster without a goroutine:
+//
+//	ch := make(chan Config, 1)
+//	ch <- initial
+//	update := func(c Config) {
+//	    select {
+//	    case <-ch:
+//	    default:
+//	    }
+//	    ch <- c
+//	}
+//
+// Every send replaces the value: it directly follows a receive from the
+// channel, which takes the old value out (a select with a default, that
+// drains it if it is there, or a plain receive, as a load that puts the
+// value back does), or it fills the channel in the statement after the one
+// that made it. An atomic.Pointer holds the value without the two
+// handoffs per update, and lets every reader see it instead of the one
+// that takes it.
+//
+// The channel is a local variable or a struct field, made with a capacity
+// of 1. Channels of empty structs are tokens, not values, and are left
+// out, and so are channels that are closed or ranged over. The make calls
+// are returned.
+func detectLatestValues(pass *analysis.Pass) []token.Pos {
+	made := map[*types.Var]token.Pos{} // capacity one, made once
+	again := map[*types.Var]bool{}     // made more than once
+	mark := func(lhs, rhs ast.Expr) {
+		mk, ok := ast.Unparen(rhs).(*ast.CallExpr)
+		if !ok {
+			return
+		}
+		if n, ok := makeChan(mk); !ok || len(mk.Args) != 2 || n != 1 {
+			return
+		}
+		obj := chanObj(pass, lhs)
+		if obj == nil {
+			return
+		}
+		if isSignal(obj.Type().Underlying().(*types.Chan)) {
+			return
+		}
+		if _, ok := made[obj]; ok {
+			again[obj] = true
+		}
+		made[obj] = mk.Pos()
+	}
+	for _, file := range pass.Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.AssignStmt:
+				if len(n.Lhs) == len(n.Rhs) {
+					for i := range n.Lhs {
+						mark(n.Lhs[i], n.Rhs[i])
+					}
+				}
+			case *ast.KeyValueExpr:
+				mark(n.Key, n.Value)
+			}
+			return true
+		})
+	}
+	if len(made) == 0 {
+		return nil
+	}
+
+	replaced := map[*types.Var]bool{} // sent on after a receive
+	bad := map[*types.Var]bool{}      // sent on otherwise, closed or ranged over
+	sends := func(list []ast.Stmt) {
+		for i, stmt := range list {
+			send, ok := stmt.(*ast.SendStmt)
+			if !ok {
+				continue
+			}
+			obj := chanObj(pass, send.Chan)
+			if _, ok := made[obj]; !ok {
+				continue
+			}
+			switch {
+			case i > 0 && takesFrom(pass, list[i-1], obj):
+				replaced[obj] = true
+			case i > 0 && makes(list[i-1], made[obj]):
+			default:
+				bad[obj] = true
+			}
+		}
+	}
+	for _, file := range pass.Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.BlockStmt:
+				sends(n.List)
+			case *ast.CaseClause:
+				sends(n.Body)
+			case *ast.CommClause:
+				sends(n.Body)
+				if send, ok := n.Comm.(*ast.SendStmt); ok {
+					bad[chanObj(pass, send.Chan)] = true
+				}
+			case *ast.CallExpr:
+				if id, ok := ast.Unparen(n.Fun).(*ast.Ident); ok && id.Name == "close" && len(n.Args) == 1 {
+					bad[chanObj(pass, n.Args[0])] = true
+				}
+			case *ast.RangeStmt:
+				bad[chanObj(pass, n.X)] = true
+			}
+			return true
+		})
+	}
+	var found []token.Pos
+	for obj, pos := range made {
+		if replaced[obj] && !bad[obj] && !again[obj] {
+			found = append(found, pos)
+		}
+	}
+	slices.Sort(found)
+	return found
+}
+
+// takesFrom reports whether stmt receives from the channel obj, as
+// `<-ch`, `v := <-ch` or a select that drains it if it holds a value.
+func takesFrom(pass *analysis.Pass, stmt ast.Stmt, obj *types.Var) bool {
+	recv := func(e ast.Expr) bool {
+		u, ok := ast.Unparen(e).(*ast.UnaryExpr)
+		return ok && u.Op == token.ARROW && chanObj(pass, u.X) == obj
+	}
+	switch s := stmt.(type) {
+	case *ast.ExprStmt:
+		return recv(s.X)
+	case *ast.AssignStmt:
+		return len(s.Rhs) == 1 && recv(s.Rhs[0])
+	case *ast.SelectStmt:
+		if len(s.Body.List) != 2 {
+			return false
+		}
+		var drains, skips bool
+		for _, c := range s.Body.List {
+			cc := c.(*ast.CommClause)
+			if len(cc.Body) != 0 {
+				return false
+			}
+			if cc.Comm == nil {
+				skips = true
+			} else if x := commRecv(cc.Comm); x != nil && chanObj(pass, x) == obj {
+				drains = true
+			}
+		}
+		return drains && skips
+	}
+	return false
+}
+
+// makes reports whether stmt contains the make call at pos.
+func makes(stmt ast.Stmt, pos token.Pos) bool {
+	return stmt.Pos() <= pos && pos < stmt.End()
+}
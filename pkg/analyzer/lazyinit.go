@@ -0,0 +1,290 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+// lazyInit is a package-level channel that an init goroutine fills once
+// and accessor functions read the value back from.
+type lazyInit struct {
+	makePos   token.Pos
+	pattern   Pattern // LazyInit, or Singleton or ConfigBroadcaster under sync.Once
+	init      *ast.FuncDecl
+	accessors []*ast.FuncDecl
+}
+
+// lazyChan tracks one package-level channel variable while its uses are
+// checked.
+type lazyChan struct {
+	makePos   token.Pos
+	buffered  bool
+	init      *ast.FuncDecl
+	parks     int // sends from an init goroutine
+	accessors map[*ast.FuncDecl]bool
+	resends   map[*ast.FuncDecl]bool
+	once      bool                  // filled from a sync.Once closure
+	taken     map[types.Object]bool // variables assigned a received value
+	updates   bool                  // an accessor sends a new value, not the one it took
+	bad       bool
+}
+
+// detectLazyInit scans the package for channel-based lazy initialization
+// of package state:
+//
+//	var cfgCh = make(chan *Config, 1)
+//
+//	func init() {
+//	    go func() { cfgCh <- load() }()
+//	}
+//
+//	func Get() *Config {
+//	    c := <-cfgCh
+//	    cfgCh <- c // park it again for the next caller
+//	    return c
+//	}
+//
+// The channel must be buffered, filled by exactly one send outside any
+// loop in a goroutine started by init, and otherwise only received from,
+// and re-filled by the functions that receive from it. Any other use
+// (close, select, range, passing it on) rules it out.
+//
+// The goroutine may also be started, and the channel made, by a
+// sync.Once:
+//
+//	func Get() *Config {
+//	    once.Do(func() {
+//	        go func() { cfgCh <- load() }()
+//	    })
+//	    c := <-cfgCh
+//	    cfgCh <- c
+//	    return c
+//	}
+//
+// The Once already synchronizes the first use, so the channel only adds a
+// handoff. It is reported as a Singleton, or as a ConfigBroadcaster if an
+// accessor parks a new value rather than the one it took.
+func detectLazyInit(pass *analysis.Pass) []lazyInit {
+	chans := map[types.Object]*lazyChan{}
+	track := func(id *ast.Ident, pos token.Pos, e ast.Expr) {
+		obj, ok := pass.TypesInfo.ObjectOf(id).(*types.Var)
+		if !ok {
+			return
+		}
+		if _, ok := obj.Type().Underlying().(*types.Chan); !ok {
+			return
+		}
+		if buf, ok := makeChan(e); ok {
+			chans[obj] = &lazyChan{
+				makePos:   pos,
+				buffered:  buf > 0,
+				accessors: map[*ast.FuncDecl]bool{},
+				resends:   map[*ast.FuncDecl]bool{},
+				taken:     map[types.Object]bool{},
+			}
+		}
+	}
+	onceLits := map[*ast.FuncLit]bool{}
+	for _, file := range pass.Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			if call, ok := n.(*ast.CallExpr); ok {
+				if lit := onceDo(pass, call); lit != nil {
+					onceLits[lit] = true
+				}
+			}
+			return true
+		})
+	}
+
+	var inits []*ast.FuncDecl
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			switch decl := decl.(type) {
+			case *ast.GenDecl:
+				if decl.Tok != token.VAR {
+					continue
+				}
+				for _, spec := range decl.Specs {
+					vs := spec.(*ast.ValueSpec)
+					if len(vs.Names) == len(vs.Values) {
+						for i, id := range vs.Names {
+							track(id, id.Pos(), vs.Values[i])
+						}
+					}
+				}
+			case *ast.FuncDecl:
+				if decl.Name.Name == "init" && decl.Recv == nil && decl.Body != nil {
+					inits = append(inits, decl)
+				}
+			}
+		}
+	}
+	// A channel declared without a value may be made at the top of init or
+	// of a sync.Once closure.
+	var bodies []*ast.BlockStmt
+	for _, fn := range inits {
+		bodies = append(bodies, fn.Body)
+	}
+	for lit := range onceLits {
+		bodies = append(bodies, lit.Body)
+	}
+	for _, body := range bodies {
+		for _, stmt := range body.List {
+			if s, ok := stmt.(*ast.AssignStmt); ok && s.Tok == token.ASSIGN {
+				if id, pos, _, ok := extractMakeChan(s); ok {
+					if obj := pass.TypesInfo.Uses[id]; obj != nil && obj.Parent() == pass.Pkg.Scope() {
+						track(id, pos, s.Rhs[0])
+					}
+				}
+			}
+		}
+	}
+	if len(chans) == 0 {
+		return nil
+	}
+
+	ins := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	ins.WithStack([]ast.Node{(*ast.Ident)(nil)}, func(n ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
+		id := n.(*ast.Ident)
+		c := chans[pass.TypesInfo.Uses[id]]
+		if c == nil {
+			return true
+		}
+		checkLazyUse(pass, c, id, stack, onceLits)
+		return true
+	})
+
+	var results []lazyInit
+	for _, c := range chans {
+		if c.bad || !c.buffered || c.parks != 1 || len(c.accessors) == 0 {
+			continue
+		}
+		if !subset(c.resends, c.accessors) {
+			continue // refilled by a function that never takes the value
+		}
+		li := lazyInit{makePos: c.makePos, pattern: LazyInit, init: c.init}
+		switch {
+		case c.once && c.updates:
+			li.pattern = ConfigBroadcaster
+		case c.once:
+			li.pattern = Singleton
+		}
+		for fn := range c.accessors {
+			li.accessors = append(li.accessors, fn)
+		}
+		results = append(results, li)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].makePos < results[j].makePos })
+	return results
+}
+
+// checkLazyUse classifies one use of a tracked channel; stack ends with id.
+// onceLits are the closures passed to sync.Once.Do.
+func checkLazyUse(pass *analysis.Pass, c *lazyChan, id *ast.Ident, stack []ast.Node, onceLits map[*ast.FuncLit]bool) {
+	var fn *ast.FuncDecl
+	inGo, inLoop, inOnce := false, false, false
+	for _, n := range stack {
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			fn = n
+		case *ast.FuncLit:
+			if onceLits[n] {
+				inOnce = true
+			}
+		case *ast.GoStmt:
+			inGo = true
+		case *ast.ForStmt, *ast.RangeStmt:
+			inLoop = true
+		case *ast.SelectStmt:
+			c.bad = true
+			return
+		}
+	}
+	if fn == nil {
+		c.bad = true // used in another package-level initializer
+		return
+	}
+	isInit := fn.Name.Name == "init" && fn.Recv == nil || inOnce
+
+	switch parent := stack[len(stack)-2].(type) {
+	case *ast.SendStmt:
+		if parent.Chan != id {
+			c.bad = true
+			return
+		}
+		switch {
+		case isInit && inGo && !inLoop:
+			c.parks++
+			c.init = fn
+			c.once = inOnce
+		case !isInit && !inGo:
+			c.resends[fn] = true
+			if v, ok := ast.Unparen(parent.Value).(*ast.Ident); !ok || !c.taken[pass.TypesInfo.Uses[v]] {
+				c.updates = true
+			}
+		default:
+			c.bad = true
+		}
+	case *ast.UnaryExpr:
+		if parent.Op != token.ARROW || isInit || inGo {
+			c.bad = true
+			return
+		}
+		c.accessors[fn] = true
+		if as, ok := stack[len(stack)-3].(*ast.AssignStmt); ok && len(as.Lhs) == 1 {
+			if v, ok := as.Lhs[0].(*ast.Ident); ok && pass.TypesInfo.ObjectOf(v) != nil {
+				c.taken[pass.TypesInfo.ObjectOf(v)] = true
+			}
+		}
+	case *ast.AssignStmt:
+		// The make in init, already recorded.
+		if !isInit || len(parent.Lhs) != 1 || parent.Lhs[0] != id {
+			c.bad = true
+		}
+	default:
+		c.bad = true
+	}
+}
+
+// onceDo returns the closure passed to a call of Do on a sync.Once, or nil.
+func onceDo(pass *analysis.Pass, call *ast.CallExpr) *ast.FuncLit {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Do" || len(call.Args) != 1 {
+		return nil
+	}
+	t := pass.TypesInfo.TypeOf(sel.X)
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	if t == nil || !isNamed(t, "sync", "Once") {
+		return nil
+	}
+	lit, _ := call.Args[0].(*ast.FuncLit)
+	return lit
+}
+
+// onceNote tells how to replace a channel filled under a sync.Once.
+func onceNote(pat Pattern) string {
+	if pat == ConfigBroadcaster {
+		return "sync.Once already synchronizes the first load: drop the channel and goroutine, load the value in once.Do and Store updates in an atomic.Pointer"
+	}
+	return "sync.Once already synchronizes the first use: drop the channel and goroutine and assign the value inside once.Do"
+}
+
+func subset(a, b map[*ast.FuncDecl]bool) bool {
+	for k := range a {
+		if !b[k] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,195 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// detectMemoCaches finds goroutines that serve cached results over a
+// request channel, looking each request up in a map by its key:
+//
+//	type request struct {
+//	    key   string
+//	    reply chan int
+//	}
+//
+//	func (m *Memo) serve() {
+//	    cache := map[string]int{}
+//	    for req := range m.requests {
+//	        v, ok := cache[req.key]
+//	        if !ok {
+//	            v = m.f(req.key)
+//	            cache[req.key] = v
+//	        }
+//	        req.reply <- v
+//	    }
+//	}
+//
+// Every lookup, hit or miss, costs two channel handoffs and waits behind
+// every miss computed before it. A map guarded by a sync.Mutex, or a
+// sync.Map, serves hits in the caller; singleflight, or a sync.OnceValue
+// per entry, keeps computing each miss once.
+//
+// The goroutine is a function literal, or a function or method of the
+// package, started with go. A statement of its body ranges over a channel of
+// requests, structs or pointers to structs with a channel field for the
+// reply. In the loop, a map declared outside it is read and written at an
+// index computed from the request, and the request's reply channel is
+// used, to send on or to hand to another function. The go statements are
+// returned.
+func detectMemoCaches(pass *analysis.Pass) []token.Pos {
+	funcs := map[*types.Func]*ast.FuncDecl{} // methods too, for go m.serve()
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Body != nil {
+				if obj, ok := pass.TypesInfo.Defs[fn.Name].(*types.Func); ok {
+					funcs[obj] = fn
+				}
+			}
+		}
+	}
+	var found []token.Pos
+	for _, file := range pass.Files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			g, ok := n.(*ast.GoStmt)
+			if !ok {
+				return true
+			}
+			var body *ast.BlockStmt
+			switch fun := ast.Unparen(g.Call.Fun).(type) {
+			case *ast.FuncLit:
+				body = fun.Body
+			default:
+				if f, ok := typeutil.Callee(pass.TypesInfo, g.Call).(*types.Func); ok && funcs[f] != nil {
+					body = funcs[f].Body
+				}
+			}
+			if body == nil {
+				return true
+			}
+			for _, stmt := range body.List {
+				if rng, ok := stmt.(*ast.RangeStmt); ok && servesFromCache(pass, rng) {
+					found = append(found, g.Pos())
+					break
+				}
+			}
+			return true
+		})
+	}
+	return found
+}
+
+// servesFromCache reports whether rng ranges over a channel of requests
+// with a reply channel, and its body reads and writes a map declared
+// outside it at a key taken from the request and uses the reply channel.
+func servesFromCache(pass *analysis.Pass, rng *ast.RangeStmt) bool {
+	t := pass.TypesInfo.TypeOf(rng.X)
+	if t == nil {
+		return false
+	}
+	ch, ok := t.Underlying().(*types.Chan)
+	if !ok || !hasReplyField(ch.Elem()) {
+		return false
+	}
+	id, ok := rng.Key.(*ast.Ident)
+	if !ok || pass.TypesInfo.Defs[id] == nil {
+		return false
+	}
+	req := pass.TypesInfo.Defs[id]
+	fromReq := func(e ast.Expr) bool {
+		found := false
+		ast.Inspect(e, func(n ast.Node) bool {
+			if id, ok := n.(*ast.Ident); ok && pass.TypesInfo.Uses[id] == req {
+				found = true
+			}
+			return !found
+		})
+		return found
+	}
+	// cacheMap returns the map variable or field that ix indexes by a key
+	// from the request, if it is declared outside the loop.
+	cacheMap := func(ix *ast.IndexExpr) types.Object {
+		mt := pass.TypesInfo.TypeOf(ix.X)
+		if mt == nil || !fromReq(ix.Index) {
+			return nil
+		}
+		if _, ok := mt.Underlying().(*types.Map); !ok {
+			return nil
+		}
+		var obj types.Object
+		switch x := ast.Unparen(ix.X).(type) {
+		case *ast.Ident:
+			obj = pass.TypesInfo.Uses[x]
+		case *ast.SelectorExpr:
+			obj = pass.TypesInfo.Uses[x.Sel]
+		}
+		if obj == nil || encloses(rng, posNode(obj.Pos())) {
+			return nil
+		}
+		return obj
+	}
+	stores := map[ast.Expr]bool{}
+	ast.Inspect(rng.Body, func(n ast.Node) bool {
+		if as, ok := n.(*ast.AssignStmt); ok && as.Tok != token.DEFINE {
+			for _, lhs := range as.Lhs {
+				stores[ast.Unparen(lhs)] = true
+			}
+		}
+		return true
+	})
+	written := map[types.Object]bool{}
+	read := map[types.Object]bool{}
+	replies := false
+	ast.Inspect(rng.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.IndexExpr:
+			if obj := cacheMap(n); obj != nil {
+				if stores[n] {
+					written[obj] = true
+				} else {
+					read[obj] = true
+				}
+			}
+		case *ast.SelectorExpr:
+			if x, ok := ast.Unparen(n.X).(*ast.Ident); ok && pass.TypesInfo.Uses[x] == req {
+				if ft := pass.TypesInfo.TypeOf(n); ft != nil {
+					if _, ok := ft.Underlying().(*types.Chan); ok {
+						replies = true
+					}
+				}
+			}
+		}
+		return true
+	})
+	if !replies {
+		return false
+	}
+	for obj := range written {
+		if read[obj] {
+			return true
+		}
+	}
+	return false
+}
+
+// hasReplyField reports whether t is a struct, or a pointer to one, with a
+// field of channel type.
+func hasReplyField(t types.Type) bool {
+	if p, ok := t.Underlying().(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	st, ok := t.Underlying().(*types.Struct)
+	if !ok {
+		return false
+	}
+	for i := range st.NumFields() {
+		if _, ok := st.Field(i).Type().Underlying().(*types.Chan); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package analyzer
+
+import (
+	"fmt"
+	"sync"
+)
+
+var (
+	overridesMu sync.RWMutex
+	overrides   = map[Pattern]PatternSpec{}
+)
+
+// Override changes the metadata reported for p, for example to point
+// Replacement at an internal wrapper library. Empty fields of spec keep
+// the Registry value. Registry itself is not modified and should be
+// treated as read-only; Override is safe to call concurrently with
+// analysis, which picks up the change for diagnostics reported after it.
+func Override(p Pattern, spec PatternSpec) error {
+	if _, ok := Registry[p]; !ok {
+		return fmt.Errorf("chanopt: cannot override unregistered pattern %s", p)
+	}
+	overridesMu.Lock()
+	defer overridesMu.Unlock()
+	overrides[p] = spec
+	return nil
+}
+
+// ResetOverrides discards every Override.
+func ResetOverrides() {
+	overridesMu.Lock()
+	defer overridesMu.Unlock()
+	clear(overrides)
+}
+
+// Spec returns the metadata reported for p: its Registry entry with any
+// Override applied.
+func Spec(p Pattern) PatternSpec {
+	spec := Registry[p]
+	overridesMu.RLock()
+	o, ok := overrides[p]
+	overridesMu.RUnlock()
+	if !ok {
+		return spec
+	}
+	if o.Replacement != "" {
+		spec.Replacement = o.Replacement
+	}
+	if o.Speedup != "" {
+		spec.Speedup = o.Speedup
+	}
+	if o.Rationale != "" {
+		spec.Rationale = o.Rationale
+	}
+	return spec
+}
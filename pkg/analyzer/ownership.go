@@ -0,0 +1,209 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// ownership describes what a producer's channel hands over besides a
+// value: references to memory that a receiver may then treat as its own.
+type ownership struct {
+	mutable string // the element type, if it holds slices, maps, pointers or interfaces
+	reused  string // a variable the goroutine keeps writing to after sending it
+}
+
+// transfersOwnership inspects the element type of cp's channel and what
+// the goroutine does with the values it sends. A channel of []byte or
+// *Request does more than deliver a value: it hands the receiver memory
+// that the sender no longer touches. The sync.Once value, atomic.Pointer
+// or guarded counter suggested in its place shares that memory between
+// every caller instead, which changes aliasing.
+//
+// Worse, a goroutine that keeps writing to what it sent,
+//
+//	buf := make([]byte, 512)
+//	for {
+//	    n := fill(buf)
+//	    ch <- buf[:n]
+//	}
+//
+// relies on the channel's handoff to order those writes after the
+// receiver's reads, and no replacement preserves that.
+func transfersOwnership(pass *analysis.Pass, cp channelProducer) ownership {
+	var own ownership
+	if cp.chanType == nil || !holdsReferences(cp.chanType.Elem(), 0) {
+		return own
+	}
+	own.mutable = types.TypeString(cp.chanType.Elem(), types.RelativeTo(pass.Pkg))
+	body := cp.funcLit.Body
+	for _, send := range cp.sends {
+		id := sentRoot(send.Value)
+		if id == nil {
+			continue
+		}
+		v, ok := pass.TypesInfo.Uses[id].(*types.Var)
+		if !ok {
+			continue
+		}
+		// A write after the send, or earlier in a loop that the variable
+		// outlives, reaches memory already sent.
+		loop := innermostLoop(body, send)
+		inSendLoop := func(pos token.Pos) bool { return loop != nil && loop.Pos() <= pos && pos < loop.End() }
+		for _, w := range contentWrites(pass, body, v) {
+			if w > send.End() || inSendLoop(w) && !inSendLoop(v.Pos()) {
+				own.reused = v.Name()
+				return own
+			}
+		}
+	}
+	return own
+}
+
+// holdsReferences reports whether values of t refer to memory they share
+// with their copies: slices, maps, pointers and interfaces other than
+// error, directly or in struct fields and array elements.
+func holdsReferences(t types.Type, depth int) bool {
+	if depth > 3 || types.Identical(t, types.Universe.Lookup("error").Type()) {
+		return false
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Slice, *types.Map, *types.Pointer, *types.Interface:
+		return true
+	case *types.Array:
+		return holdsReferences(u.Elem(), depth+1)
+	case *types.Struct:
+		for i := range u.NumFields() {
+			if holdsReferences(u.Field(i).Type(), depth+1) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sentRoot returns the variable a sent value is taken from, as in
+// `ch <- buf`, `ch <- buf[:n]`, `ch <- &req` or `ch <- st.items`.
+func sentRoot(e ast.Expr) *ast.Ident {
+	e = ast.Unparen(e)
+	if u, ok := e.(*ast.UnaryExpr); ok && u.Op == token.AND {
+		e = u.X
+	}
+	if s, ok := ast.Unparen(e).(*ast.SliceExpr); ok {
+		e = s.X
+	}
+	return rootIdent(e)
+}
+
+// innermostLoop returns the innermost loop of body around send, or nil.
+func innermostLoop(body *ast.BlockStmt, send *ast.SendStmt) ast.Node {
+	var loop ast.Node
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.ForStmt, *ast.RangeStmt:
+			if n.Pos() <= send.Pos() && send.End() <= n.End() {
+				loop = n
+			}
+		case *ast.FuncLit:
+			return false
+		}
+		return true
+	})
+	return loop
+}
+
+// contentWrites returns the positions in body that may write to the
+// memory v refers to rather than to v itself: assignments through an
+// index, pointer or pointer field, appends into it, calls of pointer
+// methods on it, and calls of functions, copy, clear or delete that are
+// passed it.
+func contentWrites(pass *analysis.Pass, body *ast.BlockStmt, v *types.Var) []token.Pos {
+	var writes []token.Pos
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.AssignStmt:
+			for i, lhs := range n.Lhs {
+				if reachesMemory(pass, lhs, v) {
+					writes = append(writes, n.Pos())
+				}
+				if len(n.Lhs) == len(n.Rhs) && isObj(pass, lhs, v) {
+					if call, ok := ast.Unparen(n.Rhs[i]).(*ast.CallExpr); ok && builtinName(pass, call) == "append" && isObj(pass, call.Args[0], v) {
+						writes = append(writes, n.Pos())
+					}
+				}
+			}
+		case *ast.IncDecStmt:
+			if reachesMemory(pass, n.X, v) {
+				writes = append(writes, n.Pos())
+			}
+		case *ast.CallExpr:
+			if sel, ok := n.Fun.(*ast.SelectorExpr); ok && isObj(pass, sel.X, v) {
+				if s, ok := pass.TypesInfo.Selections[sel]; ok && s.Kind() == types.MethodVal {
+					if _, ptr := s.Obj().Type().(*types.Signature).Recv().Type().(*types.Pointer); ptr {
+						writes = append(writes, n.Pos())
+					}
+				}
+			}
+			switch builtinName(pass, n) {
+			case "copy", "clear", "delete":
+			case "":
+				if tv, ok := pass.TypesInfo.Types[n.Fun]; ok && tv.IsType() {
+					return true // a conversion
+				}
+			default:
+				return true // len, append, ...: reads only
+			}
+			for _, arg := range n.Args {
+				if id := sentRoot(arg); id != nil && pass.TypesInfo.Uses[id] == v {
+					writes = append(writes, n.Pos())
+				}
+			}
+		}
+		return true
+	})
+	return writes
+}
+
+// reachesMemory reports whether e denotes memory that v refers to, such
+// as buf[i], p.field with p a pointer, or *p, rather than v or a field of
+// a struct value held in v.
+func reachesMemory(pass *analysis.Pass, e ast.Expr, v *types.Var) bool {
+	indirect := false
+	for {
+		switch x := ast.Unparen(e).(type) {
+		case *ast.Ident:
+			return indirect && pass.TypesInfo.Uses[x] == v
+		case *ast.SelectorExpr:
+			if sel, ok := pass.TypesInfo.Selections[x]; ok && sel.Indirect() {
+				indirect = true
+			}
+			e = x.X
+		case *ast.IndexExpr:
+			if t := pass.TypesInfo.TypeOf(x.X); t != nil {
+				if _, ok := t.Underlying().(*types.Array); !ok {
+					indirect = true
+				}
+			}
+			e = x.X
+		case *ast.StarExpr:
+			indirect = true
+			e = x.X
+		default:
+			return false
+		}
+	}
+}
+
+// builtinName returns the name of the builtin function call calls, or "".
+func builtinName(pass *analysis.Pass, call *ast.CallExpr) string {
+	id, ok := ast.Unparen(call.Fun).(*ast.Ident)
+	if !ok {
+		return ""
+	}
+	if b, ok := pass.TypesInfo.Uses[id].(*types.Builtin); ok {
+		return b.Name()
+	}
+	return ""
+}
@@ -4,29 +4,61 @@
 // synchronization primitives (atomic, mutex, sync.Once).
 package analyzer
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // Pattern represents a detected channel usage anti-pattern.
+//
+// The numeric value and the name of a pattern are stable across releases,
+// so configs, baselines and dashboards may store either. New patterns get
+// the next unused number. A pattern that is removed or renamed keeps its
+// number, which is never reused, and its old name stays accepted by
+// ParsePattern for at least two minor releases after the change is
+// announced in the release notes.
 type Pattern int
 
 const (
-	Unknown Pattern = iota
-	IDGenerator
-	RoundRobin
-	RateLimiter
-	ConfigBroadcaster
-	BoundedIterator
-	CircuitBreaker
-	ChanSemaphore
-	Singleton
-	FixedFanIn
-	ChanTicker
+	Unknown           Pattern = 0
+	IDGenerator       Pattern = 1
+	RoundRobin        Pattern = 2
+	RateLimiter       Pattern = 3
+	ConfigBroadcaster Pattern = 4
+	BoundedIterator   Pattern = 5
+	CircuitBreaker    Pattern = 6
+	ChanSemaphore     Pattern = 7
+	Singleton         Pattern = 8
+	FixedFanIn        Pattern = 9
+	ChanTicker        Pattern = 10
+	FanOutDispatcher  Pattern = 11
+	LazyInit          Pattern = 12
+	ResultCollector   Pattern = 13
+	PingPong          Pattern = 14
+	AggregatorQueue   Pattern = 15
+	ShardedDispatcher Pattern = 16
+	MemoCache         Pattern = 17
+	ErrorIterator     Pattern = 18
 )
 
 var patternNames = [...]string{
 	"Unknown", "IDGenerator", "RoundRobin", "RateLimiter",
 	"ConfigBroadcaster", "BoundedIterator", "CircuitBreaker",
 	"ChanSemaphore", "Singleton", "FixedFanIn", "ChanTicker",
+	"FanOutDispatcher", "LazyInit", "ResultCollector", "PingPong",
+	"AggregatorQueue", "ShardedDispatcher", "MemoCache",
+	"ErrorIterator",
+}
+
+// ParsePattern returns the pattern with the given name, as returned by
+// String, ignoring case. Unknown and unrecognized names are an error.
+func ParsePattern(s string) (Pattern, error) {
+	for p := IDGenerator; int(p) < len(patternNames); p++ {
+		if strings.EqualFold(s, patternNames[p]) {
+			return p, nil
+		}
+	}
+	return Unknown, fmt.Errorf("chanopt: unknown pattern %q", s)
 }
 
 func (p Pattern) String() string {
@@ -36,72 +68,138 @@ func (p Pattern) String() string {
 	return "Unknown"
 }
 
+// Detectable reports whether the classifier can report p. CircuitBreaker,
+// ChanSemaphore and FixedFanIn are in the Registry for their metadata, but
+// no classifier branch recognizes them yet, so tools that expect every
+// pattern to be found, such as the self-test, exempt them.
+func Detectable(p Pattern) bool {
+	switch p {
+	case CircuitBreaker, ChanSemaphore, FixedFanIn:
+		return false
+	}
+	return p != Unknown
+}
+
+// Checks lists the diagnostic categories reported besides the Registry
+// patterns, and whether each is on by default.
+var Checks = map[string]bool{
+	"BusyPoll":       true,
+	"StopLeak":       true,
+	"OrDone":         true,
+	"Merge":          true,
+	"ErrGroup":       true,
+	"ContextStop":    true,
+	"SendAfterClose": true,
+	"PipelineStage":  false, // -pipeline
+}
+
 // PatternSpec holds the replacement metadata for a detected pattern.
 type PatternSpec struct {
 	Replacement string // e.g. "sync/atomic.AddInt64"
-	Speedup     string // e.g. "~38x"
+	Speedup     string // e.g. "~7.3x"; in Registry, from the measured Costs
 	Rationale   string // one-line explanation
 }
 
-// Registry is the single source of truth for all pattern metadata.
+// Registry is the single source of truth for all pattern metadata. It is
+// read-only; embedders customize the reported metadata with Override.
 var Registry = map[Pattern]PatternSpec{
 	IDGenerator: {
-		"atomic.AddInt64",
-		"~38x",
-		"counter in infinite loop needs only an atomic increment",
+		Replacement: "atomic.AddInt64",
+		Rationale:   "counter in infinite loop needs only an atomic increment",
 	},
 	RoundRobin: {
-		"sync.Mutex + index",
-		"~10x",
-		"modular index cycling needs only a guarded counter",
+		Replacement: "sync.Mutex + index",
+		Rationale:   "modular index cycling needs only a guarded counter",
 	},
 	RateLimiter: {
-		"sync.Mutex + token bucket",
-		"~8x",
-		"ticker-refilled token slot needs only mutex-guarded math",
+		Replacement: "sync.Mutex + token bucket",
+		Rationale:   "ticker-refilled token slot needs only mutex-guarded math",
 	},
 	ConfigBroadcaster: {
-		"atomic.Pointer / atomic.Value",
-		"~80x",
-		"latest-value store needs only an atomic pointer swap",
+		Replacement: "atomic.Pointer / atomic.Value",
+		Rationale:   "latest-value store needs only an atomic pointer swap",
 	},
 	BoundedIterator: {
-		"range-over-func (Go 1.23+) or Next() iterator",
-		"~40x",
-		"finite iteration needs no goroutine or channel",
+		Replacement: "range-over-func (Go 1.23+) or Next() iterator",
+		Rationale:   "finite iteration needs no goroutine or channel",
 	},
 	CircuitBreaker: {
-		"atomic.Int32",
-		"~127x",
-		"state enum in buffered chan(1) needs only an atomic int",
+		Replacement: "atomic.Int32",
+		Rationale:   "state enum in buffered chan(1) needs only an atomic int",
 	},
 	ChanSemaphore: {
-		"x/sync/semaphore.Weighted",
-		"~8x",
-		"concurrency limiting chan struct{} is slower than semaphore",
+		Replacement: "x/sync/semaphore.Weighted",
+		Rationale:   "concurrency limiting chan struct{} is slower than semaphore",
 	},
 	Singleton: {
-		"sync.Once + value field",
-		"~19x",
-		"one-time value served via channel needs only sync.Once",
+		Replacement: "sync.Once + value field",
+		Rationale:   "one-time value served via channel needs only sync.Once",
 	},
 	FixedFanIn: {
-		"sync.WaitGroup + append to slice",
-		"~8x",
-		"merging 2-3 fixed goroutines doesn't need a shared channel",
+		Replacement: "sync.WaitGroup + append to slice",
+		Rationale:   "merging 2-3 fixed goroutines doesn't need a shared channel",
 	},
 	ChanTicker: {
-		"time.NewTicker directly",
-		"~15x",
-		"wrapping time.Sleep in goroutine+channel duplicates time.Ticker",
+		Replacement: "time.NewTicker directly",
+		Rationale:   "wrapping time.Sleep in goroutine+channel duplicates time.Ticker",
+	},
+	FanOutDispatcher: {
+		Replacement: "direct loop or replacements.ParallelFor",
+		Rationale:   "channel handoff dominates fixed worker pools doing trivial work",
+	},
+	LazyInit: {
+		Replacement: "sync.OnceValue",
+		Rationale:   "value computed once at init and parked in a channel needs only sync.OnceValue",
+	},
+	ResultCollector: {
+		Replacement: "errgroup + indexed slice writes",
+		Rationale:   "one result per goroutine can be written to its own slice index",
+	},
+	PingPong: {
+		Replacement: "sync.Mutex-guarded struct",
+		Rationale:   "two goroutines taking turns over a channel pair need only a mutex around the shared state",
+	},
+	AggregatorQueue: {
+		Replacement: "sync.Mutex-guarded state or per-goroutine shards",
+		Rationale:   "a single consumer folding each value into state serializes what a mutex or sharding does in the sender",
+	},
+	ShardedDispatcher: {
+		Replacement: "sync.Mutex per shard, picked by key in the sender",
+		Rationale:   "a dispatcher routing each value to a per-shard goroutine serializes what a mutex per shard does in the sender",
+	},
+	MemoCache: {
+		Replacement: "sync.Mutex-guarded map (or sync.Map) + singleflight for misses",
+		Rationale:   "a goroutine serving cached results over a request channel makes every hit wait behind every miss",
+	},
+	ErrorIterator: {
+		Replacement: "iter.Seq2[T, error] (Go 1.23+)",
+		Rationale:   "a finite stream of values and their errors needs no goroutine or channel, only a yield of each pair",
 	},
 }
 
 func init() {
-	// Compile-time guarantee: every non-Unknown pattern has a spec.
-	for p := IDGenerator; p <= ChanTicker; p++ {
-		if _, ok := Registry[p]; !ok {
+	// Compile-time guarantee: every non-Unknown pattern has a spec and a cost.
+	for p := IDGenerator; p <= ErrorIterator; p++ {
+		spec, ok := Registry[p]
+		if !ok {
 			panic(fmt.Sprintf("chanopt: pattern %d (%s) missing from Registry", p, p))
 		}
+		m, ok := Costs[p]
+		if !ok {
+			panic(fmt.Sprintf("chanopt: pattern %d (%s) missing from Costs", p, p))
+		}
+		// The speedup is the measured one, so that it cannot drift from
+		// the cost model the other figures of a diagnostic come from.
+		spec.Speedup = formatSpeedup(m.Speedup())
+		Registry[p] = spec
+	}
+}
+
+// formatSpeedup formats a speedup as diagnostics state it: "~213x", or
+// with one decimal below 10, as in "~2.3x".
+func formatSpeedup(x float64) string {
+	if x < 10 {
+		return fmt.Sprintf("~%.1fx", x)
 	}
+	return fmt.Sprintf("~%.0fx", x)
 }
@@ -0,0 +1,247 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// detectPingPongs scans a file for two goroutines taking turns over a pair
+// of unbuffered channels:
+//
+//	req, resp := make(chan int), make(chan int)
+//	go func() {
+//	    total := 0
+//	    for n := range req {
+//	        total += n
+//	        resp <- total
+//	    }
+//	}()
+//	for _, n := range items {
+//	    req <- n
+//	    sum = <-resp
+//	}
+//
+// Each send on req is immediately followed by a receive from resp, so the
+// two goroutines never run at the same time: the channels only pass the
+// turn back and forth. The state the server goroutine owns can be a struct
+// whose method does the work under a sync.Mutex, without two goroutine
+// handoffs per exchange.
+//
+// The server must be the only goroutine started with the channels. Its
+// loop receives from req first, sends on resp last and uses neither
+// channel in between. Outside it, req may only be sent on (and closed) and
+// resp only received from, in that alternation, and not from any other
+// function literal.
+func detectPingPongs(pass *analysis.Pass, file *ast.File) []token.Pos {
+	var found []token.Pos
+	ast.Inspect(file, func(n ast.Node) bool {
+		block, ok := n.(*ast.BlockStmt)
+		if !ok {
+			return true
+		}
+		chans := map[types.Object]token.Pos{} // unbuffered, made in block
+		for i, stmt := range block.List {
+			if as, ok := stmt.(*ast.AssignStmt); ok && as.Tok == token.DEFINE {
+				// Both one channel per statement and the pair made in
+				// one, req, resp := make(chan T), make(chan T).
+				for j, lhs := range as.Lhs {
+					id, ok := lhs.(*ast.Ident)
+					if !ok || len(as.Rhs) != len(as.Lhs) {
+						break
+					}
+					if _, ok := makeChan(as.Rhs[j]); !ok || !unbuffered(pass, as.Rhs[j].(*ast.CallExpr)) {
+						continue
+					}
+					if obj := pass.TypesInfo.Defs[id]; obj != nil {
+						chans[obj] = id.Pos()
+					}
+				}
+				continue
+			}
+			g, ok := stmt.(*ast.GoStmt)
+			if !ok {
+				continue
+			}
+			lit, ok := g.Call.Fun.(*ast.FuncLit)
+			if !ok {
+				continue
+			}
+			req, resp := serverLoop(pass, lit.Body, chans)
+			if req == nil || req == resp {
+				continue
+			}
+			rest := block.List[i+1:]
+			outside := 0 // uses before the goroutine or after the block
+			for _, obj := range []types.Object{req, resp} {
+				outside += countUses(pass, file, obj) - countUses(pass, lit, obj)
+				for _, stmt := range rest {
+					outside -= countUses(pass, stmt, obj)
+				}
+			}
+			if outside == 0 && alternates(pass, rest, req, resp) {
+				found = append(found, chans[req])
+			}
+		}
+		return true
+	})
+	return found
+}
+
+// unbuffered reports whether the make call creates an unbuffered channel.
+func unbuffered(pass *analysis.Pass, mk *ast.CallExpr) bool {
+	if len(mk.Args) == 1 {
+		return true
+	}
+	n, ok := constInt(pass, mk.Args[1])
+	return ok && n == 0
+}
+
+// serverLoop returns the request and response channels of a goroutine
+// body consisting of one loop that receives a request first and sends a
+// response last, both on channels in chans, and uses neither in between.
+func serverLoop(pass *analysis.Pass, body *ast.BlockStmt, chans map[types.Object]token.Pos) (req, resp types.Object) {
+	var loop ast.Stmt
+	for _, stmt := range body.List {
+		switch stmt.(type) {
+		case *ast.ForStmt, *ast.RangeStmt:
+			if loop != nil {
+				return nil, nil
+			}
+			loop = stmt
+		}
+	}
+	var stmts []ast.Stmt
+	switch l := loop.(type) {
+	case *ast.ForStmt:
+		if l.Cond != nil || len(l.Body.List) < 3 {
+			return nil, nil
+		}
+		req = recvFrom(pass, l.Body.List[0])
+		stmts = l.Body.List[1:]
+	case *ast.RangeStmt:
+		if id, ok := ast.Unparen(l.X).(*ast.Ident); ok {
+			req = pass.TypesInfo.Uses[id]
+		}
+		stmts = l.Body.List
+		if len(stmts) < 2 {
+			return nil, nil
+		}
+	default:
+		return nil, nil
+	}
+	if _, ok := chans[req]; !ok {
+		return nil, nil
+	}
+	send, ok := stmts[len(stmts)-1].(*ast.SendStmt)
+	if !ok {
+		return nil, nil
+	}
+	if id, ok := ast.Unparen(send.Chan).(*ast.Ident); ok {
+		resp = pass.TypesInfo.Uses[id]
+	}
+	if _, ok := chans[resp]; !ok || refersTo(pass, send.Value, resp) || refersTo(pass, send.Value, req) {
+		return nil, nil
+	}
+	// The loop's own receive and send are the only uses in the goroutine.
+	if countUses(pass, body, req) != 1 || countUses(pass, body, resp) != 1 {
+		return nil, nil
+	}
+	return req, resp
+}
+
+// recvFrom returns the channel that stmt receives from, as in `<-ch`,
+// `v := <-ch` or `sum += <-ch`, or nil.
+func recvFrom(pass *analysis.Pass, stmt ast.Stmt) types.Object {
+	var e ast.Expr
+	switch s := stmt.(type) {
+	case *ast.ExprStmt:
+		e = s.X
+	case *ast.AssignStmt:
+		if len(s.Rhs) != 1 {
+			return nil
+		}
+		e = s.Rhs[0]
+	}
+	u, ok := ast.Unparen(e).(*ast.UnaryExpr)
+	if !ok || u.Op != token.ARROW {
+		return nil
+	}
+	id, ok := ast.Unparen(u.X).(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	return pass.TypesInfo.Uses[id]
+}
+
+// receives reports whether stmt receives from ch, as in `v := <-ch`,
+// `out = append(out, <-ch)` or `if <-ch {`, and uses ch nowhere else.
+func receives(pass *analysis.Pass, stmt ast.Stmt, ch types.Object) bool {
+	found := false
+	ast.Inspect(stmt, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.UnaryExpr:
+			if n.Op == token.ARROW && isObj(pass, n.X, ch) {
+				found = true
+			}
+		}
+		return true
+	})
+	return found && countUses(pass, stmt, ch) == 1
+}
+
+// alternates reports whether every use of req and resp in stmts, at any
+// depth, is a send on req whose next statement receives from resp, or a
+// close of req, with at least one exchange.
+func alternates(pass *analysis.Pass, stmts []ast.Stmt, req, resp types.Object) bool {
+	uses, exchanges, closes := 0, 0, 0
+	for _, stmt := range stmts {
+		uses += countUses(pass, stmt, req) + countUses(pass, stmt, resp)
+	}
+	escapes := false
+	var walk func(list []ast.Stmt)
+	walk = func(list []ast.Stmt) {
+		for i, stmt := range list {
+			if s, ok := stmt.(*ast.SendStmt); ok && isObj(pass, s.Chan, req) {
+				if i+1 < len(list) && receives(pass, list[i+1], resp) && !refersTo(pass, s.Value, resp) {
+					exchanges++
+				}
+				continue
+			}
+			if s, ok := stmt.(*ast.ExprStmt); ok {
+				if call, ok := s.X.(*ast.CallExpr); ok && isCloseOf(call, func(e ast.Expr) bool { return isObj(pass, e, req) }) {
+					closes++
+					continue
+				}
+			}
+			ast.Inspect(stmt, func(n ast.Node) bool {
+				switch n := n.(type) {
+				case *ast.FuncLit:
+					if refersTo(pass, n, req) || refersTo(pass, n, resp) {
+						escapes = true
+					}
+					return false
+				case *ast.BlockStmt:
+					walk(n.List)
+					return false
+				case *ast.CaseClause:
+					walk(n.Body)
+					return false
+				case *ast.CommClause:
+					if n.Comm != nil && (refersTo(pass, n.Comm, req) || refersTo(pass, n.Comm, resp)) {
+						escapes = true // a select makes the turn-taking conditional
+					}
+					walk(n.Body)
+					return false
+				}
+				return true
+			})
+		}
+	}
+	walk(stmts)
+	return !escapes && exchanges > 0 && uses == 2*exchanges+closes
+}
@@ -0,0 +1,246 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// pipelineStages enables reportPipelineStages. It is off by default: a
+// goroutine per stage is sometimes wanted for reasons the analyzer cannot
+// see, so these findings are advisory.
+var pipelineStages bool
+
+func init() {
+	Analyzer.Flags.BoolVar(&pipelineStages, "pipeline", false,
+		"also report pipeline stages with no concurrency benefit (advisory)")
+}
+
+// reportPipelineStages flags calls of trivial pipeline stages whose input
+// is produced and whose output is consumed in the calling function:
+//
+//	func square(in <-chan int) <-chan int {
+//	    out := make(chan int)
+//	    go func() {
+//	        defer close(out)
+//	        for v := range in { out <- v * v }
+//	    }()
+//	    return out
+//	}
+//
+//	for v := range square(gen(nums)) { ... }
+//
+// Every value crosses two channels and a goroutine only to be squared;
+// fusing the stage into the consumer loop, or composing iter.Seq
+// functions, keeps the same structure without the handoffs.
+func reportPipelineStages(pass *analysis.Pass) {
+	stages := map[*types.Func]bool{}
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && isTrivialStage(pass, fn) {
+				if obj, ok := pass.TypesInfo.Defs[fn.Name].(*types.Func); ok {
+					stages[obj] = true
+				}
+			}
+		}
+	}
+	if len(stages) == 0 {
+		return
+	}
+
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil {
+				continue
+			}
+			for _, call := range localStageCalls(pass, fn.Body, stages) {
+				name := typeutil.StaticCallee(pass.TypesInfo, call).Name()
+				pass.Report(analysis.Diagnostic{
+					Pos:      call.Pos(),
+					Category: "PipelineStage",
+					Message: "chanopt: pipeline stage " + name + " has no concurrency benefit (advisory)" +
+						" — its input and output stay in " + fn.Name.Name +
+						"; fuse the transform into the consumer loop or compose iter.Seq functions",
+				})
+			}
+		}
+	}
+}
+
+// isTrivialStage reports whether fn has the shape shown on
+// reportPipelineStages: one input channel, a goroutine ranging over it and
+// sending a pure transform of each value to the returned channel.
+func isTrivialStage(pass *analysis.Pass, fn *ast.FuncDecl) bool {
+	if fn.Body == nil || fn.Recv != nil || fn.Type.Results == nil || !returnsChan(fn.Type.Results) {
+		return false
+	}
+	params := fn.Type.Params.List
+	if len(params) != 1 || len(params[0].Names) != 1 {
+		return false
+	}
+	in := pass.TypesInfo.Defs[params[0].Names[0]]
+	if in == nil {
+		return false
+	}
+	if _, ok := in.Type().Underlying().(*types.Chan); !ok {
+		return false
+	}
+
+	stmts := fn.Body.List
+	if len(stmts) != 3 {
+		return false
+	}
+	mk, ok := stmts[0].(*ast.AssignStmt)
+	if !ok {
+		return false
+	}
+	outID, _, _, ok := extractMakeChan(mk)
+	if !ok {
+		return false
+	}
+	out := pass.TypesInfo.ObjectOf(outID)
+	g, ok := stmts[1].(*ast.GoStmt)
+	if !ok {
+		return false
+	}
+	lit, ok := g.Call.Fun.(*ast.FuncLit)
+	if !ok {
+		return false
+	}
+	ret, ok := stmts[2].(*ast.ReturnStmt)
+	if !ok || len(ret.Results) != 1 || !isObj(pass, ret.Results[0], out) {
+		return false
+	}
+
+	isOut := func(e ast.Expr) bool { return isObj(pass, e, out) }
+	var rng *ast.RangeStmt
+	for _, stmt := range lit.Body.List {
+		switch s := stmt.(type) {
+		case *ast.RangeStmt:
+			if rng != nil || !isObj(pass, s.X, in) {
+				return false
+			}
+			rng = s
+		case *ast.DeferStmt:
+			if !isCloseOf(s.Call, isOut) {
+				return false
+			}
+		case *ast.ExprStmt:
+			if call, ok := s.X.(*ast.CallExpr); !ok || !isCloseOf(call, isOut) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	if rng == nil {
+		return false
+	}
+
+	body := rng.Body.List
+	if len(body) == 0 || len(body) > maxTrivialStmts {
+		return false
+	}
+	send, ok := body[len(body)-1].(*ast.SendStmt)
+	if !ok || !isOut(send.Chan) {
+		return false
+	}
+	handoffs := 0
+	ast.Inspect(rng.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SendStmt:
+			handoffs++
+		case *ast.UnaryExpr:
+			if n.Op == token.ARROW {
+				handoffs++
+			}
+		}
+		return true
+	})
+	return handoffs == 1 && isPureBody(pass, rng.Body)
+}
+
+// localStageCalls returns the calls of stages in body whose argument is
+// produced by a call in body and whose result is consumed in body, by a
+// range loop, a receive or another such stage.
+func localStageCalls(pass *analysis.Pass, body *ast.BlockStmt, stages map[*types.Func]bool) []*ast.CallExpr {
+	isStage := func(e ast.Expr) (*ast.CallExpr, bool) {
+		call, ok := ast.Unparen(e).(*ast.CallExpr)
+		if !ok || len(call.Args) != 1 {
+			return nil, false
+		}
+		fn := typeutil.StaticCallee(pass.TypesInfo, call)
+		return call, fn != nil && stages[fn]
+	}
+
+	// Locals assigned the result of a call, and what each was assigned.
+	fromCall := map[types.Object]*ast.CallExpr{}
+	ast.Inspect(body, func(n ast.Node) bool {
+		if as, ok := n.(*ast.AssignStmt); ok && as.Tok == token.DEFINE && len(as.Lhs) == 1 && len(as.Rhs) == 1 {
+			if call, ok := ast.Unparen(as.Rhs[0]).(*ast.CallExpr); ok {
+				if id, ok := as.Lhs[0].(*ast.Ident); ok {
+					if obj := pass.TypesInfo.Defs[id]; obj != nil {
+						fromCall[obj] = call
+					}
+				}
+			}
+		}
+		return true
+	})
+	// resolve follows a local back to the call that produced it.
+	resolve := func(e ast.Expr) (*ast.CallExpr, bool) {
+		e = ast.Unparen(e)
+		if id, ok := e.(*ast.Ident); ok {
+			call, ok := fromCall[pass.TypesInfo.Uses[id]]
+			return call, ok
+		}
+		call, ok := e.(*ast.CallExpr)
+		return call, ok
+	}
+
+	consumed := map[*ast.CallExpr]bool{}
+	ast.Inspect(body, func(n ast.Node) bool {
+		var e ast.Expr
+		switch n := n.(type) {
+		case *ast.RangeStmt:
+			e = n.X
+		case *ast.UnaryExpr:
+			if n.Op == token.ARROW {
+				e = n.X
+			}
+		case *ast.CallExpr:
+			if _, ok := isStage(n); ok {
+				e = n.Args[0]
+			}
+		}
+		if e != nil {
+			if call, ok := resolve(e); ok {
+				consumed[call] = true
+			}
+		}
+		return true
+	})
+
+	var calls []*ast.CallExpr
+	ast.Inspect(body, func(n ast.Node) bool {
+		if _, ok := n.(*ast.FuncLit); ok {
+			return false // a stage started from a goroutine may be deliberate
+		}
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		if _, ok := isStage(call); !ok || !consumed[call] {
+			return true
+		}
+		if _, ok := resolve(call.Args[0]); ok {
+			calls = append(calls, call)
+		}
+		return true
+	})
+	return calls
+}
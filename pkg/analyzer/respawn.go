@@ -0,0 +1,43 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// restarts reports why the goroutine of cp does not run once and alone, or
+// "" if it does. A goroutine that starts another one, typically to restart
+// itself after a panic,
+//
+//	go func() {
+//	    defer func() { go restart(ch) }()
+//	    ...
+//	}()
+//
+// or that calls its generator or goroutine helper again, breaks the
+// single-goroutine assumption every pattern's replacement relies on: a
+// recursive helper that sends once per call looks like a Singleton.
+func restarts(pass *analysis.Pass, cp channelProducer) string {
+	self := map[types.Object]bool{}
+	for _, fn := range []*ast.FuncDecl{cp.funcDecl, cp.helper} {
+		if fn != nil {
+			self[pass.TypesInfo.Defs[fn.Name]] = true
+		}
+	}
+	why := ""
+	ast.Inspect(cp.funcLit.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.GoStmt:
+			why = "its goroutine starts another one"
+		case *ast.CallExpr:
+			if f := typeutil.StaticCallee(pass.TypesInfo, n); f != nil && self[f] {
+				why = "its goroutine calls " + f.Name() + " again"
+			}
+		}
+		return why == ""
+	})
+	return why
+}
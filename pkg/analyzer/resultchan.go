@@ -0,0 +1,185 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// detectResultCollectors scans a file for result channels buffered to
+// exactly the number of goroutines that fill them:
+//
+//	results := make(chan T, len(items))
+//	for _, it := range items {
+//	    go func() { results <- work(it) }()
+//	}
+//	for range len(items) {
+//	    r := <-results
+//	    ...
+//	}
+//
+// The buffer size, the spawn loop and the drain loop must have the same
+// bound (n, len(s), or ranging over s). Each goroutine sends exactly once
+// and the drain loop receives exactly once per iteration; any other use of
+// the channel rules it out. Writing results[i] from an errgroup (or
+// WaitGroup) goroutine needs no channel and keeps the input order.
+func detectResultCollectors(pass *analysis.Pass, file *ast.File) []token.Pos {
+	var found []token.Pos
+	ast.Inspect(file, func(n ast.Node) bool {
+		block, ok := n.(*ast.BlockStmt)
+		if !ok {
+			return true
+		}
+		for i, stmt := range block.List {
+			as, ok := stmt.(*ast.AssignStmt)
+			if !ok || as.Tok != token.DEFINE {
+				continue
+			}
+			id, pos, _, ok := extractMakeChan(as)
+			if !ok {
+				continue
+			}
+			mk := as.Rhs[0].(*ast.CallExpr)
+			if len(mk.Args) != 2 {
+				continue
+			}
+			ch := pass.TypesInfo.Defs[id]
+			if ch == nil {
+				continue
+			}
+			if collectsResults(pass, ch, types.ExprString(mk.Args[1]), block.List[i+1:]) {
+				found = append(found, pos)
+			}
+		}
+		return true
+	})
+	return found
+}
+
+// collectsResults reports whether stmts contain a spawn loop and then a
+// drain loop for ch, both bounded by size, and no other use of ch.
+func collectsResults(pass *analysis.Pass, ch types.Object, size string, stmts []ast.Stmt) bool {
+	uses := 0
+	for _, stmt := range stmts {
+		ast.Inspect(stmt, func(n ast.Node) bool {
+			if id, ok := n.(*ast.Ident); ok && pass.TypesInfo.Uses[id] == ch {
+				uses++
+			}
+			return true
+		})
+	}
+
+	spawn := -1
+	for i, stmt := range stmts {
+		body, bound := loopBound(pass, stmt)
+		if body == nil || bound != size {
+			continue
+		}
+		if spawn < 0 {
+			if spawnsSender(pass, body, ch) {
+				spawn = i
+			}
+			continue
+		}
+		if drainsOnce(pass, body, ch) {
+			// One send and one receive are the only uses.
+			return uses == 2
+		}
+	}
+	return false
+}
+
+// loopBound returns the body of a counted loop and its bound as a string:
+// "n" for `for i := 0; i < n; i++` and `for range n`, "len(s)" for
+// `for _, v := range s` over a slice, array or string.
+func loopBound(pass *analysis.Pass, stmt ast.Stmt) (*ast.BlockStmt, string) {
+	switch s := stmt.(type) {
+	case *ast.ForStmt:
+		cond, ok := s.Cond.(*ast.BinaryExpr)
+		if !ok || cond.Op != token.LSS || s.Post == nil {
+			return nil, ""
+		}
+		if inc, ok := s.Post.(*ast.IncDecStmt); !ok || inc.Tok != token.INC {
+			return nil, ""
+		}
+		return s.Body, types.ExprString(cond.Y)
+	case *ast.RangeStmt:
+		t := pass.TypesInfo.TypeOf(s.X)
+		if t == nil {
+			return nil, ""
+		}
+		switch u := t.Underlying().(type) {
+		case *types.Basic:
+			if u.Info()&types.IsInteger != 0 {
+				return s.Body, types.ExprString(s.X)
+			}
+			if u.Info()&types.IsString == 0 {
+				return nil, ""
+			}
+		case *types.Slice, *types.Array:
+		default:
+			return nil, ""
+		}
+		return s.Body, "len(" + types.ExprString(s.X) + ")"
+	}
+	return nil, ""
+}
+
+// spawnsSender reports whether body starts, unconditionally, one goroutine
+// whose body sends on ch exactly once, as one of its top-level statements.
+func spawnsSender(pass *analysis.Pass, body *ast.BlockStmt, ch types.Object) bool {
+	var lit *ast.FuncLit
+	for _, stmt := range body.List {
+		if g, ok := stmt.(*ast.GoStmt); ok {
+			if lit != nil {
+				return false
+			}
+			if lit, ok = g.Call.Fun.(*ast.FuncLit); !ok {
+				return false
+			}
+		}
+	}
+	if lit == nil {
+		return false
+	}
+	sends := 0
+	for _, stmt := range lit.Body.List {
+		if send, ok := stmt.(*ast.SendStmt); ok && isObj(pass, send.Chan, ch) {
+			sends++
+		} else if refersTo(pass, stmt, ch) {
+			return false
+		}
+	}
+	return sends == 1
+}
+
+// drainsOnce reports whether body receives from ch once, outside any
+// nested loop, select or function literal, and never leaves the loop
+// early: a drain that can stop at the first error relies on the buffer to
+// let the remaining senders finish.
+func drainsOnce(pass *analysis.Pass, body *ast.BlockStmt, ch types.Object) bool {
+	recvs, ok := 0, true
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.ReturnStmt:
+			ok = false
+		case *ast.BranchStmt:
+			if n.Tok != token.CONTINUE {
+				ok = false
+			}
+		case *ast.ForStmt, *ast.RangeStmt, *ast.SelectStmt, *ast.FuncLit:
+			if refersTo(pass, n, ch) {
+				ok = false
+			}
+			return false
+		case *ast.UnaryExpr:
+			if n.Op == token.ARROW && isObj(pass, n.X, ch) {
+				recvs++
+			}
+		}
+		return true
+	})
+	return ok && recvs == 1
+}
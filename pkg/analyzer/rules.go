@@ -0,0 +1,188 @@
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// Every diagnostic category has a short rule ID for use in conversation,
+// issue trackers, suppression directives and config files. A Registry
+// pattern's rule ID is its stable number, CHOPT001 for IDGenerator; the
+// other checks are numbered from CHOPT101 in checkRules. Like pattern
+// numbers, rule IDs are never reused.
+var checkRules = map[string]int{
+	"BusyPoll":       101,
+	"StopLeak":       102,
+	"OrDone":         103,
+	"Merge":          104,
+	"PipelineStage":  105,
+	"ErrGroup":       106,
+	"ContextStop":    107,
+	"SendAfterClose": 108,
+}
+
+// DocsURL is the page documenting every rule, one section per rule ID.
+const DocsURL = "https://github.com/ravisastryk/chanopt/blob/main/docs/rules.md"
+
+// RuleID returns the rule ID of a diagnostic category, such as "CHOPT001"
+// for "IDGenerator", or "" for an unknown category.
+func RuleID(category string) string {
+	if n, ok := checkRules[category]; ok {
+		return fmt.Sprintf("CHOPT%03d", n)
+	}
+	if p, err := ParsePattern(category); err == nil && p.String() == category {
+		return fmt.Sprintf("CHOPT%03d", int(p))
+	}
+	return ""
+}
+
+// RuleCategory returns the diagnostic category with the given rule ID,
+// ignoring case.
+func RuleCategory(id string) (string, bool) {
+	for _, cat := range Rules() {
+		if strings.EqualFold(id, RuleID(cat)) {
+			return cat, true
+		}
+	}
+	return "", false
+}
+
+// RuleURL returns the documentation of the rule of a diagnostic category.
+func RuleURL(category string) string {
+	return DocsURL + "#" + strings.ToLower(RuleID(category))
+}
+
+// Rules returns every diagnostic category, in rule ID order.
+func Rules() []string {
+	var cats []string
+	for p := range Registry {
+		cats = append(cats, p.String())
+	}
+	for name := range Checks {
+		cats = append(cats, name)
+	}
+	sort.Slice(cats, func(i, j int) bool { return RuleID(cats[i]) < RuleID(cats[j]) })
+	return cats
+}
+
+// matchesRule reports whether s names the category, by rule ID or by name,
+// ignoring case.
+func matchesRule(s, category string) bool {
+	return strings.EqualFold(s, category) || strings.EqualFold(s, RuleID(category))
+}
+
+// ignores collects the //chanopt:ignore directives of the package's files.
+// A directive lists the rules it suppresses, by ID or name, optionally
+// followed by a reason:
+//
+//	//chanopt:ignore CHOPT001,CHOPT010 part of the v1 API
+//
+// In the doc comment of a function it covers the whole function; anywhere
+// else it covers its own line and the next.
+type ignores struct {
+	lines map[string]map[int][]string // rules by file and line
+	funcs []ignoredFunc
+}
+
+type ignoredFunc struct {
+	pos, end token.Pos
+	rules    []string
+}
+
+func collectIgnores(pass *analysis.Pass) *ignores {
+	ig := &ignores{lines: map[string]map[int][]string{}}
+	for _, file := range pass.Files {
+		docs := map[*ast.Comment]bool{}
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Doc == nil {
+				continue
+			}
+			for _, c := range fn.Doc.List {
+				docs[c] = true
+				if rules, ok := ignoreDirective(c.Text); ok {
+					ig.funcs = append(ig.funcs, ignoredFunc{fn.Pos(), fn.End(), rules})
+				}
+			}
+		}
+		for _, group := range file.Comments {
+			for _, c := range group.List {
+				rules, ok := ignoreDirective(c.Text)
+				if !ok || docs[c] {
+					continue
+				}
+				posn := pass.Fset.Position(c.Pos())
+				if ig.lines[posn.Filename] == nil {
+					ig.lines[posn.Filename] = map[int][]string{}
+				}
+				for _, line := range []int{posn.Line, posn.Line + 1} {
+					ig.lines[posn.Filename][line] = append(ig.lines[posn.Filename][line], rules...)
+				}
+			}
+		}
+	}
+	return ig
+}
+
+// ignoreDirective returns the rules listed by a //chanopt:ignore comment.
+func ignoreDirective(text string) ([]string, bool) {
+	rest, ok := strings.CutPrefix(text, "//chanopt:ignore")
+	if !ok || rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return nil, false
+	}
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return nil, false
+	}
+	return strings.Split(fields[0], ","), true
+}
+
+// suppressed reports whether a directive covers d.
+func (ig *ignores) suppressed(pass *analysis.Pass, d analysis.Diagnostic) bool {
+	posn := pass.Fset.Position(d.Pos)
+	for _, r := range ig.lines[posn.Filename][posn.Line] {
+		if matchesRule(r, d.Category) {
+			return true
+		}
+	}
+	for _, fn := range ig.funcs {
+		if fn.pos <= d.Pos && d.Pos < fn.end {
+			for _, r := range fn.rules {
+				if matchesRule(r, d.Category) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// withRule tags d with its rule: the ID ends the message, so that go vet
+// prints it, and the URL links to the rule's documentation.
+func withRule(d analysis.Diagnostic) analysis.Diagnostic {
+	if id := RuleID(d.Category); id != "" {
+		d.Message += " [" + id + "]"
+		d.URL = RuleURL(d.Category)
+	}
+	return d
+}
+
+// CutRule splits the rule ID that withRule ends every diagnostic message
+// with off msg, returning the message without it and the ID.
+func CutRule(msg string) (string, string) {
+	i := strings.LastIndex(msg, " [CHOPT")
+	if i < 0 || !strings.HasSuffix(msg, "]") {
+		return msg, ""
+	}
+	id := msg[i+2 : len(msg)-1]
+	if _, err := strconv.Atoi(id[len("CHOPT"):]); err != nil {
+		return msg, ""
+	}
+	return msg[:i], id
+}
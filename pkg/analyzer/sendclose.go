@@ -0,0 +1,264 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"strconv"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// sendsAfterClose finds the sends of cp's goroutine that can run after
+// its channel is closed, and panic:
+//
+//	go func() {
+//	    defer func() {
+//	        if r := recover(); r != nil {
+//	            ch <- Result{Err: fmt.Errorf("panic: %v", r)}
+//	        }
+//	    }()
+//	    defer close(ch)
+//	    ...
+//	}()
+//
+// Defers run last-in, first-out, so the deferred send above runs after
+// the deferred close. A deferred send also follows any close(ch) the body
+// makes before it returns, and a send follows a close(ch) that the body
+// does not return after, later in its block or in the next iteration of
+// a loop around it. The stop func of a generator that closes the channel
+// itself races with the goroutine's sends.
+//
+// Closes in function literals other than deferred ones are not followed,
+// since when they run is unknown.
+func sendsAfterClose(pass *analysis.Pass, cp channelProducer) []analysis.Diagnostic {
+	if len(cp.sends) == 0 {
+		return nil
+	}
+	id := rootIdent(cp.sends[0].Chan)
+	if id == nil || pass.TypesInfo.Uses[id] == nil {
+		return nil
+	}
+	ch := pass.TypesInfo.Uses[id]
+	isCh := func(e ast.Expr) bool { return isObj(pass, e, ch) }
+	name := id.Name
+	body := cp.funcLit.Body
+	line := func(pos token.Pos) string { return strconv.Itoa(pass.Fset.Position(pos).Line) }
+	var diags []analysis.Diagnostic
+	diag := func(at ast.Node, related token.Pos, msg string) {
+		diags = append(diags, analysis.Diagnostic{
+			Pos:      at.Pos(),
+			Category: "SendAfterClose",
+			Message:  "chanopt: " + msg,
+			Related:  []analysis.RelatedInformation{{Pos: related, Message: "close(" + name + ")"}},
+		})
+	}
+
+	var defers []*ast.DeferStmt
+	var closes []*ast.CallExpr // not deferred
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.DeferStmt:
+			defers = append(defers, n)
+			return false
+		case *ast.CallExpr:
+			if isCloseOf(n, isCh) {
+				closes = append(closes, n)
+			}
+		}
+		return true
+	})
+
+	var deferredClose *ast.DeferStmt
+	for _, d := range defers {
+		if closesIn(d.Call, isCh) {
+			deferredClose = d
+			break
+		}
+	}
+	for _, d := range defers {
+		send := deferredSend(d, isCh)
+		if send == nil {
+			continue
+		}
+		switch {
+		case deferredClose != nil && d.Pos() < deferredClose.Pos():
+			diag(send, deferredClose.Pos(), "deferred send on "+name+" runs after the deferred close("+name+") on line "+line(deferredClose.Pos())+
+				", since defers run last-in, first-out, and panics — defer the close first, so that it runs last")
+		case len(closes) > 0:
+			diag(send, closes[0].Pos(), "deferred send on "+name+" runs when the goroutine returns, after close("+name+") on line "+line(closes[0].Pos())+
+				", and panics — close "+name+" in a defer registered before this one")
+		}
+	}
+	for _, c := range closes {
+		if send := sendAfter(pass, body, c, isCh); send != nil {
+			diag(send, c.Pos(), "send on "+name+" can follow close("+name+") on line "+line(c.Pos())+
+				" and panic — return after closing, or close "+name+" once in a defer")
+		}
+	}
+	if cp.stop != nil && cp.chanIdent != nil {
+		for _, sig := range cp.stop.signals {
+			if sig == pass.TypesInfo.ObjectOf(cp.chanIdent) {
+				diags = append(diags, analysis.Diagnostic{
+					Pos:      cp.stop.pos,
+					Category: "SendAfterClose",
+					Message: "chanopt: stop func closes " + cp.chanIdent.Name + " while the goroutine may still send on it, which panics" +
+						" — close a separate done channel that the goroutine selects on, and let it close " + cp.chanIdent.Name,
+				})
+			}
+		}
+	}
+	return diags
+}
+
+// closesIn reports whether the deferred call closes the channel, as in
+// `defer close(ch)` or a deferred function literal that calls close(ch).
+func closesIn(call *ast.CallExpr, isCh func(ast.Expr) bool) bool {
+	if isCloseOf(call, isCh) {
+		return true
+	}
+	lit, ok := ast.Unparen(call.Fun).(*ast.FuncLit)
+	if !ok {
+		return false
+	}
+	found := false
+	ast.Inspect(lit.Body, func(n ast.Node) bool {
+		if c, ok := n.(*ast.CallExpr); ok && isCloseOf(c, isCh) {
+			found = true
+		}
+		_, nested := n.(*ast.FuncLit)
+		return !found && !nested
+	})
+	return found
+}
+
+// deferredSend returns a send on the channel in the function literal d
+// defers, or nil.
+func deferredSend(d *ast.DeferStmt, isCh func(ast.Expr) bool) *ast.SendStmt {
+	lit, ok := ast.Unparen(d.Call.Fun).(*ast.FuncLit)
+	if !ok {
+		return nil
+	}
+	return firstSend(lit.Body, isCh)
+}
+
+// firstSend returns the first send on the channel in n, outside function
+// literals, or nil.
+func firstSend(n ast.Node, isCh func(ast.Expr) bool) *ast.SendStmt {
+	var send *ast.SendStmt
+	ast.Inspect(n, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.SendStmt:
+			if isCh(n.Chan) {
+				send = n
+			}
+		}
+		return send == nil
+	})
+	return send
+}
+
+// sendAfter returns a send on the channel that can run after the call c
+// closes it: one in a later statement of a block around c that is reached
+// without returning, or one in a loop around c that is not left first.
+// A labeled branch or goto stops the search.
+func sendAfter(pass *analysis.Pass, body *ast.BlockStmt, c *ast.CallExpr, isCh func(ast.Expr) bool) *ast.SendStmt {
+	path := pathTo(body, c)
+	broke := false // an unlabeled break ends the innermost switch, select or loop
+	for i := len(path) - 2; i >= 0; i-- {
+		var list []ast.Stmt
+		switch n := path[i].(type) {
+		case *ast.FuncLit:
+			return nil
+		case *ast.BlockStmt:
+			if i > 0 {
+				switch path[i-1].(type) {
+				case *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt:
+					continue // the clauses are alternatives, not successors
+				}
+			}
+			list = n.List
+		case *ast.CaseClause:
+			list = n.Body
+		case *ast.CommClause:
+			list = n.Body
+		case *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt:
+			broke = false
+		case *ast.ForStmt, *ast.RangeStmt:
+			if broke {
+				broke = false
+				continue
+			}
+			if send := firstSend(n, isCh); send != nil {
+				return send
+			}
+		}
+		if broke || list == nil {
+			continue
+		}
+		after := false
+		for _, stmt := range list {
+			if !after {
+				after = stmt == path[i+1]
+				continue
+			}
+			if send := firstSend(stmt, isCh); send != nil {
+				return send
+			}
+			if br, ok := stmt.(*ast.BranchStmt); ok {
+				if br.Label != nil || br.Tok == token.GOTO {
+					return nil
+				}
+				if br.Tok == token.BREAK {
+					broke = true
+				}
+				break // continue goes on to the next iteration of the loop
+			}
+			if terminates(pass, stmt) {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// pathTo returns the nodes from root down to n.
+func pathTo(root, n ast.Node) []ast.Node {
+	var path []ast.Node
+	ast.Inspect(root, func(m ast.Node) bool {
+		if m == nil || !encloses(m, n) {
+			return false
+		}
+		path = append(path, m)
+		return m != n
+	})
+	return path
+}
+
+// terminates reports whether stmt ends the goroutine: a return, or a call
+// of panic, runtime.Goexit, os.Exit or log.Fatal and its friends.
+func terminates(pass *analysis.Pass, stmt ast.Stmt) bool {
+	switch s := stmt.(type) {
+	case *ast.ReturnStmt:
+		return true
+	case *ast.ExprStmt:
+		call, ok := ast.Unparen(s.X).(*ast.CallExpr)
+		if !ok {
+			return false
+		}
+		if builtinName(pass, call) == "panic" {
+			return true
+		}
+		if f := typeutil.StaticCallee(pass.TypesInfo, call); f != nil && f.Pkg() != nil {
+			switch f.Pkg().Path() + "." + f.Name() {
+			case "runtime.Goexit", "os.Exit", "log.Fatal", "log.Fatalf", "log.Fatalln", "log.Panic", "log.Panicf", "log.Panicln":
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,290 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// valueKind says how the value a producer sends can differ from one send
+// to the next, from most to least stable.
+type valueKind int
+
+const (
+	sendsConstant  valueKind = iota // a compile-time constant, such as 42*42 or a named const
+	sendsInvariant                  // built from constants and variables no loop changes
+	sendsShared                     // reads state that other code may update between sends
+	sendsVarying                    // derived from state a loop changes
+	sendsOpaque                     // the result of a call, receive or allocation
+)
+
+// sentKind classifies the values cp sends on its channel by the least
+// stable of them. Constants are folded by the type checker, so
+// `ch <- 42*42` and `ch <- maxRetries` are constants however they are
+// spelled. Otherwise a value is
+//
+//   - opaque if it depends on a function call, a channel receive or a new
+//     allocation, whose result chanopt cannot follow;
+//   - varying if it depends on a variable that a loop of the goroutine
+//     assigns or increments, or that is defined in such a loop from a
+//     varying expression;
+//   - shared if it reads a package-level variable, a captured variable
+//     that the enclosing function assigns after starting the goroutine,
+//     or memory reached through a pointer, slice or map, all of which
+//     other goroutines may update between sends;
+//   - invariant otherwise: the same value on every send.
+//
+// Sending the counter of an IDGenerator is varying, and sending a value
+// computed from it by a call is opaque; re-sending the
+// current configuration in a loop is shared; a Singleton sends a constant
+// or invariant value.
+func sentKind(pass *analysis.Pass, cp channelProducer) valueKind {
+	body := cp.funcLit.Body
+	changed := map[types.Object]bool{}      // assigned inside a loop
+	loopDefs := map[types.Object]ast.Expr{} // defined inside a loop, with their values
+	outerAssigns := map[types.Object]bool{} // assigned by the enclosing function after body
+	mark := func(e ast.Expr) {
+		if id := rootIdent(e); id != nil && pass.TypesInfo.ObjectOf(id) != nil {
+			changed[pass.TypesInfo.ObjectOf(id)] = true
+		}
+	}
+	var walk func(n ast.Node, inLoop bool)
+	walk = func(n ast.Node, inLoop bool) {
+		ast.Inspect(n, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.ForStmt:
+				if n.Init != nil {
+					walk(n.Init, inLoop)
+				}
+				if n.Post != nil {
+					walk(n.Post, true)
+				}
+				walk(n.Body, true)
+				return false
+			case *ast.RangeStmt:
+				if n.Key != nil {
+					mark(n.Key)
+				}
+				if n.Value != nil {
+					mark(n.Value)
+				}
+				walk(n.Body, true)
+				return false
+			case *ast.FuncLit:
+				walk(n.Body, true) // may run at any time
+				return false
+			case *ast.IncDecStmt:
+				if inLoop {
+					mark(n.X)
+				}
+			case *ast.AssignStmt:
+				if !inLoop {
+					return true
+				}
+				if n.Tok == token.DEFINE && len(n.Lhs) == len(n.Rhs) {
+					for i, lhs := range n.Lhs {
+						if id, ok := lhs.(*ast.Ident); ok && pass.TypesInfo.Defs[id] != nil {
+							loopDefs[pass.TypesInfo.Defs[id]] = n.Rhs[i]
+						} else {
+							mark(lhs)
+						}
+					}
+					return true
+				}
+				for _, lhs := range n.Lhs {
+					mark(lhs)
+				}
+			case *ast.ValueSpec:
+				if inLoop {
+					for _, id := range n.Names {
+						mark(id)
+					}
+				}
+			}
+			return true
+		})
+	}
+	walk(body, false)
+	if cp.helper == nil { // a helper's parameters are copies
+		ast.Inspect(cp.funcDecl.Body, func(n ast.Node) bool {
+			if n == body {
+				return false
+			}
+			if n == nil || n.Pos() < body.End() {
+				return true // assignments before the goroutine starts do not matter
+			}
+			switch n := n.(type) {
+			case *ast.AssignStmt:
+				if n.Tok != token.DEFINE {
+					for _, lhs := range n.Lhs {
+						if id := rootIdent(lhs); id != nil && pass.TypesInfo.Uses[id] != nil {
+							outerAssigns[pass.TypesInfo.Uses[id]] = true
+						}
+					}
+				}
+			case *ast.IncDecStmt:
+				if id := rootIdent(n.X); id != nil && pass.TypesInfo.Uses[id] != nil {
+					outerAssigns[pass.TypesInfo.Uses[id]] = true
+				}
+			}
+			return true
+		})
+	}
+
+	seen := map[types.Object]bool{}
+	var kindOf func(e ast.Expr) valueKind
+	kindOf = func(e ast.Expr) valueKind {
+		if e == nil {
+			return sendsConstant
+		}
+		if tv, ok := pass.TypesInfo.Types[e]; ok && tv.Value != nil {
+			return sendsConstant
+		}
+		switch e := e.(type) {
+		case *ast.BasicLit:
+			return sendsConstant
+		case *ast.Ident:
+			switch obj := pass.TypesInfo.Uses[e].(type) {
+			case *types.Var:
+				switch {
+				case changed[obj]:
+					return sendsVarying
+				case loopDefs[obj] != nil:
+					if seen[obj] {
+						return sendsVarying // defined in terms of itself
+					}
+					seen[obj] = true
+					return kindOf(loopDefs[obj])
+				case obj.Pkg() != pass.Pkg || obj.Parent() == pass.Pkg.Scope():
+					return sendsShared
+				case outerAssigns[obj] && !(body.Pos() <= obj.Pos() && obj.Pos() < body.End()):
+					return sendsShared
+				}
+				return sendsInvariant
+			case nil:
+				return sendsOpaque
+			}
+			return sendsConstant // a type, function, builtin or nil
+		case *ast.ParenExpr:
+			return kindOf(e.X)
+		case *ast.BinaryExpr:
+			return max(kindOf(e.X), kindOf(e.Y))
+		case *ast.UnaryExpr:
+			switch {
+			case e.Op == token.ARROW:
+				return sendsOpaque
+			case e.Op == token.AND:
+				if _, ok := ast.Unparen(e.X).(*ast.CompositeLit); ok {
+					return sendsOpaque // a new pointer on every send
+				}
+			}
+			return kindOf(e.X)
+		case *ast.StarExpr:
+			return max(sendsShared, kindOf(e.X))
+		case *ast.SelectorExpr:
+			sel, ok := pass.TypesInfo.Selections[e]
+			if !ok {
+				return kindOf(e.Sel) // qualified identifier
+			}
+			if sel.Kind() != types.FieldVal {
+				return sendsOpaque // a method value
+			}
+			k := kindOf(e.X)
+			if sel.Indirect() {
+				k = max(k, sendsShared)
+			}
+			return k
+		case *ast.IndexExpr:
+			k := max(kindOf(e.X), kindOf(e.Index))
+			if t := pass.TypesInfo.TypeOf(e.X); t != nil {
+				if _, ok := t.Underlying().(*types.Array); !ok {
+					k = max(k, sendsShared)
+				}
+			}
+			return k
+		case *ast.SliceExpr:
+			return max(sendsShared, kindOf(e.X), kindOf(e.Low), kindOf(e.High), kindOf(e.Max))
+		case *ast.TypeAssertExpr:
+			return kindOf(e.X)
+		case *ast.CompositeLit:
+			k := sendsConstant
+			for _, elt := range e.Elts {
+				if kv, ok := elt.(*ast.KeyValueExpr); ok {
+					if _, isStruct := pass.TypesInfo.TypeOf(e).Underlying().(*types.Struct); !isStruct {
+						k = max(k, kindOf(kv.Key))
+					}
+					elt = kv.Value
+				}
+				k = max(k, kindOf(elt))
+			}
+			return k
+		case *ast.CallExpr:
+			tv := pass.TypesInfo.Types[e.Fun]
+			if !tv.IsType() && !isPureBuiltin(pass, e.Fun) {
+				return sendsOpaque
+			}
+			k := sendsConstant // a conversion, or a builtin such as len
+			for _, arg := range e.Args {
+				k = max(k, kindOf(arg))
+			}
+			return k
+		}
+		return sendsOpaque
+	}
+
+	k := sendsConstant
+	for _, send := range cp.sends {
+		k = max(k, kindOf(send.Value))
+	}
+	return k
+}
+
+// isPureBuiltin reports whether fun is a builtin whose result depends only
+// on its arguments, unlike new, make and append, which allocate.
+func isPureBuiltin(pass *analysis.Pass, fun ast.Expr) bool {
+	id, ok := ast.Unparen(fun).(*ast.Ident)
+	if !ok {
+		return false
+	}
+	if _, ok := pass.TypesInfo.Uses[id].(*types.Builtin); !ok {
+		return false
+	}
+	switch id.Name {
+	case "len", "cap", "min", "max", "real", "imag", "complex":
+		return true
+	}
+	return false
+}
+
+// sendInLoop reports whether send is inside a loop of body.
+func sendInLoop(body *ast.BlockStmt, send *ast.SendStmt) bool {
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.ForStmt, *ast.RangeStmt:
+			if n.Pos() <= send.Pos() && send.End() <= n.End() {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// isSignal reports whether ch carries struct{}: its sends signal an event
+// rather than deliver a value.
+func isSignal(ch *types.Chan) bool {
+	st, ok := ch.Elem().Underlying().(*types.Struct)
+	return ok && st.NumFields() == 0
+}
+
+// resendNote tells how to replace a goroutine that sends the same value,
+// or the current value of shared state, on every receive.
+func resendNote(pat Pattern) string {
+	if pat == ConfigBroadcaster {
+		return "it re-sends shared state on every receive: keep the value in an atomic.Pointer and Load it where the channel is received from"
+	}
+	return "it sends the same value on every receive: compute the value once and return it"
+}
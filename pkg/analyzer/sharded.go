@@ -0,0 +1,246 @@
+package analyzer
+
+import (
+	"cmp"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"maps"
+	"slices"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// shardedDispatch is a goroutine that routes each value it receives to one
+// of several shard channels by key.
+type shardedDispatch struct {
+	pos    token.Pos // the go statement starting the dispatcher
+	exempt string    // why it is not reported, or ""
+}
+
+// detectShardedDispatchers finds dispatcher goroutines that pick an output
+// channel by key, and the shard goroutines draining those channels:
+//
+//	shards := make([]chan Event, 8)
+//	for i := range shards {
+//	    shards[i] = make(chan Event, 64)
+//	    go func(ch <-chan Event) {
+//	        counts := map[string]int{}
+//	        for e := range ch {
+//	            counts[e.Kind]++
+//	        }
+//	    }(shards[i])
+//	}
+//	go func() {
+//	    for e := range events {
+//	        shards[hash(e.Kind)%len(shards)] <- e
+//	    }
+//	}()
+//
+// The key is an index into a slice, array or map of channels, or a switch
+// or if that sends to two or more channel variables. The dispatcher's loop
+// ranges over its input channel and uses no other channel. When every
+// shard is drained, in the same function, by one or more goroutines whose
+// loop only folds each value into state, the dispatcher and shard
+// goroutines serialize what a mutex per shard does in the sender. Shard
+// workers that do I/O, call functions or send further keep per-key
+// ordering and take that work off the sender, so such dispatchers are
+// returned with the reason they are exempt, for -v.
+func detectShardedDispatchers(pass *analysis.Pass) []shardedDispatch {
+	funcs := funcIndex(pass)
+	var found []shardedDispatch
+	for _, file := range pass.Files {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Body == nil {
+				continue
+			}
+			var gos []*ast.GoStmt
+			ast.Inspect(fn.Body, func(n ast.Node) bool {
+				if g, ok := n.(*ast.GoStmt); ok {
+					gos = append(gos, g)
+				}
+				return true
+			})
+			for _, g := range gos {
+				lit, ok := g.Call.Fun.(*ast.FuncLit)
+				if !ok {
+					continue
+				}
+				shards := dispatchTargets(pass, lit)
+				if len(shards) == 0 {
+					continue
+				}
+				sd := shardedDispatch{pos: g.Pos()}
+				drained := map[types.Object]bool{}
+				for _, w := range gos {
+					if w == g {
+						continue
+					}
+					for _, wk := range shardWorkers(pass, w, funcs) {
+						if !shards[wk.shard] {
+							continue
+						}
+						drained[wk.shard] = true
+						if sd.exempt == "" && !aggregates(pass, wk.loop) {
+							sd.exempt = "the worker draining " + wk.shard.Name() + " does more than fold values into state, so the per-shard goroutines may be the point"
+						}
+					}
+				}
+				for _, obj := range slices.SortedFunc(maps.Keys(shards), func(a, b types.Object) int { return cmp.Compare(a.Pos(), b.Pos()) }) {
+					if !drained[obj] && sd.exempt == "" {
+						sd.exempt = "no goroutine started in " + fn.Name.Name + " drains " + obj.Name()
+					}
+				}
+				found = append(found, sd)
+			}
+		}
+	}
+	return found
+}
+
+// dispatchTargets returns the shard channels that lit's goroutine routes
+// values to by key: the variable holding a collection of channels indexed
+// by a non-constant key, or two or more channel variables sent to from
+// different branches. The goroutine must range over one channel, sending
+// only to those shards, and use no other channel outside the loop except
+// to close the shards.
+func dispatchTargets(pass *analysis.Pass, lit *ast.FuncLit) map[types.Object]bool {
+	var rng *ast.RangeStmt
+	for _, stmt := range lit.Body.List {
+		if r, ok := stmt.(*ast.RangeStmt); ok && rng == nil {
+			if t := pass.TypesInfo.TypeOf(r.X); t != nil {
+				if _, ok := t.Underlying().(*types.Chan); ok {
+					rng = r
+					continue
+				}
+			}
+		}
+		if usesChannels(stmt) {
+			return nil
+		}
+	}
+	if rng == nil {
+		return nil
+	}
+	shards := map[types.Object]bool{}
+	keyed, branched, ok := false, false, true
+	ast.Inspect(rng.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit, *ast.GoStmt, *ast.SelectStmt:
+			ok = false
+		case *ast.IfStmt, *ast.SwitchStmt:
+			branched = true
+		case *ast.UnaryExpr:
+			if n.Op == token.ARROW {
+				ok = false
+			}
+		case *ast.SendStmt:
+			switch c := ast.Unparen(n.Chan).(type) {
+			case *ast.IndexExpr:
+				id := rootIdent(c.X)
+				if id == nil || pass.TypesInfo.Uses[id] == nil {
+					ok = false
+					break
+				}
+				if _, isConst := constInt(pass, c.Index); !isConst {
+					keyed = true
+				}
+				shards[pass.TypesInfo.Uses[id]] = true
+			case *ast.Ident:
+				if pass.TypesInfo.Uses[c] == nil {
+					ok = false
+					break
+				}
+				shards[pass.TypesInfo.Uses[c]] = true
+			default:
+				ok = false
+			}
+		}
+		return ok
+	})
+	if !ok || !keyed && !(branched && len(shards) >= 2) {
+		return nil
+	}
+	return shards
+}
+
+// usesChannels reports whether n sends, receives, selects or starts a
+// goroutine. Closing a channel does not count.
+func usesChannels(n ast.Node) bool {
+	found := false
+	ast.Inspect(n, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.SendStmt, *ast.SelectStmt, *ast.GoStmt:
+			found = true
+		case *ast.UnaryExpr:
+			found = found || n.Op == token.ARROW
+		}
+		return !found
+	})
+	return found
+}
+
+// shardWorker is a goroutine's loop draining a shard channel.
+type shardWorker struct {
+	shard types.Object // the channel variable, or the collection of channels
+	loop  *ast.RangeStmt
+}
+
+// shardWorkers returns the range loops over channels at the top level of
+// the function that g starts, a literal or a function of the package,
+// resolving a parameter to the argument g passes: `go drain(shards[i])`
+// and `go func(ch <-chan T) { for v := range ch { ... } }(shards[i])`
+// both drain shards.
+func shardWorkers(pass *analysis.Pass, g *ast.GoStmt, funcs map[*types.Func]*ast.FuncDecl) []shardWorker {
+	var ftype *ast.FuncType
+	var body *ast.BlockStmt
+	switch fun := ast.Unparen(g.Call.Fun).(type) {
+	case *ast.FuncLit:
+		ftype, body = fun.Type, fun.Body
+	default:
+		f, ok := typeutil.Callee(pass.TypesInfo, g.Call).(*types.Func)
+		if !ok {
+			return nil
+		}
+		decl := funcs[f]
+		if decl == nil || decl.Body == nil {
+			return nil
+		}
+		ftype, body = decl.Type, decl.Body
+	}
+	params := map[types.Object]ast.Expr{}
+	i := 0
+	for _, field := range ftype.Params.List {
+		for _, name := range field.Names {
+			if i < len(g.Call.Args) {
+				params[pass.TypesInfo.Defs[name]] = g.Call.Args[i]
+			}
+			i++
+		}
+		if len(field.Names) == 0 {
+			i++
+		}
+	}
+	var workers []shardWorker
+	for _, stmt := range body.List {
+		rng, ok := stmt.(*ast.RangeStmt)
+		if !ok {
+			continue
+		}
+		if t := pass.TypesInfo.TypeOf(rng.X); t == nil {
+			continue
+		} else if _, ok := t.Underlying().(*types.Chan); !ok {
+			continue
+		}
+		x := rng.X
+		if id, ok := ast.Unparen(x).(*ast.Ident); ok && params[pass.TypesInfo.Uses[id]] != nil {
+			x = params[pass.TypesInfo.Uses[id]]
+		}
+		if id := rootIdent(x); id != nil && pass.TypesInfo.Uses[id] != nil {
+			workers = append(workers, shardWorker{pass.TypesInfo.Uses[id], rng})
+		}
+	}
+	return workers
+}
@@ -0,0 +1,36 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Stats is a package fact counting the findings reported in a package by
+// diagnostic category. It is exported only for packages with findings.
+// Drivers roll it up across many packages without parsing diagnostics;
+// the counts are of everything the analyzer reports, before a driver's
+// confidence threshold or disable list.
+type Stats struct {
+	Counts map[string]int
+}
+
+func (*Stats) AFact() {}
+
+func (s *Stats) String() string {
+	var cats []string
+	for cat := range s.Counts {
+		cats = append(cats, cat)
+	}
+	sort.Strings(cats)
+	var b strings.Builder
+	b.WriteString("stats(")
+	for i, cat := range cats {
+		if i > 0 {
+			b.WriteString(" ")
+		}
+		fmt.Fprintf(&b, "%s=%d", cat, s.Counts[cat])
+	}
+	b.WriteString(")")
+	return b.String()
+}
@@ -0,0 +1,217 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// stopFunc is the func() a generator returns next to its channel so that
+// callers can stop the goroutine:
+//
+//	func Watch() (<-chan Event, func()) {
+//	    ch := make(chan Event)
+//	    done := make(chan struct{})
+//	    go func() {
+//	        for {
+//	            select {
+//	            case <-done:
+//	                return
+//	            case ch <- next():
+//	            }
+//	        }
+//	    }()
+//	    return ch, func() { close(done) }
+//	}
+type stopFunc struct {
+	pos     token.Pos
+	signals []types.Object // channels closed or sent on, and contexts cancelled
+	opaque  bool           // does something else as well, with unknown effect
+	stops   bool           // the goroutine waits on one of signals
+}
+
+// returnedStopFunc returns the stop func returned by fn next to the
+// channel, or nil if fn does not return exactly a channel and a func().
+func returnedStopFunc(pass *analysis.Pass, fn *ast.FuncDecl, lit *ast.FuncLit) *stopFunc {
+	obj, ok := pass.TypesInfo.Defs[fn.Name].(*types.Func)
+	if !ok {
+		return nil
+	}
+	results := obj.Type().(*types.Signature).Results()
+	if results.Len() != 2 {
+		return nil
+	}
+	idx := -1
+	for i := range 2 {
+		switch t := results.At(i).Type().Underlying().(type) {
+		case *types.Chan:
+		case *types.Signature:
+			if t.Params().Len() == 0 && t.Results().Len() == 0 {
+				idx = i
+			}
+		default:
+			return nil
+		}
+	}
+	if idx < 0 || len(fn.Body.List) == 0 {
+		return nil
+	}
+	ret, ok := fn.Body.List[len(fn.Body.List)-1].(*ast.ReturnStmt)
+	if !ok || len(ret.Results) != 2 {
+		return nil
+	}
+
+	// ctx, cancel := context.WithCancel(...) and friends.
+	cancels := map[types.Object]types.Object{}
+	for _, stmt := range fn.Body.List {
+		as, ok := stmt.(*ast.AssignStmt)
+		if !ok || len(as.Lhs) != 2 || len(as.Rhs) != 1 {
+			continue
+		}
+		call, ok := as.Rhs[0].(*ast.CallExpr)
+		if !ok {
+			continue
+		}
+		if f := typeutil.StaticCallee(pass.TypesInfo, call); f == nil || f.Pkg() == nil || f.Pkg().Path() != "context" {
+			continue
+		}
+		ctx, ok1 := as.Lhs[0].(*ast.Ident)
+		cancel, ok2 := as.Lhs[1].(*ast.Ident)
+		if ok1 && ok2 && pass.TypesInfo.ObjectOf(ctx) != nil && pass.TypesInfo.ObjectOf(cancel) != nil {
+			cancels[pass.TypesInfo.ObjectOf(cancel)] = pass.TypesInfo.ObjectOf(ctx)
+		}
+	}
+
+	e := ast.Unparen(ret.Results[idx])
+	sf := &stopFunc{pos: e.Pos()}
+	signal := func(e ast.Expr) bool {
+		id, ok := ast.Unparen(e).(*ast.Ident)
+		if !ok {
+			return false
+		}
+		obj := pass.TypesInfo.ObjectOf(id)
+		if ctx, ok := cancels[obj]; ok {
+			sf.signals = append(sf.signals, ctx)
+			return true
+		}
+		if obj != nil && obj.Parent() != pass.Pkg.Scope() {
+			if _, ok := obj.Type().Underlying().(*types.Chan); ok {
+				sf.signals = append(sf.signals, obj)
+				return true
+			}
+		}
+		return false
+	}
+	switch e := e.(type) {
+	case *ast.Ident:
+		if !signal(e) {
+			sf.opaque = true
+		}
+	case *ast.FuncLit:
+		for _, stmt := range e.Body.List {
+			switch s := stmt.(type) {
+			case *ast.SendStmt:
+				if signal(s.Chan) {
+					continue
+				}
+			case *ast.ExprStmt:
+				if call, ok := s.X.(*ast.CallExpr); ok && len(call.Args) == 0 && signal(call.Fun) {
+					continue
+				}
+				if call, ok := s.X.(*ast.CallExpr); ok && isCloseOf(call, signal) {
+					continue
+				}
+			}
+			sf.opaque = true
+		}
+	default:
+		sf.opaque = true
+	}
+	for _, sig := range sf.signals {
+		if waitsOn(pass, lit.Body, sig) {
+			sf.stops = true
+		}
+	}
+	return sf
+}
+
+// waitsOn reports whether body receives from sig, or calls Done or Err on
+// it if sig is a context.
+func waitsOn(pass *analysis.Pass, body *ast.BlockStmt, sig types.Object) bool {
+	found := false
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.UnaryExpr:
+			if n.Op == token.ARROW && isObj(pass, n.X, sig) {
+				found = true
+			}
+		case *ast.SelectorExpr:
+			if (n.Sel.Name == "Done" || n.Sel.Name == "Err") && isObj(pass, n.X, sig) {
+				found = true
+			}
+		}
+		return !found
+	})
+	return found
+}
+
+// isStopSelect reports whether sel is the cancellable send of a generator
+// with a stop func: one case returns once the stop signal arrives and the
+// other sends on the generator channel.
+//
+//	select {
+//	case <-done:
+//	    return
+//	case ch <- v:
+//	}
+func isStopSelect(pass *analysis.Pass, sel *ast.SelectStmt, stop *stopFunc, ch string) bool {
+	if stop == nil || !stop.stops || len(sel.Body.List) != 2 {
+		return false
+	}
+	var onStop, sends bool
+	for _, c := range sel.Body.List {
+		cc := c.(*ast.CommClause)
+		if send, ok := cc.Comm.(*ast.SendStmt); ok {
+			if id, ok := send.Chan.(*ast.Ident); ok && id.Name == ch {
+				sends = true
+			}
+			continue
+		}
+		x := commRecv(cc.Comm)
+		if call, ok := ast.Unparen(x).(*ast.CallExpr); ok {
+			if s, ok := call.Fun.(*ast.SelectorExpr); ok && s.Sel.Name == "Done" {
+				x = s.X
+			}
+		}
+		for _, sig := range stop.signals {
+			if x != nil && isObj(pass, x, sig) && len(cc.Body) == 1 {
+				_, onStop = cc.Body[0].(*ast.ReturnStmt)
+			}
+		}
+	}
+	return onStop && sends
+}
+
+// stopNote tells how to keep the stop func of a generator replaced by pat.
+func stopNote(pat Pattern) string {
+	switch pat {
+	case BoundedIterator:
+		return "the stop func can go: an iter.Seq stops when the caller breaks out of the range loop"
+	case RateLimiter, ChanTicker:
+		return "return the ticker's Stop method as the stop func"
+	}
+	return "the replacement starts no goroutine, so return a no-op stop func until callers drop it"
+}
+
+// reportStopLeak reports a stop func that cannot stop the goroutine.
+func reportStopLeak(pass *analysis.Pass, fn *ast.FuncDecl, sf *stopFunc) {
+	pass.Report(analysis.Diagnostic{
+		Pos:      sf.pos,
+		Category: "StopLeak",
+		Message: "chanopt: the stop func returned by " + fn.Name.Name + " does not stop its goroutine" +
+			" — it signals nothing the goroutine waits on, so the goroutine leaks once callers stop receiving",
+	})
+}
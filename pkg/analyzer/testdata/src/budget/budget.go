@@ -0,0 +1,30 @@
+package budget // want package:"stats\\(IDGenerator=1\\)"
+
+// With the time budget spent, goroutine helpers are not resolved and only
+// generators whose goroutine is written inline are reported.
+
+func Sequence() <-chan int64 {
+	ch := make(chan int64)
+	go countInto(ch)
+	return ch
+}
+
+func countInto(c chan<- int64) {
+	var n int64
+	for {
+		n++
+		c <- n
+	}
+}
+
+func Inline() <-chan int64 { // want Inline:"producer\\(IDGenerator\\)"
+	ch := make(chan int64) // want `chanopt: IDGenerator pattern`
+	go func() {
+		var n int64
+		for {
+			n++
+			ch <- n
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,42 @@
+package ctxstop // want package:"stats\\(ContextStop=1\\)"
+
+import (
+	"context"
+	"time"
+)
+
+// The ticker loop keeps this producer from being an IDGenerator.
+func IDs(ctx context.Context, stop chan struct{}) <-chan int {
+	ch := make(chan int)
+	go func() {
+		t := time.NewTicker(time.Millisecond)
+		defer t.Stop()
+		n := 0
+		for {
+			select {
+			case <-stop: // want `chanopt: ticker loop stops on stop although ctx is in scope .* \[CHOPT107\]`
+				return
+			case <-t.C:
+				n++
+				ch <- n
+			}
+		}
+	}()
+	return ch
+}
+
+// Not reported: the loop is ignored in the function's doc comment.
+//
+//chanopt:ignore CHOPT107 stop is closed by a caller without the context
+func Poll(ctx context.Context, stop chan struct{}, poll func(context.Context)) {
+	t := time.NewTicker(time.Millisecond)
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+			poll(ctx)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package fixes // want package:"stats\\(BoundedIterator=2 ErrorIterator=2 IDGenerator=2\\)"
+
+import "strings"
+
+// Words yields each word of s.
+func Words(s string) <-chan string { // want Words:"producer\\(BoundedIterator\\)"
+	ch := make(chan string) // want `chanopt: BoundedIterator pattern`
+	go func() {
+		defer close(ch)
+		for _, w := range strings.Fields(s) {
+			if w == "" {
+				continue
+			}
+			ch <- strings.ToLower(w)
+		}
+	}()
+	return ch
+}
+
+// Pairs must not be rewritten: the channel escapes into a helper.
+func Pairs(items []int) <-chan int { // want Pairs:"producer\\(BoundedIterator\\)"
+	ch := make(chan int) // want `chanopt: BoundedIterator pattern`
+	go func() {
+		defer close(ch)
+		for _, v := range items {
+			ch <- v
+		}
+		drain(ch)
+	}()
+	return ch
+}
+
+func drain(chan int) {}
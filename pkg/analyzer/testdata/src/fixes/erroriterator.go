@@ -0,0 +1,49 @@
+package fixes
+
+import (
+	"strconv"
+	"strings"
+)
+
+type Parsed struct {
+	N   int
+	Err error
+}
+
+// Ints parses each field of s.
+func Ints(s string) <-chan Parsed { // want Ints:"producer\\(ErrorIterator\\)"
+	ch := make(chan Parsed) // want `chanopt: ErrorIterator pattern`
+	go func() {
+		defer close(ch)
+		for _, f := range strings.Fields(s) {
+			if f == "-" {
+				ch <- Parsed{Err: strconv.ErrSyntax}
+				continue
+			}
+			n, err := strconv.Atoi(f)
+			ch <- Parsed{n, err}
+		}
+	}()
+	return ch
+}
+
+type result struct {
+	err  error
+	line string
+}
+
+// Lines must be rewritten whole: results are sent as they are.
+func Lines(lines []string) <-chan result { // want Lines:"producer\\(ErrorIterator\\)"
+	ch := make(chan result) // want `chanopt: ErrorIterator pattern`
+	go func() {
+		defer close(ch)
+		for _, l := range lines {
+			r := result{line: strings.TrimSpace(l)}
+			if r.line == "" {
+				r.err = strconv.ErrRange
+			}
+			ch <- r
+		}
+	}()
+	return ch
+}
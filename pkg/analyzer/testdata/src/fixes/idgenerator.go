@@ -0,0 +1,29 @@
+package fixes
+
+// NextIDs hands out increasing IDs starting at 1.
+func NextIDs() <-chan uint64 { // want NextIDs:"producer\\(IDGenerator\\)"
+	ch := make(chan uint64) // want `chanopt: IDGenerator pattern`
+	go func() {
+		var n uint64
+		for {
+			n++
+			ch <- n
+		}
+	}()
+	return ch
+}
+
+// Tickets is part of a published API; callers receive from the channel.
+//
+//chanopt:nofix
+func Tickets() <-chan int64 { // want Tickets:"producer\\(IDGenerator\\)"
+	ch := make(chan int64) // want `chanopt: IDGenerator pattern .*; no fix offered: Tickets is marked //chanopt:nofix`
+	go func() {
+		var n int64
+		for {
+			n++
+			ch <- n
+		}
+	}()
+	return ch
+}
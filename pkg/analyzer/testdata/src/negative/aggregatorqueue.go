@@ -0,0 +1,91 @@
+package negative
+
+import "sync"
+
+// Look-alikes of a single-consumer aggregation queue where the channel
+// does more than serialize updates.
+
+type Log struct {
+	lines chan string
+	out   []string
+	write func(string)
+}
+
+func NewLog(write func(string)) *Log {
+	l := &Log{lines: make(chan string, 64), write: write}
+	go l.run()
+	return l
+}
+
+func (l *Log) Print(s string) { l.lines <- s }
+
+// run does I/O per value: the queue keeps it off the callers' path.
+func (l *Log) run() {
+	for s := range l.lines {
+		l.write(s)
+	}
+}
+
+// Workers folds with several consumers, a fan-in of partial sums.
+func Workers(nums []int) int {
+	in := make(chan int, len(nums))
+	var mu sync.Mutex
+	total := 0
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := range in {
+				mu.Lock()
+				total += n
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, n := range nums {
+		in <- n
+	}
+	close(in)
+	wg.Wait()
+	return total
+}
+
+// OneSender has a single, sequential producer.
+func OneSender(nums []int) int {
+	sizes := make(chan int, 16)
+	done := make(chan int)
+	go func() {
+		total := 0
+		for n := range sizes {
+			total += n
+		}
+		done <- total
+	}()
+	for _, n := range nums {
+		sizes <- n
+	}
+	close(sizes)
+	return <-done
+}
+
+// Escaping hands the channel to code outside the package.
+type Escaping struct {
+	ch    chan int
+	total int
+}
+
+func NewEscaping(register func(chan<- int)) *Escaping {
+	e := &Escaping{ch: make(chan int, 8)}
+	register(e.ch)
+	go e.run()
+	return e
+}
+
+func (e *Escaping) Add(n int) { e.ch <- n }
+
+func (e *Escaping) run() {
+	for n := range e.ch {
+		e.total += n
+	}
+}
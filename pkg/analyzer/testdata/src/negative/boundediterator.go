@@ -0,0 +1,20 @@
+package negative
+
+import "os"
+
+// BoundedIterator lookalike: every item costs a file read, so the goroutine
+// genuinely overlaps I/O with the consumer.
+func ReadAll(paths []string) <-chan []byte {
+	ch := make(chan []byte)
+	go func() {
+		defer close(ch)
+		for _, p := range paths {
+			b, err := os.ReadFile(p)
+			if err != nil {
+				continue
+			}
+			ch <- b
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,51 @@
+package negative
+
+// A single non-blocking check is not a busy-wait loop.
+func TryTicket() (int64, bool) {
+	select {
+	case t := <-Tickets():
+		return t, true
+	default:
+		return 0, false
+	}
+}
+
+// Blocking receives in a loop do not spin.
+func WaitTickets(work func(int64)) {
+	tickets := Tickets()
+	for {
+		select {
+		case t := <-tickets:
+			work(t)
+		}
+	}
+}
+
+// Tickets is cancellable, so it is not a flagged producer.
+func Tickets() <-chan int64 {
+	ch := make(chan int64)
+	done := make(chan struct{})
+	go func() {
+		var n int64
+		for {
+			n++
+			select {
+			case ch <- n:
+			case <-done:
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// Polling a channel that no flagged producer returned is out of scope.
+func SpinOn(in <-chan int) int {
+	for {
+		select {
+		case v := <-in:
+			return v
+		default:
+		}
+	}
+}
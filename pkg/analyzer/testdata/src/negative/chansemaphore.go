@@ -0,0 +1,14 @@
+package negative
+
+import "context"
+
+// ChanSemaphore lookalike: a done channel closed on cancellation is a
+// broadcast signal, not a counting semaphore.
+func Done(ctx context.Context) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		<-ctx.Done()
+		close(done)
+	}()
+	return done
+}
@@ -0,0 +1,50 @@
+package negative
+
+import (
+	"net"
+	"time"
+)
+
+// ChanTicker lookalike: each beat is a network round trip, not just a tick.
+func Probe(addr string, d time.Duration) <-chan bool {
+	ch := make(chan bool)
+	go func() {
+		for {
+			time.Sleep(d)
+			conn, err := net.Dial("tcp", addr)
+			if err == nil {
+				conn.Close()
+			}
+			ch <- err == nil
+		}
+	}()
+	return ch
+}
+
+// ChanTicker lookalike: exponential backoff. The sleep doubles each time,
+// so a time.Ticker cannot replace it.
+func Retries(base time.Duration) <-chan struct{} {
+	ch := make(chan struct{})
+	go func() {
+		d := base
+		for {
+			time.Sleep(d)
+			ch <- struct{}{}
+			d *= 2
+		}
+	}()
+	return ch
+}
+
+// ChanTicker lookalike: the backoff grows with the attempt number.
+func RetryAfter(base time.Duration) <-chan int {
+	ch := make(chan int)
+	go func() {
+		for attempt := 0; ; attempt++ {
+			wait := base << attempt
+			<-time.After(wait)
+			ch <- attempt
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,19 @@
+package negative
+
+import "context"
+
+// CircuitBreaker lookalike: state transitions are events that a consumer
+// must observe one by one, not a state slot.
+func Transitions(ctx context.Context, trips <-chan int32) <-chan int32 {
+	ch := make(chan int32)
+	go func() {
+		for s := range trips {
+			select {
+			case ch <- s:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,43 @@
+package negative
+
+import "context"
+
+// ConfigBroadcaster lookalike: every update is delivered in order to a
+// subscriber, which a latest-value store would not preserve.
+func Subscribe(ctx context.Context, updates <-chan string) <-chan string {
+	ch := make(chan string)
+	go func() {
+		for v := range updates {
+			select {
+			case ch <- v:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// ConfigBroadcaster lookalike: a channel of capacity one used as a lock.
+// The unlock puts the token back without taking anything out.
+type chanLock struct{ ch chan int }
+
+func newChanLock() *chanLock {
+	l := &chanLock{ch: make(chan int, 1)}
+	l.ch <- 0
+	return l
+}
+
+func (l *chanLock) Lock() { <-l.ch }
+
+func (l *chanLock) Unlock() { l.ch <- 0 }
+
+// ConfigBroadcaster lookalike: values are handed over one at a time, not
+// replaced; Put waits for the last one to be taken.
+type mailbox struct{ ch chan string }
+
+func newMailbox() *mailbox { return &mailbox{ch: make(chan string, 1)} }
+
+func (m *mailbox) Put(v string) { m.ch <- v }
+
+func (m *mailbox) Get() string { return <-m.ch }
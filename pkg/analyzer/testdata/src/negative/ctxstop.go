@@ -0,0 +1,47 @@
+package negative
+
+import (
+	"context"
+	"time"
+)
+
+// Stop channels where no context is in scope, or that are the context's
+// own Done channel, or loops without a ticker.
+
+func Tick(stop <-chan struct{}, f func()) {
+	t := time.NewTicker(time.Second)
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+			f()
+		}
+	}
+}
+
+func TickCtx(ctx context.Context, f func()) {
+	t := time.NewTicker(time.Second)
+	defer t.Stop()
+	done := ctx.Done()
+	for {
+		select {
+		case <-done:
+			return
+		case <-t.C:
+			f()
+		}
+	}
+}
+
+func Drain(ctx context.Context, in <-chan int, stop <-chan struct{}) (n int) {
+	for {
+		select {
+		case <-stop:
+			return n
+		case v := <-in:
+			n += v
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package negative
+
+import "sync"
+
+func fetch(string) error { return nil }
+
+// errgroup lookalike: errors are read while the goroutines still run, to
+// report progress as they arrive.
+func FetchStreaming(urls []string, report func(error)) {
+	var wg sync.WaitGroup
+	errCh := make(chan error, len(urls))
+	for _, u := range urls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errCh <- fetch(u)
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(errCh)
+	}()
+	for err := range errCh {
+		report(err)
+	}
+}
+
+// errgroup lookalike: the channel is handed to another function, which
+// may keep it.
+func FetchInto(urls []string, sink func(<-chan error)) {
+	var wg sync.WaitGroup
+	errCh := make(chan error, len(urls))
+	for _, u := range urls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errCh <- fetch(u)
+		}()
+	}
+	wg.Wait()
+	close(errCh)
+	sink(errCh)
+}
+
+// errgroup lookalike: the sender does not call Done, so Wait does not
+// cover it.
+func FetchDetached(u string, wg *sync.WaitGroup) error {
+	errCh := make(chan error, 1)
+	go func() { errCh <- fetch(u) }()
+	wg.Wait()
+	return <-errCh
+}
@@ -0,0 +1,40 @@
+package negative
+
+import "os"
+
+// Results of I/O: the goroutine reads ahead of the consumer.
+
+type fileResult struct {
+	Data []byte
+	Err  error
+}
+
+func ReadFiles(paths []string) <-chan fileResult {
+	ch := make(chan fileResult)
+	go func() {
+		defer close(ch)
+		for _, p := range paths {
+			b, err := os.ReadFile(p)
+			ch <- fileResult{b, err}
+		}
+	}()
+	return ch
+}
+
+func StatFiles(paths []string) (<-chan os.FileInfo, <-chan error) {
+	out := make(chan os.FileInfo)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(out)
+		for _, p := range paths {
+			fi, err := os.Stat(p)
+			if err != nil {
+				errc <- err
+				return
+			}
+			out <- fi
+		}
+		errc <- nil
+	}()
+	return out, errc
+}
@@ -0,0 +1,55 @@
+package negative
+
+import (
+	"net/http"
+	"sync"
+)
+
+// FanOutDispatcher lookalike: each job is a network request, so the pool
+// exists to overlap I/O rather than to split up arithmetic.
+func StatusAll(urls []string) []int {
+	out := make([]int, len(urls))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if resp, err := http.Get(urls[i]); err == nil {
+					out[i] = resp.StatusCode
+					resp.Body.Close()
+				}
+			}
+		}()
+	}
+	for i := range urls {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return out
+}
+
+// FanOutDispatcher lookalike: the worker count scales with the input, so
+// this is not a fixed pool.
+func PerItem(in []int, n int) []int {
+	out := make([]int, len(in))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				out[j] = in[j] * 2
+			}
+		}()
+	}
+	for j := range in {
+		jobs <- j
+	}
+	close(jobs)
+	wg.Wait()
+	return out
+}
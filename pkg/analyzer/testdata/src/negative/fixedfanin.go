@@ -0,0 +1,22 @@
+package negative
+
+import "context"
+
+// FixedFanIn lookalike: merging stops on cancellation, so the goroutine
+// coordinates rather than just collecting.
+func Merge(ctx context.Context, a, b <-chan int) <-chan int {
+	out := make(chan int)
+	go func() {
+		for {
+			select {
+			case v := <-a:
+				out <- v
+			case v := <-b:
+				out <- v
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,31 @@
+package negative
+
+// orDone lookalike: watches done but forwards nothing.
+func watch(done <-chan struct{}, events <-chan string) <-chan int {
+	count := make(chan int, 1)
+	go func() {
+		n := 0
+		for {
+			select {
+			case <-done:
+				count <- n
+				return
+			case <-events:
+				n++
+			}
+		}
+	}()
+	return count
+}
+
+// merge lookalike: drains the inputs one after another, no goroutines.
+func concat(cs []<-chan int) <-chan int {
+	out := make(chan int, 64)
+	for _, c := range cs {
+		for v := range c {
+			out <- v
+		}
+	}
+	close(out)
+	return out
+}
@@ -0,0 +1,54 @@
+package negative
+
+import "context"
+
+// IDGenerator lookalike: the counter is cancellable via ctx, so the
+// goroutine coordinates shutdown and is not a bare counter.
+func CancellableIDs(ctx context.Context) <-chan int64 {
+	ch := make(chan int64)
+	go func() {
+		var id int64
+		for {
+			id++
+			select {
+			case ch <- id:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// IDGenerator lookalike: a counter is incremented, but what is sent is
+// derived work, not the counter itself.
+func Batches(items []string, size int) <-chan []string {
+	ch := make(chan []string)
+	go func() {
+		batches := 0
+		for {
+			start := batches * size
+			if start >= len(items) {
+				return
+			}
+			end := min(start+size, len(items))
+			batches++
+			ch <- items[start:end]
+		}
+	}()
+	return ch
+}
+
+// IDGenerator and Singleton lookalike: the counter changes on every
+// iteration, but what is sent is the result of a call.
+func Jittered(jitter func(int) int) <-chan int {
+	ch := make(chan int)
+	go func() {
+		n := 0
+		for {
+			n++
+			ch <- jitter(n)
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,60 @@
+package negative
+
+import "time"
+
+// LazyInit lookalike: the init goroutine keeps producing, so the channel
+// is a stream of fresh values rather than one parked result.
+var nowCh = make(chan time.Time, 1)
+
+func init() {
+	go func() {
+		for {
+			nowCh <- time.Now()
+		}
+	}()
+}
+
+func Now() time.Time { return <-nowCh }
+
+// LazyInit lookalike: readers wait with a timeout, which sync.OnceValue
+// cannot express.
+var readyCh = make(chan string, 1)
+
+func init() {
+	go func() {
+		time.Sleep(time.Second)
+		readyCh <- "ready"
+	}()
+}
+
+func Ready(d time.Duration) (string, bool) {
+	select {
+	case s := <-readyCh:
+		readyCh <- s
+		return s, true
+	case <-time.After(d):
+		return "", false
+	}
+}
+
+// LazyInit lookalike: an unbuffered channel is a rendezvous; the init
+// goroutine blocks until the first reader arrives.
+var tokenCh = make(chan int)
+
+func init() {
+	go func() { tokenCh <- 42 }()
+}
+
+func Token() int { return <-tokenCh }
+
+// LazyInit lookalike: the channel is closed to broadcast readiness.
+var doneCh = make(chan struct{}, 1)
+
+func init() {
+	go func() {
+		doneCh <- struct{}{}
+		close(doneCh)
+	}()
+}
+
+func Wait() { <-doneCh }
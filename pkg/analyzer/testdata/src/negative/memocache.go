@@ -0,0 +1,51 @@
+package negative
+
+type lookup struct {
+	key   string
+	reply chan int
+}
+
+// ServeTable answers from a map it never writes: there is nothing to
+// cache, and nothing is reported.
+func ServeTable(table map[string]int) chan<- lookup {
+	lookups := make(chan lookup)
+	go func() {
+		for l := range lookups {
+			l.reply <- table[l.key]
+		}
+	}()
+	return lookups
+}
+
+// ServeFresh keeps a map per request, so nothing outlives the loop.
+func ServeFresh(f func(string) int) chan<- lookup {
+	lookups := make(chan lookup)
+	go func() {
+		for l := range lookups {
+			seen := map[string]int{}
+			seen[l.key] = f(l.key)
+			l.reply <- seen[l.key]
+		}
+	}()
+	return lookups
+}
+
+type hit struct {
+	key string
+	n   int
+}
+
+// CountHits updates a map by key but never replies: it is a queue, not a
+// cache.
+func CountHits(hits <-chan hit) map[string]int {
+	counts := map[string]int{}
+	done := make(chan struct{})
+	go func() {
+		for h := range hits {
+			counts[h.key] = counts[h.key] + h.n
+		}
+		close(done)
+	}()
+	<-done
+	return counts
+}
@@ -0,0 +1,37 @@
+package negative
+
+import (
+	"sync"
+	"time"
+)
+
+// Singleton lookalike: the goroutine started by the Once keeps producing.
+var (
+	tickOnce sync.Once
+	tickCh   = make(chan time.Time, 1)
+)
+
+func NextTick() time.Time {
+	tickOnce.Do(func() {
+		go func() {
+			for {
+				tickCh <- time.Now()
+			}
+		}()
+	})
+	return <-tickCh
+}
+
+// Singleton lookalike: an unbuffered channel makes every caller after the
+// first block forever; a rendezvous, not a cached value.
+var (
+	pingOnce sync.Once
+	pingCh   = make(chan int)
+)
+
+func Ping() int {
+	pingOnce.Do(func() {
+		go func() { pingCh <- 1 }()
+	})
+	return <-pingCh
+}
@@ -0,0 +1,30 @@
+package negative
+
+// BoundedIterator lookalike: the goroutine writes to buf again after
+// sending it, relying on the channel to hand each chunk over before the
+// next is filled. An iterator or a shared value would not.
+func Chunks(data []byte) <-chan []byte {
+	ch := make(chan []byte)
+	go func() {
+		defer close(ch)
+		buf := make([]byte, 1)
+		for _, b := range data {
+			buf[0] = b
+			ch <- buf
+		}
+	}()
+	return ch
+}
+
+type request struct{ attempts int }
+
+// Singleton lookalike: the request is updated after it has been sent.
+func Submit() <-chan *request {
+	ch := make(chan *request, 1)
+	go func() {
+		r := &request{}
+		ch <- r
+		r.attempts++
+	}()
+	return ch
+}
@@ -0,0 +1,131 @@
+package negative
+
+import "context"
+
+// Look-alikes of two goroutines taking turns that do run concurrently or
+// hand the turn over conditionally.
+
+// PipelinedTotals sends the next number before receiving the previous
+// total, so both goroutines work at once.
+func PipelinedTotals(nums []int) []int {
+	req := make(chan int)
+	resp := make(chan int)
+	go func() {
+		total := 0
+		for n := range req {
+			total += n
+			resp <- total
+		}
+	}()
+	var out []int
+	for _, n := range nums {
+		go func() { req <- n }()
+		out = append(out, <-resp)
+	}
+	return out
+}
+
+// CancellableTotals gives up waiting when ctx is done.
+func CancellableTotals(ctx context.Context, nums []int) []int {
+	req := make(chan int)
+	resp := make(chan int)
+	go func() {
+		total := 0
+		for n := range req {
+			total += n
+			resp <- total
+		}
+	}()
+	var out []int
+	for _, n := range nums {
+		req <- n
+		select {
+		case t := <-resp:
+			out = append(out, t)
+		case <-ctx.Done():
+			return out
+		}
+	}
+	return out
+}
+
+// BufferedTotals lets the caller run ahead of the server.
+func BufferedTotals(nums []int) int {
+	req := make(chan int, len(nums))
+	resp := make(chan int, 1)
+	go func() {
+		total := 0
+		for n := range req {
+			total += n
+			resp <- total
+		}
+	}()
+	last := 0
+	for _, n := range nums {
+		req <- n
+		last = <-resp
+	}
+	return last
+}
+
+// Shared hands both channels to a second server as well.
+func Shared(nums []int) int {
+	req := make(chan int)
+	resp := make(chan int)
+	go serveTotals(req, resp)
+	go func() {
+		total := 0
+		for n := range req {
+			total += n
+			resp <- total
+		}
+	}()
+	last := 0
+	for _, n := range nums {
+		req <- n
+		last = <-resp
+	}
+	return last
+}
+
+func serveTotals(req <-chan int, resp chan<- int) {
+	for n := range req {
+		resp <- n
+	}
+}
+
+// Relay only forwards values; there is no state for a mutex to guard.
+func Relay(nums []int) int {
+	req := make(chan int)
+	resp := make(chan int)
+	go func() {
+		for n := range req {
+			resp <- n
+		}
+	}()
+	last := 0
+	for _, n := range nums {
+		req <- n
+		last = <-resp
+	}
+	return last
+}
+
+// BufferedPair makes both channels in one statement, the response
+// channel buffered, so the server never waits for the caller to take it.
+func BufferedPair(nums []int) int {
+	req, resp := make(chan int), make(chan int, 1)
+	go func() {
+		total := 0
+		for n := range req {
+			total += n
+			resp <- total
+		}
+	}()
+	last := 0
+	for _, n := range nums {
+		req <- n
+		last = <-resp
+	}
+	return last
+}
@@ -0,0 +1,39 @@
+package negative
+
+import (
+	"context"
+	"time"
+)
+
+// RateLimiter lookalike: token refills stop when ctx is cancelled.
+func CancellableTokens(ctx context.Context, rps int) <-chan struct{} {
+	ch := make(chan struct{}, rps)
+	go func() {
+		ticker := time.NewTicker(time.Second / time.Duration(rps))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				ch <- struct{}{}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// RateLimiter lookalike: a non-blocking send without a ticker drops values
+// the consumer has not caught up with; it is not a token bucket.
+func LatestCounts() <-chan int {
+	ch := make(chan int, 1)
+	go func() {
+		for i := 0; ; i++ {
+			select {
+			case ch <- i:
+			default:
+			}
+		}
+	}()
+	return ch
+}
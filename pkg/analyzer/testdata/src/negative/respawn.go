@@ -0,0 +1,56 @@
+package negative
+
+// Generators whose goroutine restarts itself or recurses run more than one
+// goroutine over the channel's lifetime; none of the replacements applies.
+
+// RestartingIDs restarts its counter goroutine after a panic.
+func RestartingIDs() <-chan int64 {
+	ch := make(chan int64)
+	go func() {
+		defer func() {
+			if recover() != nil {
+				go countFrom(ch, 0)
+			}
+		}()
+		var n int64
+		for {
+			n++
+			ch <- n
+		}
+	}()
+	return ch
+}
+
+func countFrom(ch chan<- int64, n int64) {
+	for {
+		n++
+		ch <- n
+	}
+}
+
+// RecursiveIDs sends once per call of a recursive helper, which looks
+// like a Singleton.
+func RecursiveIDs() <-chan int {
+	ch := make(chan int)
+	go next(ch, 0)
+	return ch
+}
+
+func next(ch chan<- int, n int) {
+	ch <- n
+	next(ch, n+1)
+}
+
+// Respawn hands its channel to a new generator goroutine when done.
+func Respawn() <-chan string {
+	ch := make(chan string)
+	go func() {
+		ch <- "ready"
+		go func() {
+			for _, s := range []string{"a", "b"} {
+				ch <- s
+			}
+		}()
+	}()
+	return ch
+}
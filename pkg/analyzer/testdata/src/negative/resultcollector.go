@@ -0,0 +1,57 @@
+package negative
+
+// ResultCollector lookalike: the drain loop stops at the first error, so
+// the buffer is what lets the remaining goroutines finish.
+func FirstErr(tasks []func() error) error {
+	errs := make(chan error, len(tasks))
+	for _, t := range tasks {
+		go func() { errs <- t() }()
+	}
+	for range len(tasks) {
+		if err := <-errs; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ResultCollector lookalike: fewer receives than senders; the buffer
+// absorbs the losers of a race.
+func Fastest(mirrors []func() string) string {
+	ch := make(chan string, len(mirrors))
+	for _, m := range mirrors {
+		go func() { ch <- m() }()
+	}
+	return <-ch
+}
+
+// ResultCollector lookalike: the buffer size does not match the number of
+// goroutines.
+func Halves(in []int) []int {
+	ch := make(chan int, len(in)/2)
+	for _, v := range in {
+		go func() { ch <- v / 2 }()
+	}
+	var out []int
+	for range len(in) {
+		out = append(out, <-ch)
+	}
+	return out
+}
+
+// ResultCollector lookalike: senders may send zero or two values.
+func Maybe(in []int) []int {
+	ch := make(chan int, len(in))
+	for _, v := range in {
+		go func() {
+			if v > 0 {
+				ch <- v
+			}
+		}()
+	}
+	var out []int
+	for range len(in) {
+		out = append(out, <-ch)
+	}
+	return out
+}
@@ -0,0 +1,15 @@
+package negative
+
+// RoundRobin lookalike: weighted selection repeats each backend weights[i]
+// times per cycle, which is scheduling logic rather than a plain index.
+func WeightedRoundRobin(backends []string, weights []int) <-chan string {
+	ch := make(chan string)
+	go func() {
+		for i := 0; ; i = (i + 1) % len(backends) {
+			for w := 0; w < weights[i]; w++ {
+				ch <- backends[i]
+			}
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,52 @@
+package negative
+
+import "io"
+
+// Generators that close their channel only where they stop sending.
+
+type Chunk struct {
+	Data []byte
+	Err  error
+}
+
+func ReadChunks(r io.Reader) <-chan Chunk {
+	ch := make(chan Chunk)
+	go func() {
+		defer close(ch)
+		defer func() {
+			if p := recover(); p != nil {
+				ch <- Chunk{Err: io.ErrUnexpectedEOF}
+			}
+		}()
+		for {
+			buf := make([]byte, 4096)
+			n, err := r.Read(buf)
+			if err != nil {
+				if err != io.EOF {
+					ch <- Chunk{Err: err}
+				}
+				return
+			}
+			ch <- Chunk{Data: buf[:n]}
+		}
+	}()
+	return ch
+}
+
+func Lines(r io.Reader, split func([]byte) [][]byte) <-chan []byte {
+	ch := make(chan []byte)
+	go func() {
+		buf, err := io.ReadAll(r)
+		for _, line := range split(buf) {
+			if err != nil {
+				close(ch)
+				break
+			}
+			ch <- line
+		}
+		if err == nil {
+			close(ch)
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,64 @@
+package negative
+
+import (
+	"fmt"
+	"io"
+)
+
+// Look-alikes of a sharded dispatcher where the per-key goroutines earn
+// their keep, or where chanopt cannot see who drains a shard.
+
+type job struct {
+	key  string
+	body []byte
+}
+
+// Each shard writes to its own writer, in key order, off the sender.
+func WriteByKey(jobs <-chan job, ws []io.Writer) {
+	shards := make([]chan job, len(ws))
+	for i := range shards {
+		shards[i] = make(chan job, 16)
+		go func(ch <-chan job, w io.Writer) {
+			for j := range ch {
+				fmt.Fprintf(w, "%s: %s\n", j.key, j.body)
+			}
+		}(shards[i], ws[i])
+	}
+	go func() {
+		for j := range jobs {
+			shards[len(j.key)%len(shards)] <- j
+		}
+	}()
+}
+
+// The shards are handed to the caller, so their consumers are elsewhere.
+func Partition(jobs <-chan job, n int) []chan job {
+	shards := make([]chan job, n)
+	for i := range shards {
+		shards[i] = make(chan job, 16)
+	}
+	go func() {
+		for j := range jobs {
+			shards[len(j.key)%n] <- j
+		}
+	}()
+	return shards
+}
+
+// Always the first shard: nothing is keyed.
+func First(jobs <-chan job, sizes []int) {
+	shards := make([]chan job, 2)
+	for i := range shards {
+		shards[i] = make(chan job, 16)
+		go func(i int) {
+			for j := range shards[i] {
+				sizes[i] += len(j.body)
+			}
+		}(i)
+	}
+	go func() {
+		for j := range jobs {
+			shards[0] <- j
+		}
+	}()
+}
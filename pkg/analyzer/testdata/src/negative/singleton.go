@@ -0,0 +1,30 @@
+package negative
+
+import "net/http"
+
+// Singleton lookalike: the single value is the result of an HTTP request
+// that the caller wants to overlap with other work.
+func FetchStatus(url string) <-chan int {
+	ch := make(chan int, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			ch <- 0
+			return
+		}
+		resp.Body.Close()
+		ch <- resp.StatusCode
+	}()
+	return ch
+}
+
+// Singleton lookalike: the one send statement runs n times.
+func Repeat(s string, n int) <-chan string {
+	ch := make(chan string)
+	go func() {
+		for i := 0; i < n; i++ {
+			ch <- s
+		}
+	}()
+	return ch
+}
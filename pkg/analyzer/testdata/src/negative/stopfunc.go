@@ -0,0 +1,41 @@
+package negative
+
+import "time"
+
+// The select also waits on a timer: genuine coordination, and stop works.
+func pulses(d time.Duration) (<-chan int, func()) {
+	ch := make(chan int)
+	done := make(chan struct{})
+	go func() {
+		n := 0
+		for {
+			n++
+			select {
+			case <-done:
+				return
+			case <-time.After(d):
+			case ch <- n:
+			}
+		}
+	}()
+	return ch, func() { close(done) }
+}
+
+type server struct{ quit chan struct{} }
+
+func (s *server) shutdown() { close(s.quit) }
+
+// The stop func calls something the analyzer cannot see into.
+func (s *server) events() (<-chan int, func()) {
+	ch := make(chan int)
+	go func() {
+		for i := 0; ; i++ {
+			select {
+			case <-s.quit:
+				return
+			case ch <- i:
+			}
+		}
+	}()
+	return ch, func() { s.shutdown() }
+}
@@ -0,0 +1,15 @@
+package negative
+
+import "time"
+
+// Sends a computed deadline, not the tick.
+func deadlines(t *time.Ticker, d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time)
+	go func() {
+		for now := range t.C {
+			ch <- now.Add(d)
+			ch <- now
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,29 @@
+package negative
+
+import "time"
+
+// The ticker belongs to the caller: its channel is an input.
+func paced(t *time.Ticker) <-chan struct{} {
+	ch := make(chan struct{})
+	go func() {
+		for range t.C {
+			ch <- struct{}{}
+		}
+	}()
+	return ch
+}
+
+// tick may be the caller's channel instead of a local ticker's.
+func pacedBy(in <-chan time.Time, d time.Duration) <-chan struct{} {
+	ch := make(chan struct{})
+	tick := time.Tick(d)
+	if in != nil {
+		tick = in
+	}
+	go func() {
+		for range tick {
+			ch <- struct{}{}
+		}
+	}()
+	return ch
+}
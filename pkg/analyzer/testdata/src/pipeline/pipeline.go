@@ -0,0 +1,105 @@
+package pipeline // want package:"stats\\(BoundedIterator=2 PipelineStage=3\\)"
+
+import (
+	"os"
+	"strings"
+)
+
+// The sources are bounded iterators in their own right.
+func gen(nums []int) <-chan int { // want gen:"producer\\(BoundedIterator\\)"
+	out := make(chan int) // want `BoundedIterator pattern`
+	go func() {
+		defer close(out)
+		for _, n := range nums {
+			out <- n
+		}
+	}()
+	return out
+}
+
+func square(in <-chan int) <-chan int {
+	out := make(chan int)
+	go func() {
+		defer close(out)
+		for v := range in {
+			out <- v * v
+		}
+	}()
+	return out
+}
+
+func upper(in <-chan string) <-chan string {
+	out := make(chan string, 8)
+	go func() {
+		for s := range in {
+			s = strings.TrimSpace(s)
+			out <- strings.ToUpper(s)
+		}
+		close(out)
+	}()
+	return out
+}
+
+// size does I/O per value; overlapping it with the producer is the point.
+func size(in <-chan string) <-chan int64 {
+	out := make(chan int64)
+	go func() {
+		defer close(out)
+		for name := range in {
+			if fi, err := os.Stat(name); err == nil {
+				out <- fi.Size()
+			}
+		}
+	}()
+	return out
+}
+
+func SumSquares(nums []int) int {
+	total := 0
+	for v := range square(gen(nums)) { // want `chanopt: pipeline stage square has no concurrency benefit \(advisory\)`
+		total += v
+	}
+	return total
+}
+
+func FourthPowers(nums []int) []int {
+	src := gen(nums)
+	sq := square(square(src)) // want `pipeline stage square` `pipeline stage square`
+	var res []int
+	for v := range sq {
+		res = append(res, v)
+	}
+	return res
+}
+
+func Shout(words <-chan string) string {
+	// The input arrives from the caller, so the stage may overlap with
+	// whatever produces it.
+	return <-upper(words)
+}
+
+// Sizes feeds upper into a stage that is not trivial, which may want its
+// input to arrive concurrently.
+func Sizes(lines []string) []int64 {
+	var res []int64
+	for n := range size(upper(strs(lines))) {
+		res = append(res, n)
+	}
+	return res
+}
+
+// Handoff returns the stage's channel; its consumer is elsewhere.
+func Handoff(nums []int) <-chan int {
+	return square(gen(nums))
+}
+
+func strs(lines []string) <-chan string { // want strs:"producer\\(BoundedIterator\\)"
+	out := make(chan string) // want `BoundedIterator pattern`
+	go func() {
+		defer close(out)
+		for _, l := range lines {
+			out <- l
+		}
+	}()
+	return out
+}
@@ -0,0 +1,58 @@
+package positive // want package:"stats\\(AggregatorQueue=\\d+ .*\\)"
+
+import "sync"
+
+type Event struct {
+	Kind  string
+	Bytes int
+}
+
+type Metrics struct {
+	events chan Event
+	counts map[string]int
+	bytes  int
+}
+
+func NewMetrics() *Metrics {
+	m := &Metrics{events: make(chan Event, 1024), counts: map[string]int{}} // want `chanopt: AggregatorQueue pattern — replace channel with sync.Mutex-guarded state or per-goroutine shards`
+	go m.run()
+	return m
+}
+
+func (m *Metrics) Record(e Event) {
+	select {
+	case m.events <- e:
+	default: // dropped when the consumer falls behind
+	}
+}
+
+func (m *Metrics) run() {
+	for e := range m.events {
+		m.counts[e.Kind]++
+		m.bytes += e.Bytes
+	}
+}
+
+func TotalSize(files [][]byte) int {
+	sizes := make(chan int, len(files)) // want `chanopt: AggregatorQueue pattern`
+	done := make(chan struct{})
+	total := 0
+	go func() {
+		for n := range sizes {
+			total += n
+		}
+		close(done)
+	}()
+	var wg sync.WaitGroup
+	for _, f := range files {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sizes <- len(f)
+		}()
+	}
+	wg.Wait()
+	close(sizes)
+	<-done
+	return total
+}
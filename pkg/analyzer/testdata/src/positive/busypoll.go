@@ -0,0 +1,34 @@
+package positive
+
+func Tickets() <-chan int64 { // want Tickets:"producer\\(IDGenerator\\)"
+	ch := make(chan int64) // want `chanopt: IDGenerator pattern`
+	go func() {
+		var n int64
+		for {
+			n++
+			ch <- n
+		}
+	}()
+	return ch
+}
+
+func SpinForTicket(work func(int64)) {
+	tickets := Tickets()
+	for {
+		select { // want `chanopt: busy-wait polling of Tickets \(IDGenerator pattern\)`
+		case t := <-tickets:
+			work(t)
+		default:
+		}
+	}
+}
+
+func SpinInline() int64 {
+	for {
+		select { // want `chanopt: busy-wait polling of Tickets`
+		case t := <-Tickets():
+			return t
+		default:
+		}
+	}
+}
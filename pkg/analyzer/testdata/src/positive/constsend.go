@@ -0,0 +1,40 @@
+package positive
+
+// DefaultPort sends the same constant forever; the type checker folds
+// 8000 + 80, so it is a value, not a sequence.
+func DefaultPort() <-chan int { // want DefaultPort:"producer\\(Singleton\\)"
+	ch := make(chan int) // want `chanopt: Singleton pattern — .*it sends the same value on every receive`
+	go func() {
+		for {
+			ch <- 8000 + 80
+		}
+	}()
+	return ch
+}
+
+// Epoch increments its counter once, before the loop, so every receive
+// gets the same number: it is not an IDGenerator.
+func Epoch() <-chan int64 { // want Epoch:"producer\\(Singleton\\)"
+	ch := make(chan int64) // want `chanopt: Singleton pattern`
+	go func() {
+		var epoch int64
+		epoch++
+		for {
+			ch <- epoch
+		}
+	}()
+	return ch
+}
+
+type limits struct{ rps, burst int }
+
+// CurrentLimits hands out whatever cur points to at each receive.
+func CurrentLimits(cur *limits) <-chan limits { // want CurrentLimits:"producer\\(ConfigBroadcaster\\)"
+	ch := make(chan limits) // want `chanopt: ConfigBroadcaster pattern — .*keep the value in an atomic.Pointer`
+	go func() {
+		for {
+			ch <- *cur
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,40 @@
+package positive
+
+import (
+	"context"
+	"time"
+)
+
+type Poller struct {
+	every time.Duration
+	stop  chan struct{}
+	polls int
+}
+
+func (p *Poller) Run(ctx context.Context) {
+	t := time.NewTicker(p.every)
+	defer t.Stop()
+	for {
+		select {
+		case <-p.stop: // want `chanopt: ticker loop stops on p.stop although ctx is in scope — select on ctx.Done\(\) and cancel the context where p.stop is closed \[CHOPT107\]`
+			return
+		case <-t.C:
+			p.polls++
+		}
+	}
+}
+
+func KeepAlive(ctx context.Context, beat func(context.Context), quit <-chan struct{}) {
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-quit: // want `chanopt: ticker loop stops on both ctx.Done\(\) and quit — drop quit and cancel the context where it is closed, so there is one shutdown signal`
+				return
+			case <-time.After(time.Second):
+				beat(ctx)
+			}
+		}
+	}()
+}
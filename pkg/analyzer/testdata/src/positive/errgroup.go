@@ -0,0 +1,43 @@
+package positive
+
+import "sync"
+
+func fetch(string) error { return nil }
+
+func FetchAll(urls []string) error {
+	var wg sync.WaitGroup
+	errCh := make(chan error, 1) // want `chanopt: wg and errCh reimplement errgroup`
+	for _, u := range urls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fetch(u); err != nil {
+				select {
+				case errCh <- err:
+				default:
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errCh)
+	return <-errCh
+}
+
+func FetchEach(urls []string) error {
+	var wg sync.WaitGroup
+	errs := make(chan error, len(urls)) // want `chanopt: wg and errs reimplement errgroup`
+	for _, u := range urls {
+		wg.Go(func() {
+			if err := fetch(u); err != nil {
+				errs <- err
+			}
+		})
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		return err
+	}
+	return nil
+}
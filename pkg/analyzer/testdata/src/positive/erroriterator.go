@@ -0,0 +1,40 @@
+package positive
+
+type Entry struct {
+	Key, Value string
+}
+
+type entryResult struct {
+	Rec Entry
+	Err error
+}
+
+func ParseEntries(lines []string, parse func(string) (Entry, error)) <-chan entryResult { // want ParseEntries:"producer\\(ErrorIterator\\)"
+	ch := make(chan entryResult) // want `chanopt: ErrorIterator pattern — replace channel with iter.Seq2\[T, error\] \(Go 1.23\+\)`
+	go func() {
+		defer close(ch)
+		for _, l := range lines {
+			rec, err := parse(l)
+			ch <- entryResult{Rec: rec, Err: err}
+		}
+	}()
+	return ch
+}
+
+// The value and error channels are returned together.
+func CheckNames(names []string, check func(string) error) (<-chan string, <-chan error) { // want CheckNames:"producer\\(ErrorIterator\\)"
+	out := make(chan string) // want `chanopt: ErrorIterator pattern`
+	errc := make(chan error, 1)
+	go func() {
+		defer close(out)
+		for _, n := range names {
+			if err := check(n); err != nil {
+				errc <- err
+				return
+			}
+			out <- n
+		}
+		errc <- nil
+	}()
+	return out, errc
+}
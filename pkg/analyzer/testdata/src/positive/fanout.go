@@ -0,0 +1,48 @@
+package positive
+
+import (
+	"runtime"
+	"sync"
+)
+
+func SquareAll(in []int) []int {
+	out := make([]int, len(in))
+	jobs := make(chan int) // want `chanopt: FanOutDispatcher pattern`
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				out[j] = in[j] * in[j]
+			}
+		}()
+	}
+	for j := range in {
+		jobs <- j
+	}
+	close(jobs)
+	wg.Wait()
+	return out
+}
+
+func Lengths(words []string) []int {
+	out := make([]int, len(words))
+	jobs := make(chan int, len(words)) // want `chanopt: FanOutDispatcher pattern`
+	var wg sync.WaitGroup
+	for range runtime.NumCPU() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				out[i] = len(words[i])
+			}
+		}()
+	}
+	for i := range words {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return out
+}
@@ -0,0 +1,45 @@
+package positive
+
+// Generators held in variables and struct fields are found like functions.
+
+var NewIDs = func() <-chan int64 {
+	ch := make(chan int64) // want `chanopt: IDGenerator pattern`
+	go func() {
+		var id int64
+		for {
+			id++
+			ch <- id
+		}
+	}()
+	return ch
+}
+
+type Source struct {
+	Items func() <-chan string
+	Ticks func() <-chan int
+}
+
+func NewSource(items []string) *Source {
+	s := &Source{
+		Items: func() <-chan string {
+			ch := make(chan string) // want `chanopt: BoundedIterator pattern`
+			go func() {
+				defer close(ch)
+				for _, it := range items {
+					ch <- it
+				}
+			}()
+			return ch
+		},
+	}
+	s.Ticks = func() <-chan int {
+		ch := make(chan int) // want `chanopt: IDGenerator pattern`
+		go func() {
+			for i := 0; ; i++ {
+				ch <- i
+			}
+		}()
+		return ch
+	}
+	return s
+}
@@ -0,0 +1,16 @@
+package positive
+
+func countInto(c chan<- int64) {
+	var n int64
+	for {
+		n++
+		c <- n
+	}
+}
+
+func fill(items []string, dst chan<- string) {
+	defer close(dst)
+	for _, it := range items {
+		dst <- it
+	}
+}
@@ -0,0 +1,15 @@
+package positive
+
+// The goroutine bodies live in helper_fill.go.
+
+func Sequence() <-chan int64 { // want Sequence:"producer\\(IDGenerator\\)"
+	ch := make(chan int64) // want `chanopt: IDGenerator pattern`
+	go countInto(ch)
+	return ch
+}
+
+func Each(items []string) <-chan string { // want Each:"producer\\(BoundedIterator\\)"
+	out := make(chan string) // want `chanopt: BoundedIterator pattern`
+	go fill(items, out)
+	return out
+}
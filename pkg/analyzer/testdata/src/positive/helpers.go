@@ -0,0 +1,45 @@
+package positive
+
+import "sync"
+
+func orDone(done <-chan struct{}, c <-chan int) <-chan int { // want `chanopt: orDone reimplements orDone`
+	valStream := make(chan int)
+	go func() {
+		defer close(valStream)
+		for {
+			select {
+			case <-done:
+				return
+			case v, ok := <-c:
+				if !ok {
+					return
+				}
+				select {
+				case valStream <- v:
+				case <-done:
+				}
+			}
+		}
+	}()
+	return valStream
+}
+
+func merge(cs ...<-chan int) <-chan int { // want `chanopt: merge reimplements channel merge`
+	var wg sync.WaitGroup
+	out := make(chan int)
+	output := func(c <-chan int) {
+		for n := range c {
+			out <- n
+		}
+		wg.Done()
+	}
+	wg.Add(len(cs))
+	for _, c := range cs {
+		go output(c)
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
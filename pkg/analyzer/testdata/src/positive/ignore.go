@@ -0,0 +1,48 @@
+package positive
+
+// Findings are suppressed by rule ID or name with //chanopt:ignore, either
+// in a function's doc comment or on or above the reported line. The rule ID
+// ends every message.
+
+// LegacyIDs is part of the v1 API.
+//
+//chanopt:ignore CHOPT001 callers receive from the channel
+func LegacyIDs() <-chan int64 { // want LegacyIDs:"producer\\(IDGenerator\\)"
+	ch := make(chan int64)
+	go func() {
+		var id int64
+		for {
+			id++
+			ch <- id
+		}
+	}()
+	return ch
+}
+
+func LegacyTickets() <-chan int64 { // want LegacyTickets:"producer\\(IDGenerator\\)"
+	//chanopt:ignore IDGenerator
+	ch := make(chan int64)
+	go func() {
+		var id int64
+		for {
+			id++
+			ch <- id
+		}
+	}()
+	return ch
+}
+
+// OtherRule ignores a rule that does not apply here.
+//
+//chanopt:ignore CHOPT010
+func OtherRule() <-chan int64 { // want OtherRule:"producer\\(IDGenerator\\)"
+	ch := make(chan int64) // want `chanopt: IDGenerator pattern .* \[CHOPT001\]$`
+	go func() {
+		var id int64
+		for {
+			id++
+			ch <- id
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,17 @@
+package positive
+
+type Record struct {
+	ID      int64
+	Payload [31]int64
+}
+
+func Records(rs []Record) <-chan Record { // want Records:"producer\\(BoundedIterator\\)"
+	ch := make(chan Record) // want `chanopt: BoundedIterator pattern .*each send copies 256 B of Record, which the replacement avoids \(if the channel stays, send \*Record\)`
+	go func() {
+		defer close(ch)
+		for _, r := range rs {
+			ch <- r
+		}
+	}()
+	return ch
+}
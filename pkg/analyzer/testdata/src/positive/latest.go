@@ -0,0 +1,52 @@
+package positive
+
+import "time"
+
+// A full bucket drops the ticker's token rather than block the ticker.
+func DroppingTokens(rps int) <-chan struct{} { // want DroppingTokens:"producer\\(RateLimiter\\)"
+	ch := make(chan struct{}, rps) // want `chanopt: RateLimiter pattern`
+	go func() {
+		t := time.NewTicker(time.Second / time.Duration(rps))
+		defer t.Stop()
+		for range t.C {
+			select {
+			case ch <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return ch
+}
+
+// A channel of capacity one holding the latest value, replaced on update.
+func LatestValue(initial string) (<-chan string, func(string)) {
+	ch := make(chan string, 1) // want `chanopt: ConfigBroadcaster pattern`
+	ch <- initial
+	update := func(v string) {
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- v
+	}
+	return ch, update
+}
+
+type limitsStore struct{ ch chan limits }
+
+func newLimitsStore(l limits) *limitsStore {
+	s := &limitsStore{ch: make(chan limits, 1)} // want `chanopt: ConfigBroadcaster pattern`
+	s.ch <- l
+	return s
+}
+
+func (s *limitsStore) Load() limits {
+	l := <-s.ch
+	s.ch <- l
+	return l
+}
+
+func (s *limitsStore) Store(l limits) {
+	<-s.ch
+	s.ch <- l
+}
@@ -0,0 +1,33 @@
+package positive
+
+import "os"
+
+type settings struct{ home string }
+
+var settingsCh = make(chan *settings, 1) // want `chanopt: LazyInit pattern`
+
+func init() {
+	go func() {
+		settingsCh <- &settings{home: os.Getenv("HOME")}
+	}()
+}
+
+// Settings parks the value again so the next caller finds it.
+func Settings() *settings {
+	s := <-settingsCh
+	settingsCh <- s
+	return s
+}
+
+var hostCh chan string
+
+func init() {
+	hostCh = make(chan string, 1) // want `chanopt: LazyInit pattern`
+	go func() {
+		h, _ := os.Hostname()
+		hostCh <- h
+	}()
+}
+
+// Host can be called only once; the value is not parked again.
+func Host() string { return <-hostCh }
@@ -0,0 +1,70 @@
+package positive
+
+type memoRequest struct {
+	key   string
+	reply chan int
+}
+
+type Memo struct {
+	f        func(string) int
+	cache    map[string]int
+	requests chan *memoRequest
+}
+
+func NewMemo(f func(string) int) *Memo {
+	m := &Memo{f: f, cache: map[string]int{}, requests: make(chan *memoRequest)}
+	go m.serve() // want `chanopt: MemoCache pattern — replace channel with sync.Mutex-guarded map \(or sync.Map\) \+ singleflight for misses`
+	return m
+}
+
+func (m *Memo) Get(key string) int {
+	req := &memoRequest{key, make(chan int)}
+	m.requests <- req
+	return <-req.reply
+}
+
+func (m *Memo) serve() {
+	for req := range m.requests {
+		if v, ok := m.cache[req.key]; ok {
+			req.reply <- v
+			continue
+		}
+		v := m.f(req.key)
+		m.cache[req.key] = v
+		req.reply <- v
+	}
+}
+
+// memoEntry is the duplicate-suppressing cache of The Go Programming
+// Language, section 9.7: the server hands each request to the entry.
+type memoEntry struct {
+	res   int
+	ready chan struct{}
+}
+
+func (e *memoEntry) call(f func(string) int, key string) {
+	e.res = f(key)
+	close(e.ready)
+}
+
+func (e *memoEntry) deliver(reply chan<- int) {
+	<-e.ready
+	reply <- e.res
+}
+
+func ServeMemo(f func(string) int) chan<- memoRequest {
+	requests := make(chan memoRequest)
+	go func() { // want `chanopt: MemoCache pattern`
+		cache := map[string]*memoEntry{}
+		for req := range requests {
+			e := cache[req.key]
+			if e == nil {
+				e = &memoEntry{ready: make(chan struct{})}
+				cache[req.key] = e
+				go e.call(f, req.key)
+			}
+			go e.deliver(req.reply)
+		}
+	}()
+	return requests
+}
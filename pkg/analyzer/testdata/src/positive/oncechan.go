@@ -0,0 +1,44 @@
+package positive
+
+import (
+	"os"
+	"sync"
+)
+
+var (
+	tmpOnce sync.Once
+	tmpCh   = make(chan string, 1) // want `chanopt: Singleton pattern — .*sync.Once already synchronizes the first use`
+)
+
+// TempDir starts the loader once, then parks the value for the next caller.
+func TempDir() string {
+	tmpOnce.Do(func() {
+		go func() { tmpCh <- os.TempDir() }()
+	})
+	d := <-tmpCh
+	tmpCh <- d
+	return d
+}
+
+var (
+	optsOnce sync.Once
+	optsCh   chan *settings
+)
+
+// Options makes the channel inside the Once as well.
+func Options() *settings {
+	optsOnce.Do(func() {
+		optsCh = make(chan *settings, 1) // want `chanopt: ConfigBroadcaster pattern — .*Store updates in an atomic.Pointer`
+		go func() { optsCh <- &settings{home: os.Getenv("HOME")} }()
+	})
+	s := <-optsCh
+	optsCh <- s
+	return s
+}
+
+// SetOptions replaces the parked value.
+func SetOptions(s *settings) {
+	Options()
+	<-optsCh
+	optsCh <- s
+}
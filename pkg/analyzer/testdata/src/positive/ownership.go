@@ -0,0 +1,11 @@
+package positive
+
+// DefaultArgs hands each call a fresh slice, which a sync.Once value would
+// share between callers.
+func DefaultArgs() <-chan []string { // want DefaultArgs:"producer\\(Singleton\\)"
+	ch := make(chan []string, 1) // want `chanopt: Singleton pattern — .*70% confidence.*; lower confidence: each receiver gets a \[\]string of its own`
+	go func() {
+		ch <- []string{"-v", "-count=1"}
+	}()
+	return ch
+}
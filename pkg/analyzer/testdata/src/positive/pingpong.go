@@ -0,0 +1,63 @@
+package positive
+
+func RunningTotals(nums []int) []int {
+	req := make(chan int) // want `chanopt: PingPong pattern — replace channel with sync.Mutex-guarded struct .*60% confidence`
+	resp := make(chan int)
+	go func() {
+		total := 0
+		for n := range req {
+			total += n
+			resp <- total
+		}
+	}()
+	out := make([]int, 0, len(nums))
+	for _, n := range nums {
+		req <- n
+		out = append(out, <-resp)
+	}
+	close(req)
+	return out
+}
+
+type command struct {
+	key string
+	val int
+}
+
+func Tally(cmds []command) int {
+	in := make(chan command) // want `chanopt: PingPong pattern`
+	done := make(chan bool)
+	go func() {
+		counts := map[string]int{}
+		for {
+			c := <-in
+			counts[c.key] += c.val
+			done <- counts[c.key] > 10
+		}
+	}()
+	over := 0
+	for _, c := range cmds {
+		in <- c
+		if <-done {
+			over++
+		}
+	}
+	return over
+}
+
+func Squares(nums []int) []int {
+	req, resp := make(chan int), make(chan int) // want `chanopt: PingPong pattern`
+	go func() {
+		for n := range req {
+			sq := n * n
+			resp <- sq
+		}
+	}()
+	var out []int
+	for _, n := range nums {
+		req <- n
+		out = append(out, <-resp)
+	}
+	close(req)
+	return out
+}
@@ -2,7 +2,7 @@ package positive
 
 import "time"
 
-func NewIDGenerator() <-chan int64 {
+func NewIDGenerator() <-chan int64 { // want NewIDGenerator:"producer\\(IDGenerator\\)"
 	ch := make(chan int64) // want `chanopt: IDGenerator pattern`
 	go func() {
 		var id int64
@@ -14,7 +14,7 @@ func NewIDGenerator() <-chan int64 {
 	return ch
 }
 
-func RoundRobin(backends []string) <-chan string {
+func RoundRobin(backends []string) <-chan string { // want RoundRobin:"producer\\(RoundRobin\\)"
 	ch := make(chan string) // want `chanopt: RoundRobin pattern`
 	go func() {
 		for i := 0; ; i = (i + 1) % len(backends) {
@@ -24,7 +24,7 @@ func RoundRobin(backends []string) <-chan string {
 	return ch
 }
 
-func Iterate(items []int) <-chan int {
+func Iterate(items []int) <-chan int { // want Iterate:"producer\\(BoundedIterator\\)"
 	ch := make(chan int) // want `chanopt: BoundedIterator pattern`
 	go func() {
 		defer close(ch)
@@ -35,7 +35,7 @@ func Iterate(items []int) <-chan int {
 	return ch
 }
 
-func Heartbeat(d time.Duration) <-chan struct{} {
+func Heartbeat(d time.Duration) <-chan struct{} { // want Heartbeat:"producer\\(ChanTicker\\)"
 	ch := make(chan struct{}) // want `chanopt: ChanTicker pattern`
 	go func() {
 		for {
@@ -46,7 +46,7 @@ func Heartbeat(d time.Duration) <-chan struct{} {
 	return ch
 }
 
-func RateLimited(rps int) <-chan struct{} {
+func RateLimited(rps int) <-chan struct{} { // want RateLimited:"producer\\(RateLimiter\\)"
 	ch := make(chan struct{}, rps) // want `chanopt: RateLimiter pattern`
 	go func() {
 		ticker := time.NewTicker(time.Second / time.Duration(rps))
@@ -57,3 +57,22 @@ func RateLimited(rps int) <-chan struct{} {
 	}()
 	return ch
 }
+
+func Answer() <-chan int { // want Answer:"producer\\(Singleton\\)"
+	ch := make(chan int, 1) // want `chanopt: Singleton pattern`
+	go func() {
+		ch <- 42 * 42
+	}()
+	return ch
+}
+
+func AfterHeartbeat(d time.Duration) <-chan struct{} { // want AfterHeartbeat:"producer\\(ChanTicker\\)"
+	ch := make(chan struct{}) // want `chanopt: ChanTicker pattern`
+	go func() {
+		for {
+			<-time.After(d)
+			ch <- struct{}{}
+		}
+	}()
+	return ch
+}
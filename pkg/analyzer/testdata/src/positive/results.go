@@ -0,0 +1,33 @@
+package positive
+
+import "strconv"
+
+func SquaresUnordered(in []int) []int {
+	results := make(chan int, len(in)) // want `chanopt: ResultCollector pattern`
+	for _, v := range in {
+		go func() {
+			results <- v * v
+		}()
+	}
+	var out []int
+	for range len(in) {
+		out = append(out, <-results)
+	}
+	return out
+}
+
+func Itoa(n int) []string {
+	ch := make(chan string, n) // want `chanopt: ResultCollector pattern`
+	for i := 0; i < n; i++ {
+		go func() {
+			s := strconv.Itoa(i)
+			ch <- s
+		}()
+	}
+	out := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		s := <-ch
+		out = append(out, s)
+	}
+	return out
+}
@@ -0,0 +1,29 @@
+package positive
+
+import "io"
+
+type Block struct {
+	Data []byte
+	Err  error
+}
+
+func Blocks(r io.Reader) <-chan Block {
+	ch := make(chan Block)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				ch <- Block{Err: io.ErrUnexpectedEOF} // want `chanopt: deferred send on ch runs after the deferred close\(ch\) on line 18, since defers run last-in, first-out, and panics — defer the close first, so that it runs last \[CHOPT108\]`
+			}
+		}()
+		defer close(ch)
+		for {
+			buf := make([]byte, 4096)
+			n, err := r.Read(buf)
+			if err != nil {
+				return
+			}
+			ch <- Block{Data: buf[:n]}
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,74 @@
+package positive
+
+import "sync"
+
+func CountByKind(events <-chan Event, n int) []map[string]int {
+	counts := make([]map[string]int, n)
+	shards := make([]chan Event, n)
+	var wg sync.WaitGroup
+	for i := range shards {
+		counts[i] = map[string]int{}
+		shards[i] = make(chan Event, 64)
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for e := range shards[i] {
+				counts[i][e.Kind]++
+			}
+		}(i)
+	}
+	go func() { // want `chanopt: ShardedDispatcher pattern — replace channel with sync.Mutex per shard, picked by key in the sender`
+		for e := range events {
+			shards[len(e.Kind)%n] <- e
+		}
+		for _, ch := range shards {
+			close(ch)
+		}
+	}()
+	wg.Wait()
+	return counts
+}
+
+type byteTotals struct {
+	small, large int
+}
+
+func (t *byteTotals) sumSmall(ch <-chan Event) {
+	for e := range ch {
+		t.small += e.Bytes
+	}
+}
+
+func sumLarge(ch <-chan Event, total *int, done chan<- struct{}) {
+	for e := range ch {
+		*total += e.Bytes
+	}
+	close(done)
+}
+
+// SplitBySize routes by a switch on the key rather than an index.
+func SplitBySize(events <-chan Event) int {
+	var t byteTotals
+	small, large := make(chan Event), make(chan Event)
+	done := make(chan struct{})
+	go func() {
+		for e := range small {
+			t.small += e.Bytes
+		}
+	}()
+	go sumLarge(large, &t.large, done)
+	go func() { // want `chanopt: ShardedDispatcher pattern`
+		for e := range events {
+			switch {
+			case e.Bytes < 1024:
+				small <- e
+			default:
+				large <- e
+			}
+		}
+		close(small)
+		close(large)
+	}()
+	<-done
+	return t.large
+}
@@ -0,0 +1,65 @@
+package positive
+
+import "context"
+
+// The stop func closes done, which the send selects on.
+func WatchIDs() (<-chan int64, func()) { // want WatchIDs:"producer\\(IDGenerator\\)"
+	ch := make(chan int64) // want `chanopt: IDGenerator pattern .*return a no-op stop func`
+	done := make(chan struct{})
+	go func() {
+		var id int64
+		for {
+			id++
+			select {
+			case <-done:
+				return
+			case ch <- id:
+			}
+		}
+	}()
+	return ch, func() { close(done) }
+}
+
+// The stop func is the context's cancel func.
+func CancellableIDs(parent context.Context) (<-chan int64, func()) { // want CancellableIDs:"producer\\(IDGenerator\\)"
+	ctx, cancel := context.WithCancel(parent)
+	ch := make(chan int64) // want `chanopt: IDGenerator pattern .*return a no-op stop func`
+	go func() {
+		var id int64
+		for {
+			id++
+			select {
+			case <-ctx.Done():
+				return
+			case ch <- id:
+			}
+		}
+	}()
+	return ch, cancel
+}
+
+// The goroutine never looks at done, so stop does nothing.
+func LeakyIDs() (<-chan int64, func()) { // want LeakyIDs:"producer\\(IDGenerator\\)"
+	ch := make(chan int64) // want `chanopt: IDGenerator pattern`
+	done := make(chan struct{})
+	go func() {
+		var id int64
+		for {
+			id++
+			ch <- id
+		}
+	}()
+	return ch, func() { close(done) } // want `chanopt: the stop func returned by LeakyIDs does not stop its goroutine`
+}
+
+func NoopStop() (<-chan int64, func()) { // want NoopStop:"producer\\(IDGenerator\\)"
+	ch := make(chan int64) // want `chanopt: IDGenerator pattern`
+	go func() {
+		var id int64
+		for {
+			id++
+			ch <- id
+		}
+	}()
+	return ch, func() {} // want `chanopt: the stop func returned by NoopStop does not stop its goroutine`
+}
@@ -0,0 +1,25 @@
+package positive
+
+import "time"
+
+func Every(d time.Duration) <-chan time.Time { // want Every:"producer\\(ChanTicker\\)"
+	ch := make(chan time.Time) // want `chanopt: ChanTicker pattern .*85% confidence.*; it only relays the ticker's ticks; return the \*time.Ticker, or its C field, instead`
+	t := time.NewTicker(d)
+	go func() {
+		for v := range t.C {
+			ch <- v
+		}
+	}()
+	return ch
+}
+
+func Ticks(t *time.Ticker) <-chan time.Time { // want Ticks:"producer\\(ChanTicker\\)"
+	ch := make(chan time.Time, 1) // want `chanopt: ChanTicker pattern .*; it only relays the ticker's ticks`
+	go func() {
+		for {
+			now := <-t.C
+			ch <- now
+		}
+	}()
+	return ch
+}
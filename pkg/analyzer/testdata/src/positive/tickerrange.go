@@ -0,0 +1,27 @@
+package positive
+
+import "time"
+
+// The ticker is created outside the goroutine; ranging over its channel is
+// not a pipeline stage.
+func PacedTokens(rps int) <-chan struct{} { // want PacedTokens:"producer\\(RateLimiter\\)"
+	ch := make(chan struct{}, rps) // want `chanopt: RateLimiter pattern`
+	t := time.NewTicker(time.Second / time.Duration(rps))
+	go func() {
+		for range t.C {
+			ch <- struct{}{}
+		}
+	}()
+	return ch
+}
+
+func TickTokens(d time.Duration) <-chan struct{} { // want TickTokens:"producer\\(RateLimiter\\)"
+	ch := make(chan struct{}, 1) // want `chanopt: RateLimiter pattern`
+	tick := time.Tick(d)
+	go func() {
+		for range tick {
+			ch <- struct{}{}
+		}
+	}()
+	return ch
+}
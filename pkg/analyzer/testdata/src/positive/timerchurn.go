@@ -0,0 +1,31 @@
+//go:build go1.22
+
+package positive
+
+import (
+	"time"
+	clock "time"
+)
+
+func LegacyAfterHeartbeat(d time.Duration) <-chan struct{} { // want LegacyAfterHeartbeat:"producer\\(ChanTicker\\)"
+	ch := make(chan struct{}) // want `chanopt: ChanTicker pattern .*; before Go 1.23 each time.After in the loop also leaks a timer`
+	go func() {
+		for {
+			<-time.After(d)
+			ch <- struct{}{}
+		}
+	}()
+	return ch
+}
+
+// The time package is resolved by type, not by the name it is imported as.
+func LegacyClockHeartbeat(d time.Duration) <-chan struct{} { // want LegacyClockHeartbeat:"producer\\(ChanTicker\\)"
+	ch := make(chan struct{}) // want `chanopt: ChanTicker pattern .*; before Go 1.23 each time.After in the loop also leaks a timer`
+	go func() {
+		for {
+			<-clock.After(d)
+			ch <- struct{}{}
+		}
+	}()
+	return ch
+}
@@ -0,0 +1,135 @@
+package sendclose // want package:"stats\\(BoundedIterator=3 ErrorIterator=3 IDGenerator=1 SendAfterClose=4 StopLeak=1\\)"
+
+import "fmt"
+
+type Result struct {
+	N   int
+	Err error
+}
+
+// The recover handler is deferred first, so it runs after close(ch).
+func Results(items []int) <-chan Result { // want Results:"producer\\(ErrorIterator\\)"
+	ch := make(chan Result) // want `chanopt: ErrorIterator pattern .* \[CHOPT018\]`
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				ch <- Result{Err: fmt.Errorf("panic: %v", r)} // want `chanopt: deferred send on ch runs after the deferred close\(ch\) on line 19, since defers run last-in, first-out, and panics — defer the close first, so that it runs last \[CHOPT108\]`
+			}
+		}()
+		defer close(ch)
+		for _, n := range items {
+			ch <- Result{N: n}
+		}
+	}()
+	return ch
+}
+
+// The deferred send runs on the way out, after the close in the loop.
+func Drain(items []int) <-chan int { // want Drain:"producer\\(BoundedIterator\\)"
+	ch := make(chan int) // want `chanopt: BoundedIterator pattern .* \[CHOPT005\]`
+	go func() {
+		defer func() { ch <- -1 }() // want `chanopt: deferred send on ch runs when the goroutine returns, after close\(ch\) on line 34, and panics — close ch in a defer registered before this one \[CHOPT108\]`
+		for _, n := range items {
+			if n < 0 {
+				close(ch)
+				return
+			}
+			ch <- n
+		}
+	}()
+	return ch
+}
+
+// close(ch) is not followed by a return, so the loop sends again.
+func Until(items []int) <-chan int { // want Until:"producer\\(BoundedIterator\\)"
+	ch := make(chan int) // want `chanopt: BoundedIterator pattern .* \[CHOPT005\]`
+	go func() {
+		for _, n := range items {
+			if n == 0 {
+				close(ch)
+			}
+			ch <- n // want `chanopt: send on ch can follow close\(ch\) on line 49 and panic — return after closing, or close ch once in a defer \[CHOPT108\]`
+		}
+	}()
+	return ch
+}
+
+// The stop func closes the channel the goroutine sends on.
+func Watch() (<-chan int, func()) { // want Watch:"producer\\(IDGenerator\\)"
+	ch := make(chan int) // want `chanopt: IDGenerator pattern .* \[CHOPT001\]`
+	go func() {
+		n := 0
+		for {
+			n++
+			ch <- n
+		}
+	}()
+	return ch, func() { close(ch) } // want `chanopt: stop func closes ch while the goroutine may still send on it, which panics — close a separate done channel that the goroutine selects on, and let it close ch \[CHOPT108\]` `chanopt: the stop func returned by Watch does not stop its goroutine .* \[CHOPT102\]`
+}
+
+// Not reported: the close is deferred first, so it runs last.
+func Ordered(items []int) <-chan Result { // want Ordered:"producer\\(ErrorIterator\\)"
+	ch := make(chan Result) // want `chanopt: ErrorIterator pattern .* \[CHOPT018\]`
+	go func() {
+		defer close(ch)
+		defer func() {
+			if r := recover(); r != nil {
+				ch <- Result{Err: fmt.Errorf("panic: %v", r)}
+			}
+		}()
+		for _, n := range items {
+			ch <- Result{N: n}
+		}
+	}()
+	return ch
+}
+
+// Not reported: the loop is left after closing, and nothing sends after it.
+func Stop(items []int) <-chan int { // want Stop:"producer\\(BoundedIterator\\)"
+	ch := make(chan int) // want `chanopt: BoundedIterator pattern .* \[CHOPT005\]`
+	go func() {
+		for _, n := range items {
+			if n == 0 {
+				close(ch)
+				break
+			}
+			ch <- n
+		}
+	}()
+	return ch
+}
+
+// Not reported: the close is in a select case that returns.
+func Select(items []int, done <-chan struct{}) <-chan int {
+	ch := make(chan int)
+	go func() {
+		for _, n := range items {
+			select {
+			case <-done:
+				close(ch)
+				return
+			case ch <- n:
+			}
+		}
+		close(ch)
+	}()
+	return ch
+}
+
+// Not reported: the send is ignored, although it runs after close(ch).
+func Logged(items []int) <-chan Result { // want Logged:"producer\\(ErrorIterator\\)"
+	ch := make(chan Result) // want `chanopt: ErrorIterator pattern .* \[CHOPT018\]`
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				//chanopt:ignore SendAfterClose the caller recovers
+				ch <- Result{Err: fmt.Errorf("panic: %v", r)}
+			}
+		}()
+		defer close(ch)
+		for _, n := range items {
+			ch <- Result{N: n}
+		}
+	}()
+	return ch
+}
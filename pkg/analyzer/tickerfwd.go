@@ -0,0 +1,82 @@
+package analyzer
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// forwardsTicker reports whether cp only relays the ticks of a time.Ticker
+// created elsewhere to its own chan time.Time:
+//
+//	func Every(d time.Duration) <-chan time.Time {
+//	    ch := make(chan time.Time)
+//	    t := time.NewTicker(d)
+//	    go func() {
+//	        for v := range t.C {
+//	            ch <- v
+//	        }
+//	    }()
+//	    return ch
+//	}
+//
+// Every send must pass on a value received from a ticker's C field,
+// directly, through the range variable, or through a variable assigned
+// from the receive.
+func forwardsTicker(pass *analysis.Pass, cp channelProducer) bool {
+	if cp.chanType == nil || !isNamed(cp.chanType.Elem(), "time", "Time") {
+		return false
+	}
+	ticks := map[types.Object]bool{}
+	ast.Inspect(cp.funcLit.Body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.RangeStmt:
+			if id, ok := n.Key.(*ast.Ident); ok && isTickerC(pass, n.X) {
+				ticks[pass.TypesInfo.ObjectOf(id)] = true
+			}
+		case *ast.AssignStmt:
+			if len(n.Rhs) == 1 && isTickRecv(pass, n.Rhs[0]) {
+				if id, ok := n.Lhs[0].(*ast.Ident); ok {
+					ticks[pass.TypesInfo.ObjectOf(id)] = true
+				}
+			}
+		}
+		return true
+	})
+	for _, s := range cp.sends {
+		v := ast.Unparen(s.Value)
+		if id, ok := v.(*ast.Ident); ok && ticks[pass.TypesInfo.ObjectOf(id)] {
+			continue
+		}
+		if !isTickRecv(pass, v) {
+			return false
+		}
+	}
+	return len(cp.sends) > 0
+}
+
+// isTickRecv reports whether e is <-t.C for a time.Ticker t.
+func isTickRecv(pass *analysis.Pass, e ast.Expr) bool {
+	u, ok := ast.Unparen(e).(*ast.UnaryExpr)
+	return ok && u.Op == token.ARROW && isTickerC(pass, u.X)
+}
+
+// isTickerC reports whether e is the C field of a time.Ticker.
+func isTickerC(pass *analysis.Pass, e ast.Expr) bool {
+	sel, ok := ast.Unparen(e).(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "C" {
+		return false
+	}
+	t := pass.TypesInfo.TypeOf(sel.X)
+	if p, ok := t.(*types.Pointer); ok {
+		t = p.Elem()
+	}
+	return t != nil && isNamed(t, "time", "Ticker")
+}
+
+func isNamed(t types.Type, pkg, name string) bool {
+	n, ok := types.Unalias(t).(*types.Named)
+	return ok && n.Obj().Pkg() != nil && n.Obj().Pkg().Path() == pkg && n.Obj().Name() == name
+}
@@ -0,0 +1,103 @@
+// Package chanopttest runs chanopt from go test, for projects that enforce
+// its checks in their test pipeline rather than through go vet -vettool or
+// a separate CI step:
+//
+//	func TestChanopt(t *testing.T) {
+//	    chanopttest.Run(t)
+//	}
+//
+// The test analyzes the package it belongs to and fails with one error per
+// finding, printed as chanopt prints it. The .chanopt.yaml files from the
+// package's directory upward apply as they do to the chanopt command, so
+// disable, ignore and min_confidence entries, and //chanopt:ignore
+// directives, keep working.
+package chanopttest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/ravisastryk/chanopt/internal/config"
+	"github.com/ravisastryk/chanopt/internal/driver"
+)
+
+// Options sets which findings fail the test.
+type Options struct {
+	// Dir is the directory of the package to analyze; "" means the
+	// current one, which go test sets to the directory of the package
+	// under test.
+	Dir string
+
+	// Tests also analyzes the package's _test.go files.
+	Tests bool
+
+	// MinConfidence, if set, ignores findings with a lower confidence, in
+	// place of the min_confidence of the configuration files.
+	MinConfidence float64
+
+	// MaxFindings is how many findings the test tolerates before it
+	// fails, for a package that is working its way down to none.
+	MaxFindings int
+}
+
+// Run analyzes the package of the test with the default options and fails
+// t if any finding remains. With patterns, only findings of those
+// patterns and checks, by name or rule ID, count.
+func Run(t testing.TB, patterns ...string) {
+	t.Helper()
+	Options{}.Run(t, patterns...)
+}
+
+// Run analyzes the package with the options and fails t if more than
+// MaxFindings findings remain. With patterns, only findings of those
+// patterns and checks, by name or rule ID, count.
+func (o Options) Run(t testing.TB, patterns ...string) {
+	t.Helper()
+	if err := config.CheckNames(patterns); err != nil {
+		t.Fatalf("chanopttest: %v", err)
+	}
+	dirs := &config.Resolver{Top: &config.Config{Set: map[string]bool{}}}
+	if o.MinConfidence > 0 {
+		dirs.Top.MinConfidence, dirs.Top.Set["min_confidence"] = o.MinConfidence, true
+	}
+	findings, err := driver.Run(&driver.Config{Dir: o.Dir, Tests: o.Tests, Dirs: dirs}, ".")
+	if err != nil {
+		t.Fatalf("chanopttest: %v", err)
+	}
+	if len(patterns) > 0 {
+		findings = slices.DeleteFunc(findings, func(f driver.Finding) bool {
+			return !slices.Contains(patterns, f.Pattern) && !slices.Contains(patterns, f.Rule)
+		})
+	}
+	if len(findings) <= o.MaxFindings {
+		return
+	}
+	for _, f := range findings {
+		t.Errorf("%s", format(f))
+	}
+	if o.MaxFindings > 0 {
+		t.Errorf("chanopttest: %d findings, more than the %d allowed", len(findings), o.MaxFindings)
+	}
+}
+
+// format returns the finding as the chanopt command prints it, with the
+// file name relative to the current directory where it is beneath it.
+func format(f driver.Finding) string {
+	posn := f.Posn
+	if wd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(wd, posn.Filename); err == nil && filepath.IsLocal(rel) {
+			posn.Filename = rel
+		}
+	}
+	s := fmt.Sprintf("%s: %s", posn, f.Message)
+	if f.Rule != "" {
+		s += " [" + f.Rule + "]"
+	}
+	for _, r := range f.Related {
+		s += fmt.Sprintf("\n\t%s: %s", r.Posn, r.Message)
+	}
+	return s
+}
@@ -0,0 +1,86 @@
+package chanopttest_test
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/ravisastryk/chanopt/pkg/analyzer"
+	"github.com/ravisastryk/chanopt/pkg/chanopttest"
+)
+
+// recorder records the errors a test would fail with. Like the testing
+// package's, its Fatalf stops the goroutine that calls it, so Run must be
+// called on a goroutine of its own; see run.
+type recorder struct {
+	testing.TB
+	errs []string
+}
+
+// run calls f with r on a new goroutine and waits for it to return or stop.
+func (r *recorder) run(f func(testing.TB)) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f(r)
+	}()
+	<-done
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errs = append(r.errs, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.Errorf(format, args...)
+	runtime.Goexit()
+}
+
+func TestRun(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		opts     chanopttest.Options
+		patterns []string
+		want     []string // a substring of each error
+	}{
+		{"all", chanopttest.Options{}, nil, []string{"IDGenerator pattern", "BoundedIterator pattern"}},
+		{"patterns", chanopttest.Options{}, []string{"CHOPT001"}, []string{"IDGenerator pattern"}},
+		{"confidence", chanopttest.Options{MinConfidence: 0.99}, nil, nil},
+		{"tolerated", chanopttest.Options{MaxFindings: 2}, nil, nil},
+		{"too many", chanopttest.Options{MaxFindings: 1}, nil, []string{"IDGenerator pattern", "BoundedIterator pattern", "2 findings, more than the 1 allowed"}},
+		{"unknown", chanopttest.Options{}, []string{"NoSuchPattern"}, []string{`"NoSuchPattern"`}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &recorder{TB: t}
+			tt.opts.Dir = "testdata/ids"
+			r.run(func(tb testing.TB) { tt.opts.Run(tb, tt.patterns...) })
+			if len(r.errs) != len(tt.want) {
+				t.Fatalf("got errors %q, want %d", r.errs, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(r.errs[i], want) {
+					t.Errorf("error %d = %q, want it to contain %q", i, r.errs[i], want)
+				}
+			}
+		})
+	}
+}
+
+// TestRunParallel runs Run from parallel tests. Run must leave the
+// analyzer's flags, which the whole test binary shares, alone.
+func TestRunParallel(t *testing.T) {
+	t.Run("group", func(t *testing.T) {
+		for _, name := range []string{"a", "b"} {
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+				chanopttest.Options{Dir: "testdata/ids", MaxFindings: 2}.Run(t)
+			})
+		}
+	})
+	if got := analyzer.Analyzer.Flags.Lookup("pipeline").Value.String(); got != "false" {
+		t.Errorf("after Run, the pipeline flag is %s, want false", got)
+	}
+}
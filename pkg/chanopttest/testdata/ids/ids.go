@@ -0,0 +1,26 @@
+package ids
+
+// IDs hands out increasing IDs.
+func IDs() <-chan int64 {
+	ch := make(chan int64)
+	go func() {
+		var n int64
+		for {
+			n++
+			ch <- n
+		}
+	}()
+	return ch
+}
+
+// Words yields each word.
+func Words(words []string) <-chan string {
+	ch := make(chan string)
+	go func() {
+		defer close(ch)
+		for _, w := range words {
+			ch <- w
+		}
+	}()
+	return ch
+}
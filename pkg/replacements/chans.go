@@ -0,0 +1,64 @@
+package replacements
+
+import (
+	"context"
+	"sync"
+)
+
+// OrDone forwards values from c until c is closed or ctx is done, then
+// closes the returned channel. The forwarding goroutine never blocks past
+// cancellation, on either the receive or the send.
+//
+// Prefer selecting on ctx.Done() where c is consumed; OrDone is for code
+// that wants to keep ranging over a channel:
+//
+//	for v := range replacements.OrDone(ctx, results) { ... }
+func OrDone[T any](ctx context.Context, c <-chan T) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case v, ok := <-c:
+				if !ok {
+					return
+				}
+				select {
+				case out <- v:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out
+}
+
+// Merge forwards the values of every input channel to the returned
+// channel, which is closed once all inputs are closed or ctx is done.
+// Unlike the usual hand-rolled merge, cancelling ctx releases every
+// forwarding goroutine even if the consumer has stopped receiving.
+func Merge[T any](ctx context.Context, cs ...<-chan T) <-chan T {
+	out := make(chan T)
+	var wg sync.WaitGroup
+	wg.Add(len(cs))
+	for _, c := range cs {
+		go func() {
+			defer wg.Done()
+			for v := range OrDone(ctx, c) {
+				select {
+				case out <- v:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
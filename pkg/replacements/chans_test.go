@@ -0,0 +1,66 @@
+package replacements_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ravisastryk/chanopt/pkg/replacements"
+)
+
+func feed(vals ...int) <-chan int {
+	c := make(chan int, len(vals))
+	for _, v := range vals {
+		c <- v
+	}
+	close(c)
+	return c
+}
+
+func TestMergeForwardsEverything(t *testing.T) {
+	var got []int
+	for v := range replacements.Merge(context.Background(), feed(1, 2), feed(3), feed()) {
+		got = append(got, v)
+	}
+	slices.Sort(got)
+	if !slices.Equal(got, []int{1, 2, 3}) {
+		t.Errorf("Merge = %v, want [1 2 3]", got)
+	}
+}
+
+func TestOrDoneStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	never := make(chan int) // never closed: only ctx can end the loop
+	out := replacements.OrDone(ctx, never)
+	cancel()
+	select {
+	case _, ok := <-out:
+		if ok {
+			t.Fatal("OrDone forwarded a value that was never sent")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("OrDone did not close its output after cancellation")
+	}
+}
+
+func TestMergeStopsOnCancelWithoutConsumer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	open := make(chan int, 2) // never closed: only ctx can end the merge
+	open <- 1
+	open <- 2
+	out := replacements.Merge(ctx, open, feed(3, 4))
+	<-out // take one value, then abandon the rest
+	cancel()
+	done := make(chan struct{})
+	go func() {
+		for range out {
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Merge did not close its output after cancellation")
+	}
+}
@@ -0,0 +1,48 @@
+// Package replacements provides drop-in helpers that chanopt suggests in
+// place of channel-based idioms.
+package replacements
+
+import (
+	"runtime"
+	"sync"
+)
+
+// ParallelFor calls fn over [0, n) split into contiguous chunks, one
+// goroutine per chunk, and waits for all of them. workers <= 0 means
+// runtime.GOMAXPROCS(0). Each index is visited exactly once; fn must be
+// safe to call concurrently for disjoint ranges.
+//
+// It replaces the jobs-channel worker pool for cheap per-item work, where
+// the channel handoff costs more than the work itself:
+//
+//	replacements.ParallelFor(len(in), 0, func(lo, hi int) {
+//		for i := lo; i < hi; i++ {
+//			out[i] = in[i] * in[i]
+//		}
+//	})
+func ParallelFor(n, workers int, fn func(lo, hi int)) {
+	if n <= 0 {
+		return
+	}
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > n {
+		workers = n
+	}
+	if workers == 1 {
+		fn(0, n)
+		return
+	}
+	chunk := (n + workers - 1) / workers
+	var wg sync.WaitGroup
+	for lo := 0; lo < n; lo += chunk {
+		hi := min(lo+chunk, n)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn(lo, hi)
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,25 @@
+package replacements_test
+
+import (
+	"testing"
+
+	"github.com/ravisastryk/chanopt/pkg/replacements"
+)
+
+func TestParallelForVisitsEachIndexOnce(t *testing.T) {
+	for _, tc := range []struct{ n, workers int }{
+		{0, 4}, {1, 4}, {7, 3}, {100, 0}, {5, 10}, {64, 1},
+	} {
+		hits := make([]int, tc.n)
+		replacements.ParallelFor(tc.n, tc.workers, func(lo, hi int) {
+			for i := lo; i < hi; i++ {
+				hits[i]++
+			}
+		})
+		for i, h := range hits {
+			if h != 1 {
+				t.Errorf("n=%d workers=%d: index %d visited %d times", tc.n, tc.workers, i, h)
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// Package templates holds the canonical before and after code of each
+// chanopt pattern: the channel code chanopt reports and what it suggests
+// in its place. The rules page and the gen-testdata fixtures are
+// generated from it, and code that explains, benchmarks or fixes a pattern
+// should take its examples from here rather than keep its own copy.
+//
+// Each example is a txtar archive in examples/, named after the pattern in
+// lower case, with a comment saying what the before code does and two
+// files, before.go and after.go, each a complete file of package example.
+package templates
+
+import (
+	"embed"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/ravisastryk/chanopt/pkg/analyzer"
+	"golang.org/x/tools/txtar"
+)
+
+//go:embed examples/*.txtar
+var files embed.FS
+
+// Example is the canonical before and after code of a pattern.
+type Example struct {
+	Pattern analyzer.Pattern
+	Summary string // what the before code does, one line
+	Before  string // a file of package example using channels
+	After   string // the same API, or its replacement, without them
+}
+
+var examples = map[analyzer.Pattern]Example{}
+
+func init() {
+	entries, err := files.ReadDir("examples")
+	if err != nil {
+		panic(err)
+	}
+	for _, e := range entries {
+		name := strings.TrimSuffix(e.Name(), ".txtar")
+		p, err := analyzer.ParsePattern(name)
+		if err != nil {
+			panic(fmt.Sprintf("templates: %s: %v", e.Name(), err))
+		}
+		data, err := files.ReadFile("examples/" + e.Name())
+		if err != nil {
+			panic(err)
+		}
+		ar := txtar.Parse(data)
+		ex := Example{Pattern: p, Summary: strings.TrimSpace(string(ar.Comment))}
+		for _, f := range ar.Files {
+			switch f.Name {
+			case "before.go":
+				ex.Before = string(f.Data)
+			case "after.go":
+				ex.After = string(f.Data)
+			default:
+				panic(fmt.Sprintf("templates: %s: unexpected file %s", e.Name(), f.Name))
+			}
+		}
+		if ex.Before == "" || ex.After == "" {
+			panic(fmt.Sprintf("templates: %s: want before.go and after.go", e.Name()))
+		}
+		examples[p] = ex
+	}
+}
+
+// For returns the example of p, if there is one.
+func For(p analyzer.Pattern) (Example, bool) {
+	ex, ok := examples[p]
+	return ex, ok
+}
+
+// All returns the examples in pattern order.
+func All() []Example {
+	var all []Example
+	for _, p := range slices.Sorted(maps.Keys(examples)) {
+		all = append(all, examples[p])
+	}
+	return all
+}
@@ -0,0 +1,90 @@
+package templates_test
+
+import (
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ravisastryk/chanopt/internal/driver"
+	"github.com/ravisastryk/chanopt/pkg/analyzer"
+	"github.com/ravisastryk/chanopt/pkg/templates"
+)
+
+func TestEveryPatternHasExample(t *testing.T) {
+	for p := range analyzer.Registry {
+		if _, ok := templates.For(p); !ok {
+			t.Errorf("no example for %s: add examples/%s.txtar", p, p)
+		}
+	}
+	if got, want := len(templates.All()), len(analyzer.Registry); got != want {
+		t.Errorf("All() returned %d examples, want %d", got, want)
+	}
+}
+
+// TestExamplesCompile type-checks the before and after file of every
+// example, so the docs never show code that does not build, and checks
+// that they are gofmt-ed.
+func TestExamplesCompile(t *testing.T) {
+	fset := token.NewFileSet()
+	imp := importer.ForCompiler(fset, "source", nil)
+	for _, ex := range templates.All() {
+		for name, src := range map[string]string{"before.go": ex.Before, "after.go": ex.After} {
+			t.Run(ex.Pattern.String()+"/"+name, func(t *testing.T) {
+				if out, err := format.Source([]byte(src)); err != nil || string(out) != src {
+					t.Errorf("not gofmt-ed: %v", err)
+				}
+				f, err := parser.ParseFile(fset, name, src, parser.SkipObjectResolution)
+				if err != nil {
+					t.Fatal(err)
+				}
+				conf := types.Config{Importer: imp}
+				if _, err := conf.Check("example", fset, []*ast.File{f}, nil); err != nil {
+					t.Fatal(err)
+				}
+			})
+		}
+	}
+}
+
+// TestExamplesDetected runs the analyzer over the before file of every
+// example and requires it to report the example's pattern, so the docs
+// never show channel code that chanopt would not flag. Patterns that are
+// not Detectable are skipped.
+func TestExamplesDetected(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/m\n\ngo 1.23\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, ex := range templates.All() {
+		pkg := filepath.Join(dir, strings.ToLower(ex.Pattern.String()))
+		if err := os.Mkdir(pkg, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(pkg, "before.go"), []byte(ex.Before), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	findings, err := driver.Run(&driver.Config{Dir: dir}, "./...")
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := map[string]bool{}
+	for _, f := range findings {
+		if filepath.Base(filepath.Dir(f.Posn.Filename)) == strings.ToLower(f.Pattern) {
+			found[f.Pattern] = true
+		}
+	}
+	for _, ex := range templates.All() {
+		name := ex.Pattern.String()
+		if analyzer.Detectable(ex.Pattern) && !found[name] {
+			t.Errorf("%s: chanopt does not report the before code of examples/%s.txtar", name, strings.ToLower(name))
+		}
+	}
+}
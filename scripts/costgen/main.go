@@ -0,0 +1,166 @@
+// Command costgen turns demos benchmark output into the analyzer cost model.
+//
+// Usage:
+//
+//	cd demos && go test -bench=. -benchmem -count=5 > bench.txt
+//	go run ./scripts/costgen -in demos/bench.txt -out pkg/analyzer/costmodel_gen.go
+//
+// Each pattern has a pair of benchmarks named Benchmark<Prefix>_Channel and
+// Benchmark<Prefix>_<Replacement>. Generator patterns, whose Channel
+// benchmark uses a buffered channel, may add Benchmark<Prefix>_Unbuffered.
+// Repeated runs are reduced to their median.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// prefixes maps benchmark name prefixes to analyzer pattern names.
+var prefixes = map[string]string{
+	"IDGen":      "IDGenerator",
+	"RR":         "RoundRobin",
+	"RateLimit":  "RateLimiter",
+	"Config":     "ConfigBroadcaster",
+	"Iter":       "BoundedIterator",
+	"CB":         "CircuitBreaker",
+	"Sem":        "ChanSemaphore",
+	"Singleton":  "Singleton",
+	"FanIn":      "FixedFanIn",
+	"Ticker":     "ChanTicker",
+	"FanOut":     "FanOutDispatcher",
+	"LazyInit":   "LazyInit",
+	"Results":    "ResultCollector",
+	"PingPong":   "PingPong",
+	"Aggregator": "AggregatorQueue",
+	"Sharded":    "ShardedDispatcher",
+	"Memo":       "MemoCache",
+	"ErrIter":    "ErrorIterator",
+}
+
+type sample struct {
+	ns     []float64
+	bytes  []float64
+	allocs []float64
+}
+
+func main() {
+	in := flag.String("in", "demos/bench.txt", "benchmark output to read")
+	out := flag.String("out", "pkg/analyzer/costmodel_gen.go", "Go file to write")
+	flag.Parse()
+
+	f, err := os.Open(*in)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	// samples[pattern][side] where side is "Channel", "Unbuffered" or
+	// "Optimized".
+	samples := map[string]map[string]*sample{}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 8 || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+		name := strings.TrimPrefix(fields[0], "Benchmark")
+		if i := strings.LastIndexByte(name, '-'); i >= 0 {
+			name = name[:i] // strip GOMAXPROCS suffix
+		}
+		prefix, impl, ok := strings.Cut(name, "_")
+		pat, known := prefixes[prefix]
+		if !ok || !known {
+			continue
+		}
+		side := "Optimized"
+		if impl == "Channel" || impl == "Unbuffered" {
+			side = impl
+		}
+		if samples[pat] == nil {
+			samples[pat] = map[string]*sample{}
+		}
+		s := samples[pat][side]
+		if s == nil {
+			s = &sample{}
+			samples[pat][side] = s
+		}
+		s.ns = append(s.ns, metric(fields, "ns/op"))
+		s.bytes = append(s.bytes, metric(fields, "B/op"))
+		s.allocs = append(s.allocs, metric(fields, "allocs/op"))
+	}
+	if err := sc.Err(); err != nil {
+		log.Fatal(err)
+	}
+
+	pats := make([]string, 0, len(samples))
+	for p, sides := range samples {
+		if sides["Channel"] == nil || sides["Optimized"] == nil {
+			log.Fatalf("costgen: pattern %s is missing one side of its benchmark pair", p)
+		}
+		pats = append(pats, p)
+	}
+	sort.Strings(pats)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by scripts/costgen from %s; DO NOT EDIT.\n\n", *in)
+	buf.WriteString("package analyzer\n\n")
+	buf.WriteString("// Costs holds the measured cost model for each pattern.\n")
+	buf.WriteString("var Costs = map[Pattern]CostModel{\n")
+	for _, p := range pats {
+		fmt.Fprintf(&buf, "\t%s: {\n", p)
+		fmt.Fprintf(&buf, "\t\tChannel:   %s,\n", costLit(samples[p]["Channel"]))
+		if s := samples[p]["Unbuffered"]; s != nil {
+			fmt.Fprintf(&buf, "\t\tUnbuffered: %s,\n", costLit(s))
+		}
+		fmt.Fprintf(&buf, "\t\tOptimized: %s,\n", costLit(samples[p]["Optimized"]))
+		buf.WriteString("\t},\n")
+	}
+	buf.WriteString("}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// metric returns the value preceding unit in a benchmark result line.
+func metric(fields []string, unit string) float64 {
+	for i := 1; i < len(fields); i++ {
+		if fields[i] == unit {
+			v, err := strconv.ParseFloat(fields[i-1], 64)
+			if err != nil {
+				log.Fatalf("costgen: bad %s value %q", unit, fields[i-1])
+			}
+			return v
+		}
+	}
+	log.Fatalf("costgen: %s missing from %q (run with -benchmem)", unit, strings.Join(fields, " "))
+	return 0
+}
+
+func costLit(s *sample) string {
+	return fmt.Sprintf("Cost{NsPerOp: %s, BytesPerOp: %d, AllocsPerOp: %d}",
+		strconv.FormatFloat(median(s.ns), 'f', -1, 64),
+		int64(median(s.bytes)), int64(median(s.allocs)))
+}
+
+func median(vs []float64) float64 {
+	sort.Float64s(vs)
+	n := len(vs)
+	if n%2 == 1 {
+		return vs[n/2]
+	}
+	return (vs[n/2-1] + vs[n/2]) / 2
+}